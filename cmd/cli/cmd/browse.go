@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/snippetaccumulator/snac/internal/backend/request"
+	"github.com/snippetaccumulator/snac/internal/cli/browse"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var browseCmd = &cobra.Command{
+	Use:     "browse",
+	Aliases: []string{"b"},
+	Args:    cobra.NoArgs,
+	Short:   "Browse the snippets of the team in a full-screen interface",
+	Long: `Shows the snippets of the team in a list with a preview of the selected one.
+Type / to filter the list by title and tags. The selected snippet can be copied (c), edited (e),
+retagged (t) and deleted (d), and new ones created (n). snac without a command starts it as well.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runBrowse()
+	},
+}
+
+func runBrowse() {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		log.Error(true, "snac browse needs a terminal")
+	}
+	if dryRunParameter {
+		log.Error(true, "snac browse does not support --dry-run")
+	}
+
+	clipboard := systemClipboard()
+	err := browse.Run(browse.Config{
+		Team:     config.TeamName,
+		Requests: func() *request.RequestBuilder { return teamRequest(false) },
+		Pipeline: pipeline,
+		DB:       db,
+		Copy: func(content string) error {
+			_, err := clipboard.Copy(content)
+			return err
+		},
+		Editor: editorCommand(),
+		Style:  config.Theme,
+	})
+	log.Err(true, err)
+}
+
+func init() {
+	rootCmd.AddCommand(browseCmd)
+}
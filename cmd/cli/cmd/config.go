@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/cli"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var configShowSecretsParameter bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show, change and validate the config",
+	Long: `Every setting of the config file can be overridden by an environment variable, e.g. SNAC_TEAM_NAME for team_name
+or SNAC_DATABASE_URL for database.url. SNAC_PROFILE picks the profile like --profile.
+Flags take precedence over the environment, which takes precedence over the config file and its profile,
+which take precedence over the defaults.`,
+}
+
+var configPathCmd = &cobra.Command{
+	Use:         "path",
+	Args:        cobra.NoArgs,
+	Short:       "Print the path of the config file",
+	Annotations: map[string]string{offlineAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(configLoc)
+		if _, err := os.Stat(configLoc); os.IsNotExist(err) {
+			log.Info("The config file does not exist yet, snac config set or --create-config creates it")
+		}
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:         "show",
+	Args:        cobra.NoArgs,
+	Short:       "Show the settings in effect with their environment variables",
+	Long:        `Shows the settings in effect after the config file, the profile, the environment and flags. Credentials are hidden unless --secrets is given.`,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if configShowSecretsParameter {
+			unlockSecrets()
+		}
+		for _, field := range config.Fields() {
+			value := field.Value()
+			if field.Secret() && value != "" && !configShowSecretsParameter {
+				value = "(hidden)"
+			}
+			fmt.Printf("%-38s %-40s %s\n", field.Key, value, field.Env)
+		}
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Args:  cobra.ExactArgs(2),
+	Short: "Change a setting in the config file",
+	Long: `Changes a setting in the config file, e.g. snac config set database.password_policy.min_length 12.
+With --profile or SNAC_PROFILE the setting of that profile is changed instead. The config file is created if it does not exist,
+a config that does not load can be fixed with it.`,
+	Annotations: map[string]string{offlineAnnotation: "true", repairAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		key, value := args[0], args[1]
+		profile := cli.ProfileName(profileParameter, os.LookupEnv)
+		updateConfigFile(cli.Field{Key: key}.Secret(), func(fileConfig *cli.Config) {
+			var field cli.Field
+			var err error
+			if profile != "" {
+				_, err = fileConfig.Credentials(profile)
+				log.Err(true, err)
+				field, err = fileConfig.Profiles[profile].Field(key)
+			} else {
+				field, err = fileConfig.Field(key)
+			}
+			log.Err(true, err)
+			log.Err(true, field.Set(value))
+			log.Err(true, fileConfig.Validate())
+		})
+		if profile != "" {
+			log.Success("Set %s of profile '%s'", key, profile)
+			return
+		}
+		log.Success("Set %s", key)
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Args:  cobra.NoArgs,
+	Short: "Check the config file and the settings in effect",
+	Long: `Checks the config file for unknown keys and the settings in effect for invalid values, a team and a backend.
+Every problem is reported with its key, the exit code is 2 if there is any.`,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		var failures []error
+		data, err := os.ReadFile(configLoc)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Err(true, err)
+		}
+		if err == nil {
+			failures = append(failures, cli.CheckKeys(data))
+			if plaintext := plaintextSecrets(); len(plaintext) > 0 {
+				log.Warn("The config file has credentials in plaintext (%s), snac vault init moves them into an encrypted store", strings.Join(plaintext, ", "))
+			}
+		}
+		failures = append(failures, config.Validate())
+		if config.TeamName == "" {
+			failures = append(failures, errs.New(errs.ErrInvalid, "team_name: no team is set"))
+		}
+		if config.Server == "" && config.Database.Url == "" {
+			failures = append(failures, errs.New(errs.ErrInvalid, "server: neither a server nor a database.url is set"))
+		}
+
+		if err := errors.Join(failures...); err != nil {
+			log.Err(true, err)
+		}
+		log.Success("The config is valid")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configPathCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configValidateCmd)
+
+	configShowCmd.Flags().BoolVar(&configShowSecretsParameter, "secrets", false, "Show credentials instead of hiding them")
+}
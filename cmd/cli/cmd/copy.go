@@ -1,33 +1,85 @@
 package cmd
 
 import (
-	"fmt"
+	"os"
 
+	"github.com/snippetaccumulator/snac/internal/cli/clipboard"
+	"github.com/snippetaccumulator/snac/internal/log"
 	"github.com/spf13/cobra"
 )
 
 var (
 	silentParameter     bool
 	outputFileParameter string
+	varParameters       []string
+	rawParameter        bool
 
 	copyCmd = &cobra.Command{
-		Use:     "copy <ID>",
+		Use:     "copy [ID or title]",
 		Aliases: []string{"cp"},
-		Args:    cobra.ExactArgs(1),
+		Args:    cobra.MaximumNArgs(1),
 		Short:   "Copy snippet content to clipboard or file",
 		Long: `Copies content of a snippet to the clipboard.
-Can also write the content to a file.`,
+Can also write the content to a file.
+` + snippetArgHelp + `
+The clipboard is reached through wl-copy, xclip or xsel on Linux, pbcopy on macOS and clip on Windows.
+Without any of them, e.g. over SSH, the terminal is asked to copy with an OSC 52 escape sequence.
+Set clipboard in the config to force one of wl-copy, xclip, xsel, pbcopy, clip and osc52.
+Placeholders in the content like ${host:}, ${user:root} or ${env|dev,prod} are filled in first,
+${HOME} and the other expansions of shell scripts are left alone.
+Their values are asked for, proposing the ones used last time, or given with --var name=value.
+Without a terminal the defaults are used.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("copy called")
+			id := snippetID(args)
+			snippet, err := teamRequest(false).BuildGet(id).ExecuteWith(pipeline, db)
+			log.Err(true, err)
+			if !rawParameter {
+				snippet.Content = fillPlaceholders(snippet, varParameters)
+			}
+
+			switch outputFileParameter {
+			case "":
+				backend, err := systemClipboard().Copy(snippet.Content)
+				log.Err(true, err)
+				if silentParameter {
+					return
+				}
+				if backend == "osc52" {
+					log.Success("Sent '%s' to the clipboard of the terminal", snippet.Title)
+					log.Info("The terminal needs to support OSC 52 to copy it")
+					return
+				}
+				log.Success("Copied '%s' to the clipboard", snippet.Title)
+			case "-":
+				_, err = os.Stdout.WriteString(snippet.Content)
+				log.Err(true, err)
+			default:
+				err = os.WriteFile(outputFileParameter, []byte(snippet.Content), 0644)
+				log.Err(true, err)
+				if !silentParameter {
+					log.Success("Wrote '%s' to %s", snippet.Title, outputFileParameter)
+				}
+			}
 		},
 	}
 )
 
+// systemClipboard returns the clipboard with the backend of the config.
+func systemClipboard() *clipboard.Clipboard {
+	c, err := clipboard.New(config.Clipboard)
+	log.Err(true, err)
+	return c
+}
+
 func init() {
 	rootCmd.AddCommand(copyCmd)
 
 	copyCmd.Flags().BoolVarP(&silentParameter, "silent", "s", false, "Do not print anything to the console")
 	copyCmd.Flags().StringVarP(&outputFileParameter, "output", "o", "", "Write content to a file instead of copying it to the clipboard. Can use '-' to write to stdout")
 
+	copyCmd.Flags().StringArrayVar(&varParameters, "var", nil, "Value of a placeholder as name=value, can be used multiple times")
+	copyCmd.Flags().BoolVar(&rawParameter, "raw", false, "Copy the content with its placeholders as they are")
+	copyCmd.MarkFlagsMutuallyExclusive("var", "raw")
+
 	copyCmd.Flags().SortFlags = false
 }
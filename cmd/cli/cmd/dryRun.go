@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/snippetaccumulator/snac/internal/backend/request"
+	"github.com/snippetaccumulator/snac/internal/log"
+)
+
+// execute runs a request that changes something through the pipeline.
+// With --dry-run it prints the effect of the request as JSON to stdout instead and exits, nothing is changed.
+func execute[T any](t request.Typed[T]) T {
+	if dryRunParameter {
+		effect, err := t.ExplainWith(pipeline, db)
+		log.Err(true, err)
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		log.Err(true, encoder.Encode(effect))
+		os.Exit(0)
+	}
+
+	result, err := t.ExecuteWith(pipeline, db)
+	log.Err(true, err)
+	return result
+}
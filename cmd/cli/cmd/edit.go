@@ -1,8 +1,16 @@
 package cmd
 
 import (
-	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
 
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/frontmatter"
+	"github.com/snippetaccumulator/snac/internal/cli/lang"
+	"github.com/snippetaccumulator/snac/internal/log"
 	"github.com/spf13/cobra"
 )
 
@@ -11,24 +19,144 @@ var (
 	fileParameter      string
 	editorBinParameter string
 	editCmd            = &cobra.Command{
-		Use:     "edit <ID>",
+		Use:     "edit [ID or title]",
 		Aliases: []string{"e"},
-		Args:    cobra.ExactArgs(1),
+		Args:    cobra.MaximumNArgs(1),
 		Short:   "Edit snippet content in $EDITOR",
-		Long: `Put snippet content into temporary file and open it in $EDITOR, or provided editor binary.
-Afterwards update metadata as if using update command.
-Options to skip metadata update or provide file directly.`,
+		Long: `Put the snippet into a temporary file and open it in $VISUAL, $EDITOR, or the editor of --editor.
+Title, description, language and tags are in a YAML block between two --- lines above the content and can be edited as well.
+The snippet is updated once the editor is closed, unless nothing changed.
+If the update fails, the file is kept and can be used to try again with --file.
+` + snippetArgHelp,
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("edit called")
+			id := snippetID(args)
+			snippet, err := teamRequest(false).BuildGet(id).ExecuteWith(pipeline, db)
+			log.Err(true, err)
+
+			if fileParameter != "" {
+				text, err := readEditFile(fileParameter)
+				log.Err(true, err)
+				updateEdited(snippet, text, "")
+				return
+			}
+
+			text := snippet.Content
+			if !skipMetaParameter {
+				text, err = frontmatter.Write(snippet)
+				log.Err(true, err)
+			}
+			// editors add a line break at the end, which would count as a change
+			if !strings.HasSuffix(text, "\n") {
+				text += "\n"
+			}
+
+			file, err := os.CreateTemp("", "snac-"+string(snippet.ID)+"-*"+lang.Extension(snippet.Language))
+			log.Err(true, err)
+			_, err = file.WriteString(text)
+			file.Close()
+			if err != nil {
+				os.Remove(file.Name())
+				log.Err(true, err)
+			}
+
+			editor := editorCommand()
+			if editorBinParameter != "" {
+				editor = editorBinParameter
+			}
+			err = runEditor(editor, file.Name())
+			if err != nil {
+				os.Remove(file.Name())
+				log.Err(true, err)
+			}
+
+			edited, err := os.ReadFile(file.Name())
+			log.Err(true, err)
+			updateEdited(snippet, string(edited), file.Name())
 		},
 	}
 )
 
+// updateEdited updates the snippet with the edited text, if anything changed. Text with a frontmatter changes the metadata too.
+// The file of the text is removed afterwards, unless the update failed, so the changes are not lost.
+func updateEdited(snippet model.Snippet, text, file string) {
+	keep := func() {
+		if file != "" {
+			log.Info("Your changes are kept in %s, fix them and run snac edit %s --file %s", file, snippet.ID, file)
+		}
+	}
+
+	edited := snippet
+	edited.Content = text
+	if frontmatter.Has(text) && !skipMetaParameter {
+		meta, content, err := frontmatter.Parse(text)
+		if err == nil {
+			err = meta.Check()
+		}
+		if err != nil {
+			keep()
+			log.Err(true, err)
+		}
+		edited = meta.Apply(snippet)
+		edited.Content = content
+	}
+	if !strings.HasSuffix(snippet.Content, "\n") {
+		edited.Content = strings.TrimSuffix(edited.Content, "\n")
+	}
+	if strings.TrimSpace(edited.Content) == "" {
+		keep()
+		log.Err(true, errs.New(errs.ErrInvalid, "The content must not be empty"))
+	}
+
+	if !changed(snippet, edited) {
+		if file != "" {
+			os.Remove(file)
+		}
+		log.Info("Nothing changed, '%s' was not updated", snippet.Title)
+		return
+	}
+
+	if dryRunParameter {
+		if file != "" {
+			os.Remove(file)
+		}
+		execute(teamRequest(false).BuildUpdate(edited))
+	}
+	_, err := teamRequest(false).BuildUpdate(edited).ExecuteWith(pipeline, db)
+	if err != nil {
+		keep()
+		log.Err(true, err)
+	}
+	if file != "" {
+		os.Remove(file)
+	}
+	log.Success("Updated '%s'", edited.Title)
+}
+
+func changed(snippet, edited model.Snippet) bool {
+	return snippet.Title != edited.Title ||
+		snippet.Description != edited.Description ||
+		snippet.Language != edited.Language ||
+		!slices.Equal(snippet.Tags, edited.Tags) ||
+		snippet.Content != edited.Content
+}
+
+// readEditFile reads the file of --file, - reads stdin.
+func readEditFile(path string) (string, error) {
+	if path == "-" {
+		text, err := io.ReadAll(os.Stdin)
+		return string(text), err
+	}
+	text, err := os.ReadFile(path)
+	return string(text), err
+}
+
 func init() {
 	rootCmd.AddCommand(editCmd)
 
-	editCmd.Flags().BoolVarP(&skipMetaParameter, "skip-meta", "s", false, "Skip asking with any meta data should be changed")
-	editCmd.Flags().StringVarP(&fileParameter, "file", "f", "", "File to use for content")
+	editCmd.Flags().BoolVarP(&skipMetaParameter, "skip-meta", "s", false, "Edit only the content, without the metadata block")
+	editCmd.Flags().StringVarP(&fileParameter, "file", "f", "", "File to take the content from instead of opening an editor, - reads stdin. A metadata block at its top updates the metadata too")
+	editCmd.Flags().StringVar(&editorBinParameter, "editor", "", "Editor to use instead of $VISUAL or $EDITOR")
+	editCmd.MarkFlagsMutuallyExclusive("file", "editor")
 
 	editCmd.Flags().SortFlags = false
 }
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// editorCommand returns the editor of the user from $VISUAL or $EDITOR, vi or notepad if neither is set.
+func editorCommand() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(env)); editor != "" {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// editContent opens content in the editor in a temporary file with the extension ext, so the editor can
+// highlight it, and returns the content once the editor is closed.
+func editContent(content, ext string) (string, error) {
+	file, err := os.CreateTemp("", "snac-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(content)
+	file.Close()
+	if err != nil {
+		return "", err
+	}
+
+	err = runEditor(editorCommand(), file.Name())
+	if err != nil {
+		return "", err
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(edited), nil
+}
+
+// runEditor opens the file in the editor and waits until it is closed.
+func runEditor(editorCommand, path string) error {
+	// the editor may come with arguments, e.g. code --wait
+	editor := strings.Fields(editorCommand)
+	if len(editor) == 0 {
+		return fmt.Errorf("No editor given")
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Editor %s failed: %w", editor[0], err)
+	}
+	return nil
+}
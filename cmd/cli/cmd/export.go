@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/cli/exporter"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportToParameter     string
+	exportOutputParameter string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export --to <format>",
+	Args:  cobra.NoArgs,
+	Short: "Export the snippets of the team for an editor or text expander",
+	Long: `Exports the snippets of the team into files that VS Code, JetBrains IDEs, UltiSnips or espanso load directly.
+Placeholders become the tab stops of the format, or the fields of a form for espanso.
+The snippets can be filtered by tags and language like for list.
+The files are written to the current directory, or to --output. Existing files are replaced.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		to, err := exporter.Get(exportToParameter)
+		log.Err(true, err)
+
+		partials, err := teamRequest(false).BuildGetAllPartials().ExecuteWith(pipeline, db)
+		log.Err(true, err)
+		partials = filterPartials(partials)
+		if len(partials) == 0 {
+			log.Error(true, "No snippets to export")
+		}
+		sort.Slice(partials, func(i, j int) bool {
+			return strings.ToLower(partials[i].Title) < strings.ToLower(partials[j].Title)
+		})
+
+		snippets := fetchSnippets(partials)
+		files, err := to.Export(snippets)
+		log.Err(true, err)
+
+		if exportOutputParameter == "-" {
+			if len(files) > 1 {
+				log.Error(true, "The %s export has %d files, write them to a directory with --output", to.Name(), len(files))
+			}
+			_, err := os.Stdout.Write(files[0].Content)
+			log.Err(true, err)
+			return
+		}
+
+		if err := os.MkdirAll(exportOutputParameter, 0755); err != nil {
+			log.Error(true, "Could not create %s: %v", exportOutputParameter, err)
+		}
+		for _, file := range files {
+			path := filepath.Join(exportOutputParameter, file.Name)
+			if err := os.WriteFile(path, file.Content, 0644); err != nil {
+				log.Error(true, "Could not write %s: %v", path, err)
+			}
+			log.Info("Wrote %s", path)
+		}
+		log.Success("Exported %d snippets for %s", len(snippets), to.Name())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVar(&exportToParameter, "to", "", fmt.Sprintf("Format to export to, one of %v", exporter.Names()))
+	exportCmd.Flags().StringVarP(&exportOutputParameter, "output", "o", ".", "Directory to write the files to, - writes a single file to stdout")
+	exportCmd.Flags().StringArrayVar(&tagsParameter, "tag", []string{}, "Filter by tags, can be used multiple times. Multiple tags means that snippet must match ANY not ALL tags")
+	exportCmd.Flags().StringVar(&languageParameter, "language", "", "Filter by language")
+	exportCmd.MarkFlagRequired("to")
+}
@@ -3,46 +3,35 @@ package cmd
 import (
 	"fmt"
 	"strings"
-)
-
-type formatParameterValueType int
 
-const (
-	FormatParameterValueTypeDefault formatParameterValueType = iota
-	FormatParameterValueTypeJSON
-	FormatParameterValueTypeYAML
+	"github.com/snippetaccumulator/snac/internal/cli/format"
 )
 
+// formatParameterValue is the name of a formatter of the format package, default leaves the output to the command.
 type formatParameterValue struct {
-	format formatParameterValueType
+	format string
 }
 
 func (f *formatParameterValue) String() string {
-	switch f.format {
-	case FormatParameterValueTypeJSON:
-		return "json"
-	case FormatParameterValueTypeYAML:
-		return "yaml"
-	default:
+	if f.format == "" {
 		return "default"
 	}
+	return f.format
 }
 
 func (f *formatParameterValue) Set(input string) error {
 	lowercaseInput := strings.ToLower(input)
-	switch lowercaseInput {
-	case "json":
-		f.format = FormatParameterValueTypeJSON
-	case "yaml":
-		f.format = FormatParameterValueTypeYAML
-	case "default":
-		f.format = FormatParameterValueTypeDefault
-	default:
-		return fmt.Errorf("invalid format: ''%s' (allowed values: 'json', 'yaml', 'default')", input)
+	if lowercaseInput == "default" {
+		f.format = ""
+		return nil
+	}
+	if _, err := format.Get(lowercaseInput); err != nil {
+		return fmt.Errorf("invalid format: '%s' (allowed values: 'default', '%s')", input, strings.Join(format.Names(), "', '"))
 	}
+	f.format = lowercaseInput
 	return nil
 }
 
 func (f *formatParameterValue) Type() string {
-	return "json/yaml"
+	return "format"
 }
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/importer"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFromParameter      string
+	importTagsParameter      []string
+	importBatchSizeParameter int
+	importYesParameter       bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <path>...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Import snippets from VS Code, JetBrains or a directory",
+	Long: `Imports snippets from VS Code snippet files (.code-snippets and language .json files),
+JetBrains live templates (templateSet .xml files) or a directory of plain files.
+The format is detected for each path unless --from is given.
+Folder names become tags, tab stops and variables become placeholders.
+
+Snippets whose content is already in the team, or that appear twice, are not imported.
+A summary is shown and asked to be confirmed before anything is inserted, use --yes to skip that.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if importBatchSizeParameter < 1 {
+			log.Error(true, "--batch-size must be at least 1")
+		}
+
+		var result importer.Result
+		for _, path := range args {
+			from, err := importerFor(path)
+			log.Err(true, err)
+			found, err := from.Import(path)
+			log.Err(true, err)
+			log.Info("Found %d snippets in %s (%s)", len(found.Items), path, from.Name())
+			result.Add(found)
+		}
+
+		items, duplicates := importer.Dedupe(result.Items, existingContent())
+		snippets := make([]model.Snippet, len(items))
+		for i, item := range items {
+			snippets[i] = item.Snippet
+			snippets[i].TeamID = config.TeamName
+			for _, tag := range importTagsParameter {
+				if !slices.Contains(snippets[i].Tags, tag) {
+					snippets[i].Tags = append(snippets[i].Tags, tag)
+				}
+			}
+		}
+
+		printImportSummary(result, duplicates, len(snippets))
+		if len(snippets) == 0 {
+			return
+		}
+		if dryRunParameter {
+			execute(teamRequest(false).BuildInsertBatch(snippets))
+		}
+		if !importYesParameter && !confirm(fmt.Sprintf("Import %d snippets into team '%s'?", len(snippets), config.TeamName)) {
+			log.Error(true, "Cancelled")
+		}
+
+		imported := 0
+		for start := 0; start < len(snippets); start += importBatchSizeParameter {
+			batch := snippets[start:min(start+importBatchSizeParameter, len(snippets))]
+			inserted, err := teamRequest(false).BuildInsertBatch(batch).ExecuteWith(pipeline, db)
+			if err != nil {
+				log.Error(true, "Could not import snippets %d to %d, %d were imported before: %v", start+1, start+len(batch), imported, err)
+			}
+			imported += len(inserted)
+			log.Info("Imported %d/%d", imported, len(snippets))
+		}
+		log.Success("Imported %d snippets into team '%s'", imported, config.TeamName)
+	},
+}
+
+// importerFor returns the importer given with --from, else the one detected for the path.
+func importerFor(path string) (importer.Importer, error) {
+	if importFromParameter != "" {
+		return importer.Get(importFromParameter)
+	}
+	return importer.Detect(path)
+}
+
+// existingContent returns the titles of the snippets of the team by the hash of their content.
+func existingContent() map[string]string {
+	snippets, err := teamRequest(false).BuildGetAll().ExecuteWith(pipeline, db)
+	log.Err(true, err)
+
+	existing := make(map[string]string, len(snippets))
+	for _, snippet := range snippets {
+		existing[importer.Hash(snippet.Content)] = snippet.Title
+	}
+	return existing
+}
+
+func printImportSummary(result importer.Result, duplicates []importer.Skip, toImport int) {
+	fmt.Printf("Found:      %d\n", len(result.Items))
+	fmt.Printf("Duplicates: %d\n", len(duplicates))
+	fmt.Printf("Skipped:    %d\n", len(result.Skipped))
+	fmt.Printf("To import:  %d\n", toImport)
+	for _, skip := range duplicates {
+		fmt.Printf("  duplicate %s: %s\n", skip.Source, skip.Reason)
+	}
+	for _, skip := range result.Skipped {
+		fmt.Printf("  skipped %s: %s\n", skip.Source, skip.Reason)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().StringVar(&importFromParameter, "from", "", fmt.Sprintf("Format of the paths, one of %v (detected by default)", importer.Names()))
+	importCmd.Flags().StringSliceVarP(&importTagsParameter, "tag", "t", nil, "Tag added to every imported snippet")
+	importCmd.Flags().IntVar(&importBatchSizeParameter, "batch-size", 50, "Number of snippets inserted at once")
+	importCmd.Flags().BoolVarP(&importYesParameter, "yes", "y", false, "Import without asking for confirmation")
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cqroot/prompt"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"golang.org/x/term"
+)
+
+// promptPassword asks for a password on the terminal without echoing it.
+func promptPassword(label string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("Cannot ask for %s, stdin is not a terminal", label)
+	}
+	fmt.Fprintf(os.Stderr, "%s: ", label)
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
+
+// confirm asks a yes or no question on the terminal, without one it cannot ask and the answer is no.
+func confirm(question string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		log.Info("Use --yes to go on without a terminal")
+		return false
+	}
+	return ask(prompt.New().Ask(question).Choose([]string{"Yes", "No"})) == "Yes"
+}
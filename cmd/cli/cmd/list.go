@@ -1,8 +1,13 @@
 package cmd
 
 import (
-	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/format"
+	"github.com/snippetaccumulator/snac/internal/log"
 	"github.com/spf13/cobra"
 )
 
@@ -13,7 +18,7 @@ var (
 	minContentLengthParameter int
 	maxContentLengthParameter int
 	fullShowParameter         bool
-	listFormatParameter       formatParameterValue
+	listOutputParameters      outputParameters
 
 	listCmd = &cobra.Command{
 		Use:     "list [flags]",
@@ -21,13 +26,80 @@ var (
 		Short:   "List snippets filter/query options",
 		Long: `List snippets.
 Can filter by tags, language, content length min and max, and query for matching text in title and description.
-Can show full data of the snippet. Can output in different formats.`,
+Can show full data of the snippet. Can output in different formats.
+The fields are id, team, title, language and tags, and with --full also description, content and last_modified.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("list called")
+			partials, err := teamRequest(false).BuildGetAllPartials().ExecuteWith(pipeline, db)
+			log.Err(true, err)
+			partials = filterPartials(partials)
+			sort.Slice(partials, func(i, j int) bool {
+				return strings.ToLower(partials[i].Title) < strings.ToLower(partials[j].Title)
+			})
+
+			// the default format of list is a table
+			if listOutputParameters.format.format == "" {
+				listOutputParameters.format.format = "table"
+			}
+
+			// the query and content length need the whole snippets, they are fetched at once
+			if !fullShowParameter && queryParameter == "" && minContentLengthParameter < 0 && maxContentLengthParameter < 0 {
+				listOutputParameters.print(format.Partials(partials), "id", "title", "language", "tags")
+				return
+			}
+
+			var snippets []model.Snippet
+			for _, snippet := range fetchSnippets(partials) {
+				if matchesSnippet(snippet) {
+					snippets = append(snippets, snippet)
+				}
+			}
+			listOutputParameters.print(format.Snippets(snippets), "id", "title", "language", "tags", "description")
 		},
 	}
 )
 
+// filterPartials keeps the snippets with any of the tags and the language of the flags.
+func filterPartials(partials []model.PartialSnippet) []model.PartialSnippet {
+	var result []model.PartialSnippet
+	for _, partial := range partials {
+		if languageParameter != "" && !strings.EqualFold(partial.Language, languageParameter) {
+			continue
+		}
+		if len(tagsParameter) > 0 && !hasAnyTag(partial.Tags, tagsParameter) {
+			continue
+		}
+		result = append(result, partial)
+	}
+	return result
+}
+
+// matchesSnippet checks the query and content length of the flags.
+func matchesSnippet(snippet model.Snippet) bool {
+	query := strings.ToLower(queryParameter)
+	if query != "" && !strings.Contains(strings.ToLower(snippet.Title), query) && !strings.Contains(strings.ToLower(snippet.Description), query) {
+		return false
+	}
+	length := utf8.RuneCountInString(snippet.Content)
+	if minContentLengthParameter >= 0 && length < minContentLengthParameter {
+		return false
+	}
+	if maxContentLengthParameter >= 0 && length > maxContentLengthParameter {
+		return false
+	}
+	return true
+}
+
+func hasAnyTag(tags []string, wanted []string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if strings.EqualFold(tag, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func init() {
 	rootCmd.AddCommand(listCmd)
 
@@ -37,7 +109,7 @@ func init() {
 	listCmd.Flags().IntVar(&minContentLengthParameter, "min-content-length", -1, "Filter by minimum content length (character count) (inclusive)")
 	listCmd.Flags().IntVar(&maxContentLengthParameter, "max-content-length", -1, "Filter by maximum content length (character count) (inclusive)")
 	listCmd.Flags().BoolVar(&fullShowParameter, "full", false, "Show full data of the snippet")
-	listCmd.Flags().VarP(&listFormatParameter, "format", "f", "Output format (allowed values: 'json', 'yaml', 'default')")
+	listOutputParameters.addFlags(listCmd)
 
 	rootCmd.Flags().SortFlags = false
 }
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/snippetaccumulator/snac/internal/backend/request"
+	"github.com/snippetaccumulator/snac/internal/cli"
+	"github.com/snippetaccumulator/snac/internal/cli/vault"
+	"github.com/snippetaccumulator/snac/internal/common"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Args:  cobra.NoArgs,
+	Short: "Exchange the team password for a session token",
+	Long: `Exchanges team name and password for a session token that expires.
+The token is stored in the config file in place of the password, which is removed from it.
+Asks for the password if neither the config nor --password provides one.`,
+	Annotations: map[string]string{credentialsAnnotation: cli.UsesTeam + "," + cli.UsesSessions},
+	Run: func(cmd *cobra.Command, args []string) {
+		if config.Password == "" {
+			password, err := promptPassword("Password for team '" + config.TeamName + "'")
+			log.Err(true, err)
+			config.Password = password
+			config.Session = ""
+			db.Close()
+			openDatabase()
+		}
+
+		session, err := teamRequest(false).BuildLogin().ExecuteWith(pipeline, db)
+		log.Err(true, err)
+
+		saveSession(func(credentials *common.CommonConfig) {
+			credentials.TeamName = config.TeamName
+			credentials.Password = ""
+			credentials.Session = session.Token
+		})
+
+		log.Success("Logged in to team '%s' as %s until %s", session.TeamID, session.Role, session.Expires.Format("2006-01-02 15:04"))
+	},
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Args:  cobra.NoArgs,
+	Short: "Revoke the session token and remove it from the config",
+	Run: func(cmd *cobra.Command, args []string) {
+		if config.Session == "" {
+			log.Error(true, "Not logged in")
+		}
+
+		_, err := request.NewRequestBuilder().ForTeamWithSession(config.TeamName, config.Session).BuildLogout().ExecuteWith(pipeline, db)
+		if err != nil {
+			log.Warn("Could not revoke session: %v", err)
+		}
+
+		saveSession(func(credentials *common.CommonConfig) {
+			credentials.Session = ""
+		})
+
+		log.Success("Logged out of team '%s'", config.TeamName)
+	},
+}
+
+var refreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Args:  cobra.NoArgs,
+	Short: "Exchange the session token for a new one",
+	Long:  `Exchanges the session token for a new one that expires later, the old one is revoked.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if config.Session == "" {
+			log.Error(true, "Not logged in, use snac login first")
+		}
+
+		session, err := request.NewRequestBuilder().ForTeamWithSession(config.TeamName, config.Session).BuildRefresh().ExecuteWith(pipeline, db)
+		log.Err(true, err)
+
+		saveSession(func(credentials *common.CommonConfig) {
+			credentials.Session = session.Token
+		})
+
+		log.Success("Refreshed session for team '%s' until %s", session.TeamID, session.Expires.Format("2006-01-02 15:04"))
+	},
+}
+
+// saveSession applies update to the credentials of the profile in use, as they are in the config file on disk,
+// so overrides from flags are not written.
+func saveSession(update func(credentials *common.CommonConfig)) {
+	updateConfigFile(true, func(fileConfig *cli.Config) {
+		credentials, err := fileConfig.Credentials(config.Profile)
+		log.Err(true, err)
+		update(credentials)
+	})
+}
+
+// updateConfigFile applies update to the config file as it is on disk.
+// If it changes credentials and there is a credential store, update sees the credentials of the store, and they are
+// moved back into it afterwards. Otherwise the store is left alone, so it is not unlocked for other settings.
+func updateConfigFile(secrets bool, update func(fileConfig *cli.Config)) {
+	fileConfig, err := cli.LoadFile(configLoc)
+	if errors.Is(err, os.ErrNotExist) {
+		err = os.MkdirAll(filepath.Dir(configLoc), os.ModePerm)
+	}
+	log.Err(true, err)
+	var store *vault.Store
+	if secrets {
+		store = openStore()
+	}
+	if store != nil {
+		fileConfig.RestoreSecrets(store)
+	}
+	update(&fileConfig)
+	if store != nil {
+		fileConfig.MoveSecrets(store)
+		if err := store.Save(); err != nil {
+			log.Error(true, "Error while writing credential store: %s", err)
+		}
+	}
+	err = fileConfig.Save(configLoc)
+	if err != nil {
+		log.Error(true, "Error while writing config file: %s", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
+	rootCmd.AddCommand(refreshCmd)
+}
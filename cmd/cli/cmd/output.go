@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/cli/format"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// colorOutput reports if stdout is a terminal that wants colors, see https://no-color.org.
+func colorOutput() bool {
+	_, noColor := os.LookupEnv("NO_COLOR")
+	return !noColor && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// outputParameters are the flags of commands that output snippets or teams.
+type outputParameters struct {
+	format       formatParameterValue
+	columns      []string
+	template     string
+	templateFile string
+}
+
+func (o *outputParameters) addFlags(cmd *cobra.Command) {
+	cmd.Flags().VarP(&o.format, "format", "f", "Output format (allowed values: 'default', '"+strings.Join(format.Names(), "', '")+"')")
+	cmd.Flags().StringSliceVar(&o.columns, "columns", nil, "Fields to output, in this order, e.g. id,title,tags")
+	cmd.Flags().StringVar(&o.template, "template", "", "Go text/template executed for every snippet or team, e.g. '{{.id}} {{.title}}'")
+	cmd.Flags().StringVar(&o.templateFile, "template-file", "", "File with a Go text/template executed for every snippet or team")
+	cmd.MarkFlagsMutuallyExclusive("format", "template", "template-file")
+}
+
+// print writes data to stdout in the format of the flags. It returns false for the default format without columns,
+// which the command prints on its own. Table and markdown show the brief columns unless columns are selected.
+func (o *outputParameters) print(data format.Data, brief ...string) bool {
+	var formatter format.Formatter
+	var err error
+	switch {
+	case o.template != "":
+		formatter, err = format.Template(o.template)
+	case o.templateFile != "":
+		formatter, err = format.TemplateFile(o.templateFile)
+	case o.format.format != "":
+		formatter, err = format.Get(o.format.format)
+	case len(o.columns) > 0:
+		formatter, err = format.Get("table")
+	default:
+		return false
+	}
+	log.Err(true, err)
+
+	columns := o.columns
+	human := o.format.format == "" || o.format.format == "table" || o.format.format == "markdown"
+	if len(columns) == 0 && human && o.template == "" && o.templateFile == "" {
+		columns = brief
+	}
+	data, err = data.Select(columns)
+	log.Err(true, err)
+
+	log.Err(true, formatter.Format(os.Stdout, data))
+	return true
+}
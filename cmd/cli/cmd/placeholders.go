@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cqroot/prompt"
+	"github.com/cqroot/prompt/choose"
+	"github.com/cqroot/prompt/input"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/values"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"golang.org/x/term"
+)
+
+// fillPlaceholders returns the content of the snippet with values for its placeholders.
+// Values come from vars (name=value), else the user is asked for them, proposing the ones used last time.
+// Without a terminal the defaults are used.
+func fillPlaceholders(snippet model.Snippet, vars []string) string {
+	variables := model.Variables(snippet.Content)
+	given, err := parseVars(vars)
+	log.Err(true, err)
+	for name := range given {
+		if !hasVariable(variables, name) {
+			log.Warn("Snippet '%s' has no placeholder %s", snippet.Title, name)
+		}
+	}
+	if len(variables) == 0 {
+		return snippet.Content
+	}
+
+	store, err := values.Open(valuesLoc())
+	if err != nil {
+		log.Warn("Could not read the values used last time: %v", err)
+		store = nil
+	}
+	last := map[string]string{}
+	if store != nil {
+		last = store.Last(config.TeamName, snippet.ID)
+	}
+
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+	used := map[string]string{}
+	for _, variable := range variables {
+		value, ok := given[variable.Name]
+		if !ok && interactive {
+			value, ok = askVariable(variable, last[variable.Name]), true
+		}
+		if ok {
+			log.Err(true, variable.Check(value))
+			used[variable.Name] = value
+		}
+	}
+
+	content, err := model.Fill(snippet.Content, used)
+	if err != nil {
+		log.Info("Give values for placeholders with --var name=value")
+		log.Err(true, err)
+	}
+
+	if store != nil {
+		err = store.Remember(config.TeamName, snippet.ID, used)
+		if err != nil {
+			log.Warn("Could not remember the values: %v", err)
+		}
+	}
+	return content
+}
+
+// askVariable asks for the value of a placeholder on stderr, so it does not end up in piped output.
+func askVariable(variable model.Placeholder, last string) string {
+	if len(variable.Choices) > 0 {
+		index := 0
+		for i, choice := range variable.Choices {
+			if choice == last || (last == "" && choice == variable.Default) {
+				index = i
+			}
+		}
+		return ask(stderrPrompt().Ask(variable.Name+":").Choose(variable.Choices, choose.WithDefaultIndex(index)))
+	}
+
+	value := variable.Default
+	if last != "" {
+		value = last
+	}
+	var options []input.Option
+	if !variable.HasDefault {
+		options = append(options, input.WithValidateFunc(required(variable.Name)))
+	}
+	return ask(stderrPrompt().Ask(variable.Name+":").Input(value, options...))
+}
+
+func stderrPrompt() *prompt.Prompt {
+	return prompt.New(prompt.WithTeaProgramOpts(tea.WithOutput(os.Stderr)))
+}
+
+// parseVars splits name=value pairs of --var.
+func parseVars(vars []string) (map[string]string, error) {
+	parsed := map[string]string{}
+	for _, v := range vars {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("Invalid --var '%s', use name=value", v)
+		}
+		parsed[strings.TrimSpace(name)] = value
+	}
+	return parsed, nil
+}
+
+func hasVariable(variables []model.Placeholder, name string) bool {
+	for _, variable := range variables {
+		if variable.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// valuesLoc is the file with the values last used for placeholders, next to the config file.
+func valuesLoc() string {
+	return filepath.Join(filepath.Dir(configLoc), "values.json")
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/cli"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var profileUseParameter bool
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage the profiles of the config",
+	Long: `A profile is a named team with its credentials and backend, e.g. one for work and one for a side project.
+The current profile is used in place of team_name, password, database and server at the top of the config,
+--profile uses another one for a single command. Flags like --team-name still override the profile.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:         "list",
+	Args:        cobra.NoArgs,
+	Short:       "List the profiles, the current one is marked with *",
+	Annotations: map[string]string{offlineAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		fileConfig, err := cli.LoadFile(configLoc)
+		log.Err(true, err)
+		if len(fileConfig.Profiles) == 0 {
+			log.Info("There are no profiles yet, add one with snac profile add")
+			return
+		}
+		for _, name := range fileConfig.ProfileNames() {
+			profile := fileConfig.Profiles[name]
+			current := " "
+			if name == fileConfig.Profile {
+				current = "*"
+			}
+			fmt.Printf("%s %-15s team %-20s %s\n", current, name, profile.TeamName, backendOf(*profile))
+		}
+	},
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Add a profile with the team, credentials and backend in use",
+	Long: `Adds a profile with the team, credentials and backend in use, which flags like --team-name, --password,
+--server or --url change. --use makes it the current profile.`,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		unlockSecrets()
+		profile := config.CurrentProfile()
+		updateConfigFile(true, func(fileConfig *cli.Config) {
+			if _, ok := fileConfig.Profiles[name]; ok {
+				log.Err(true, errs.Errorf(errs.ErrConflict, "Profile '%s' exists already, remove it first", name))
+			}
+			if fileConfig.Profiles == nil {
+				fileConfig.Profiles = map[string]*cli.Profile{}
+			}
+			fileConfig.Profiles[name] = &profile
+			if profileUseParameter {
+				fileConfig.Profile = name
+			}
+		})
+		log.Success("Added profile '%s' for team '%s' on %s", name, profile.TeamName, backendOf(profile))
+	},
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:         "remove <name>",
+	Args:        cobra.ExactArgs(1),
+	Short:       "Remove a profile",
+	Long:        `Removes a profile. If it is the current one, the team and backend at the top of the config are used again.`,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		updateConfigFile(true, func(fileConfig *cli.Config) {
+			_, err := fileConfig.Credentials(name)
+			log.Err(true, err)
+			delete(fileConfig.Profiles, name)
+			if fileConfig.Profile == name {
+				fileConfig.Profile = ""
+			}
+		})
+		log.Success("Removed profile '%s'", name)
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:         "use <name>",
+	Args:        cobra.ExactArgs(1),
+	Short:       "Make a profile the current one",
+	Annotations: map[string]string{offlineAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		useProfile(args[0])
+	},
+}
+
+// useProfile makes the profile the current one in the config file.
+func useProfile(name string) {
+	var profile cli.Profile
+	updateConfigFile(false, func(fileConfig *cli.Config) {
+		_, err := fileConfig.Credentials(name)
+		log.Err(true, err)
+		fileConfig.Profile = name
+		profile = *fileConfig.Profiles[name]
+	})
+	log.Success("Using profile '%s' for team '%s' on %s", name, profile.TeamName, backendOf(profile))
+}
+
+// backendOf describes where the team of the profile is.
+func backendOf(profile cli.Profile) string {
+	if profile.Server != "" {
+		return "server " + profile.Server
+	}
+	if profile.Database.Url != "" {
+		return "database " + profile.Database.Url
+	}
+	return "no backend"
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileAddCmd)
+	profileCmd.AddCommand(profileRemoveCmd)
+	profileCmd.AddCommand(profileUseCmd)
+
+	profileAddCmd.Flags().BoolVar(&profileUseParameter, "use", false, "Make the profile the current one")
+}
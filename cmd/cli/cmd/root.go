@@ -1,29 +1,37 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
-	"github.com/snippetaccumulator/configloader"
 	"github.com/snippetaccumulator/snac/internal/backend/database"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/backend/request"
 	"github.com/snippetaccumulator/snac/internal/cli"
 	"github.com/snippetaccumulator/snac/internal/log"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
+	"golang.org/x/term"
 )
 
 var (
 	config    cli.Config
 	configLoc string
 	db        database.Database
+	pipeline  *request.Pipeline
 
 	configFileParameter       string
 	createConfigFileParameter bool
 	urlParameter              string
+	serverParameter           string
 	tokenParameter            string
 	teamNameParameter         string
 	passwordParameter         string
+	apiKeyParameter           string
+	profileParameter          string
 	verboseParameter          bool
+	dryRunParameter           bool
 	rootCmd                   = &cobra.Command{
 		Use:   "snac",
 		Short: "CLI to interact with a snac server",
@@ -49,69 +57,147 @@ Can do all operations either interactively or with flags.`,
 					if err != nil {
 						log.Err(true, err)
 					}
-					file, err := os.Create(configLoc)
-					if err != nil {
-						log.Err(true, err)
-					}
-
-					//write empty config struct to config
+					//write empty config struct to config, only readable by the user
 					emptyConfig := cli.Config{}
-					data, err := yaml.Marshal(emptyConfig)
-					if err != nil {
-						log.Error(true, "Error while writing new config file: %s", err)
-					}
-					_, err = file.Write(data)
-					if err != nil {
+					if err := emptyConfig.Save(configLoc); err != nil {
 						log.Error(true, "Error while writing new config file: %s", err)
 					}
 
 					log.Info("Created new empty config file at %s", configLoc)
-
-					file.Close()
 				} else {
-					log.Info("Use --create-config flag to create a new config file")
-					log.Error(true, "Config file does not exist at %s", configLoc)
+					log.Debug("No config file at %s, using the environment and flags (--create-config creates one)", configLoc)
 				}
 			}
 
-			cfgLoader := configloader.NewConfigLoader("config.yaml",
-				configloader.WithPath(cfgDir),
-				configloader.WithDeserializer(&configloader.YAMLDeserializer{}),
-			)
-
-			if urlParameter != "" {
-				cfgLoader.Override("Database.Url", urlParameter)
+			// flags take precedence over the environment, which takes precedence over the file and its profile
+			config, err = cli.Load(configLoc, profileParameter, os.LookupEnv)
+			if err != nil && cmd.Annotations[repairAnnotation] == "true" {
+				log.Warn("Error while loading config: %s", err)
+				err = nil
 			}
-			if tokenParameter != "" {
-				cfgLoader.Override("Database.AuthToken", tokenParameter)
-			}
-			if teamNameParameter != "" {
-				cfgLoader.Override("TeamName", teamNameParameter)
+			if err != nil {
+				log.Err(true, fmt.Errorf("Error while loading config: %w", err))
 			}
-			if passwordParameter != "" {
-				cfgLoader.Override("Password", passwordParameter)
+			overrides := map[string]string{
+				"database.url":        urlParameter,
+				"database.auth_token": tokenParameter,
+				"server":              serverParameter,
+				"team_name":           teamNameParameter,
+				"password":            passwordParameter,
+				"api_key":             apiKeyParameter,
 			}
 			if verboseParameter {
-				cfgLoader.Override("LogLevel", "DEBUG")
+				overrides["log_level"] = "DEBUG"
 			}
-
-			err = cfgLoader.Load(&config)
-			if err != nil {
-				log.Error(true, "Error while loading config file: %s", err)
+			for key, value := range overrides {
+				if value == "" {
+					continue
+				}
+				field, err := config.Field(key)
+				log.Err(true, err)
+				log.Err(true, field.Set(value))
 			}
 
 			log.SetLevel(log.FromString(config.LogLevel))
 
 			log.Debug("Loaded config")
 
-			db, err = database.NewDB(config.Database)
-			if err != nil {
-				log.Error(true, "Error while creating database connection: %s", err)
+			if cmd.Annotations[offlineAnnotation] == "true" {
+				return
+			}
+			if config.MissingCredentials(credentialsOf(cmd)...) {
+				unlockSecrets()
+			}
+			log.Err(true, config.Validate())
+
+			pipeline = request.NewPipeline(
+				request.Audit(func(entry request.AuditEntry) {
+					log.Debug("%s for team '%s' took %s (error: %v)", entry.Operation, entry.TeamID, entry.Duration, entry.Err)
+				}),
+				request.InstanceAdmin(instanceToken()),
+			)
+			// a server tracks failed password checks itself
+			if config.Server == "" {
+				pipeline.Use(request.NewGuard(request.DefaultLockoutPolicy(), func(lockout model.Lockout) {
+					log.Warn("Failed password check for team '%s' (%d failed attempts)", lockout.TeamID, lockout.Failures)
+				}).Middleware())
 			}
+			pipeline.Use(request.Defaults()...)
+
+			openDatabase()
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			// snac without a command browses the snippets, unless it is used in a script
+			if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+				cmd.Help()
+				return
+			}
+			runBrowse()
 		},
 	}
 )
 
+// offlineAnnotation marks commands that only work with the config file, they do not connect to a backend.
+const offlineAnnotation = "offline"
+
+// credentialsAnnotation lists the credentials an online command uses, e.g. "team,instance", see cli.MissingCredentials.
+const credentialsAnnotation = "credentials"
+
+// credentialsOf returns the credentials cmd uses, the ones of the team unless it says otherwise.
+func credentialsOf(cmd *cobra.Command) []string {
+	uses, ok := cmd.Annotations[credentialsAnnotation]
+	if !ok {
+		return []string{cli.UsesTeam}
+	}
+	return strings.Split(uses, ",")
+}
+
+// repairAnnotation marks offline commands that still run when the config does not load, so they can fix it.
+const repairAnnotation = "repair"
+
+// openDatabase connects to the server or the database of the config.
+func openDatabase() {
+	if config.Server != "" {
+		log.Debug("Using snac server at %s", config.Server)
+		remote := database.NewRemote(config.Server, config.TeamName, config.Password).WithInstanceToken(config.InstanceToken)
+		if config.Password == "" && config.APIKey != "" {
+			remote.WithAPIKey(config.APIKey)
+		} else if config.Password == "" {
+			remote.WithSession(config.Session)
+		}
+		db = remote
+		return
+	}
+
+	var err error
+	db, err = database.NewDB(config.Database)
+	if err != nil {
+		log.Error(true, "Error while creating database connection: %s", err)
+	}
+}
+
+// teamRequest returns a request builder for the team of the config. It uses the password if one is given,
+// then the API key, and the session of snac login otherwise.
+func teamRequest(admin bool) *request.RequestBuilder {
+	b := request.NewRequestBuilder()
+	if config.Password == "" && config.APIKey != "" {
+		return b.ForTeamWithAPIKey(config.TeamName, config.APIKey)
+	}
+	if config.Password == "" && config.Session != "" {
+		return b.ForTeamWithSession(config.TeamName, config.Session)
+	}
+	return b.ForTeamByID(config.TeamName, config.Password, admin)
+}
+
+// instanceToken returns the token that grants the instance-admin role, add it to requests that need it.
+// Whoever holds the credentials of the database is its instance admin.
+func instanceToken() string {
+	if config.Server != "" {
+		return config.InstanceToken
+	}
+	return config.Database.AuthToken
+}
+
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
@@ -124,10 +210,15 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&createConfigFileParameter, "create-config", false, "Create a new config file with the provided flags; has no effect if config file already exists")
 	rootCmd.PersistentFlags().StringVar(&urlParameter, "url", "", "Override URL for database connection")
 	rootCmd.PersistentFlags().StringVar(&tokenParameter, "token", "", "Override token for database connection")
+	rootCmd.PersistentFlags().StringVar(&serverParameter, "server", "", "Override URL of the snac server to use instead of connecting to the database directly")
 	rootCmd.PersistentFlags().StringVar(&teamNameParameter, "team-name", "", "Override team name for connection")
 	rootCmd.PersistentFlags().StringVar(&passwordParameter, "password", "", "Override password for connection")
+	rootCmd.PersistentFlags().StringVar(&apiKeyParameter, "api-key", "", "Override API key for connection, used instead of the password")
+	rootCmd.PersistentFlags().StringVar(&profileParameter, "profile", "", "Use the profile with this name instead of the current one")
 	rootCmd.MarkFlagsRequiredTogether("url", "token")
-	rootCmd.MarkFlagsRequiredTogether("team-name", "password")
+	rootCmd.MarkFlagsMutuallyExclusive("server", "url")
+	rootCmd.MarkFlagsMutuallyExclusive("password", "api-key")
 
+	rootCmd.PersistentFlags().BoolVar(&dryRunParameter, "dry-run", false, "Print what a command would change as JSON without changing anything")
 	rootCmd.PersistentFlags().BoolVarP(&verboseParameter, "verbose", "V", false, "Enable verbose (debug) output")
 }
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/picker"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"golang.org/x/term"
+)
+
+// snippetArgHelp explains the [ID or title] argument of the commands that use snippetID.
+const snippetArgHelp = "The snippet can also be given by its title or a part of it. Without one, or if several snippets match, it is picked in a fuzzy finder."
+
+// snippetID returns the ID of the snippet meant by the arguments of a command, which is its ID, its title or a part of it.
+// Without arguments, or if several snippets match, the user picks one in a fuzzy finder.
+func snippetID(args []string) model.ID {
+	partial, _ := resolveSnippet(args)
+	return partial.ID
+}
+
+// confirmedSnippetID is snippetID for commands that change the snippet. Unless yes is set, a snippet that was
+// only matched by a part of its title, tags or language has to be confirmed, so a typo cannot change another one.
+func confirmedSnippetID(args []string, action string, yes bool) model.ID {
+	partial, exact := resolveSnippet(args)
+	if !exact && !yes && !confirm(fmt.Sprintf("%s '%s' (%s)?", action, partial.Title, partial.ID)) {
+		log.Error(true, "Cancelled")
+	}
+	return partial.ID
+}
+
+// resolveSnippet returns the snippet meant by the arguments and if it was given by its exact ID or title, or picked.
+func resolveSnippet(args []string) (model.PartialSnippet, bool) {
+	partials, err := teamRequest(false).BuildGetAllPartials().ExecuteWith(pipeline, db)
+	log.Err(true, err)
+	if len(partials) == 0 {
+		log.Err(true, errs.Errorf(errs.ErrNotFound, "Team '%s' has no snippets", config.TeamName))
+	}
+
+	query := ""
+	if len(args) > 0 {
+		query = args[0]
+		partial, candidates, err := picker.Resolve(partials, query)
+		log.Err(true, err)
+		if candidates == nil {
+			return partial, picker.Exact(partial, query)
+		}
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			var ids []string
+			for _, candidate := range candidates {
+				ids = append(ids, candidate.ID.String()+" ("+candidate.Title+")")
+			}
+			log.Err(true, errs.Errorf(errs.ErrInvalid, "'%s' matches %d snippets, use one of their IDs: %s", query, len(candidates), strings.Join(ids, ", ")))
+		}
+	} else if !term.IsTerminal(int(os.Stdin.Fd())) {
+		log.Err(true, errs.Errorf(errs.ErrInvalid, "No snippet given, pass its ID or title"))
+	}
+
+	partial, err := picker.Pick(partials, query)
+	if errors.Is(err, picker.ErrCancelled) {
+		log.Error(true, "Cancelled")
+	}
+	log.Err(true, err)
+	return partial, true
+}
@@ -2,7 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/format"
+	"github.com/snippetaccumulator/snac/internal/cli/lang"
+	"github.com/snippetaccumulator/snac/internal/log"
 	"github.com/spf13/cobra"
 )
 
@@ -14,6 +21,8 @@ var (
 	createLanguageParameter       string
 	createContentParameter        string
 	createContentFileParameter    string
+	createFromClipboardParameter  bool
+	createOutputParameters        outputParameters
 
 	createCmd = &cobra.Command{
 		Use:     "create [flags]",
@@ -22,33 +31,86 @@ var (
 		Short:   "Creates a new snippet",
 		Long: `Interactively create a new snippet.
 If non-interactive mode is used, all required fields must be given as arguments (title, at least one tag, some content).
+The content is given with --content, read from --content-file (- reads stdin) or taken from the clipboard with --from-clipboard.
+The language is guessed from the extension of the content file if --language is not given.
+Prints the ID of the new snippet, or the whole snippet with --format, --columns or --template.
 If interactive mode is used, any of the field flags will be ignored.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if createNonInteractiveParameter {
-				if createTitleParameter == "" || len(createTagsParameter) == 0 || (createContentParameter == "" && createContentFileParameter == "") {
+				if createTitleParameter == "" || len(createTagsParameter) == 0 || (createContentParameter == "" && createContentFileParameter == "" && !createFromClipboardParameter) {
 					return fmt.Errorf("All required fields must be given in non-interactive mode")
 				}
-				fmt.Println("TODO: Implement non-interactive mode")
+				createNonInteractive()
 				return nil
 			}
-			fmt.Println("TODO: Implement interactive mode")
+			createInteractive()
 			return nil
 		},
 	}
 )
 
+func createNonInteractive() {
+	content, err := createContent()
+	log.Err(true, err)
+
+	language := createLanguageParameter
+	if language == "" && createContentFileParameter != "" && createContentFileParameter != "-" {
+		language = lang.FromFilename(createContentFileParameter)
+		if language != "" {
+			log.Debug("Guessed language %s from %s", language, createContentFileParameter)
+		}
+	}
+
+	snippet := model.NewSnippetBuilder(createTitleParameter, config.TeamName).
+		WithDescription(createDescriptionParameter).
+		WithLanguage(language).
+		WithTags(createTagsParameter).
+		WithContent(content).
+		Build()
+	created := execute(teamRequest(false).BuildInsert(snippet))
+
+	if !createOutputParameters.print(format.Snippet(created)) {
+		fmt.Println(created.ID)
+	}
+}
+
+// createContent returns the content from the flag, the content file, stdin or the clipboard.
+func createContent() (string, error) {
+	switch {
+	case createFromClipboardParameter:
+		content, err := systemClipboard().Paste()
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(content) == "" {
+			return "", fmt.Errorf("The clipboard is empty")
+		}
+		return content, nil
+	case createContentFileParameter == "-":
+		content, err := io.ReadAll(os.Stdin)
+		return string(content), err
+	case createContentFileParameter != "":
+		content, err := os.ReadFile(createContentFileParameter)
+		return string(content), err
+	default:
+		return createContentParameter, nil
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(createCmd)
 
 	createCmd.Flags().BoolVarP(&createNonInteractiveParameter, "non-interactive", "n", false, "Non interactive mode if used, all other fields must be given as aruments")
 	createCmd.Flags().StringVar(&createTitleParameter, "title", "", "Title of the snippet")
 	createCmd.Flags().StringVar(&createDescriptionParameter, "description", "", "Description of the snippet")
-	createCmd.Flags().StringVar(&createLanguageParameter, "language", "", "Language of the snippet")
+	createCmd.Flags().StringVar(&createLanguageParameter, "language", "", "Language of the snippet, guessed from the extension of --content-file if not given")
 	createCmd.Flags().StringArrayVar(&createTagsParameter, "tag", []string{}, "Add a single tag to the snippet, can be used multiple times")
 
 	createCmd.Flags().StringVar(&createContentParameter, "content", "", "Content of the snippet")
-	createCmd.Flags().StringVar(&createContentFileParameter, "content-file", "", "File containing the content of the snippet")
-	createCmd.MarkFlagsMutuallyExclusive("content", "content-file")
+	createCmd.Flags().StringVar(&createContentFileParameter, "content-file", "", "File containing the content of the snippet, - reads it from stdin")
+	createCmd.Flags().BoolVar(&createFromClipboardParameter, "from-clipboard", false, "Use the content of the clipboard")
+	createCmd.MarkFlagsMutuallyExclusive("content", "content-file", "from-clipboard")
+	createOutputParameters.addFlags(createCmd)
 
 	createCmd.Flags().SortFlags = false
 }
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cqroot/prompt"
+	"github.com/cqroot/prompt/input"
+	"github.com/cqroot/prompt/multichoose"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/lang"
+	"github.com/snippetaccumulator/snac/internal/cli/suggest"
+	"github.com/snippetaccumulator/snac/internal/log"
+)
+
+const (
+	otherLanguageChoice = "other..."
+	noLanguageChoice    = "none"
+
+	editorContentChoice = "open in editor"
+	pasteContentChoice  = "paste or type here"
+
+	createChoice      = "create"
+	editContentChoice = "edit content"
+	startOverChoice   = "start over"
+	cancelChoice      = "cancel"
+)
+
+// createInteractive asks for the fields of a new snippet step by step, shows it and creates it once confirmed.
+// Languages and tags already used by the team are suggested.
+func createInteractive() {
+	partials, err := teamRequest(false).BuildGetAllPartials().ExecuteWith(pipeline, db)
+	log.Err(true, err)
+	languages, tags := suggest.Languages(partials), suggest.Tags(partials)
+
+	var title, description string
+wizard:
+	for {
+		// starting over keeps the title and description as defaults
+		title = ask(prompt.New().Ask("Title:").Input(title, input.WithValidateFunc(required("title"))))
+		description = ask(prompt.New().Ask("Description (optional):").Input(description))
+		language := askLanguage(languages)
+		snippetTags := askTags(tags)
+		content := askContent("", language)
+
+		for {
+			snippet := model.NewSnippetBuilder(title, config.TeamName).
+				WithDescription(description).
+				WithLanguage(language).
+				WithTags(snippetTags).
+				WithContent(content).
+				Build()
+			printPreview(snippet)
+
+			switch ask(prompt.New().Ask("Create this snippet?").Choose([]string{createChoice, editContentChoice, startOverChoice, cancelChoice})) {
+			case createChoice:
+				created := execute(teamRequest(false).BuildInsert(snippet))
+				log.Success("Created snippet %s", created.ID)
+				return
+			case editContentChoice:
+				content = askContent(content, language)
+			case startOverChoice:
+				continue wizard
+			default:
+				log.Info("Cancelled, no snippet was created")
+				return
+			}
+		}
+	}
+}
+
+func askLanguage(languages []string) string {
+	if len(languages) > 0 {
+		choice := ask(prompt.New().Ask("Language:").Choose(append(languages, otherLanguageChoice, noLanguageChoice)))
+		switch choice {
+		case noLanguageChoice:
+			return ""
+		case otherLanguageChoice:
+		default:
+			return choice
+		}
+	}
+	return strings.TrimSpace(ask(prompt.New().Ask("Language (optional):").Input("")))
+}
+
+// askTags lets the user pick from the tags of the team and add new ones, at least one tag is needed.
+func askTags(tags []string) []string {
+	var picked []string
+	if len(tags) > 0 {
+		picked = ask(prompt.New().Ask("Tags (space to select):").MultiChoose(tags, multichoose.WithHelp(true)))
+	}
+
+	label := "New tags, comma separated:"
+	if len(tags) > 0 {
+		label = "More tags, comma separated (optional):"
+	}
+	added := ask(prompt.New().Ask(label).Input("", input.WithValidateFunc(func(value string) error {
+		if len(picked) == 0 && len(splitTags(value)) == 0 {
+			return errors.New("at least one tag is needed")
+		}
+		return nil
+	})))
+
+	for _, tag := range splitTags(added) {
+		if !contains(picked, tag) {
+			picked = append(picked, tag)
+		}
+	}
+	return picked
+}
+
+// askContent opens the content in the editor, or lets the user paste it if the editor is not wanted.
+func askContent(content, language string) string {
+	how := ask(prompt.New().Ask("Content:").Choose([]string{editorContentChoice, pasteContentChoice}))
+	if how == pasteContentChoice {
+		return ask(prompt.New().Ask("Content (ctrl+d to finish):").Write(content))
+	}
+
+	edited, err := editContent(content, lang.Extension(language))
+	log.Err(true, err)
+	return edited
+}
+
+func printPreview(snippet model.Snippet) {
+	fmt.Println()
+	fmt.Printf("Title:       %s\n", snippet.Title)
+	if snippet.Description != "" {
+		fmt.Printf("Description: %s\n", snippet.Description)
+	}
+	if snippet.Language != "" {
+		fmt.Printf("Language:    %s\n", snippet.Language)
+	}
+	fmt.Printf("Tags:        %s\n", strings.Join(snippet.Tags, ", "))
+	fmt.Println(strings.Repeat("-", 40))
+	fmt.Println(strings.TrimRight(snippet.Content, "\n"))
+	fmt.Println(strings.Repeat("-", 40))
+}
+
+// ask returns the answer of a prompt and exits if it failed or the user quit it.
+func ask[T any](answer T, err error) T {
+	if errors.Is(err, prompt.ErrUserQuit) {
+		log.Error(true, "Cancelled")
+	}
+	log.Err(true, err)
+	return answer
+}
+
+func required(name string) input.ValidateFunc {
+	return func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("%s is required", name)
+		}
+		return nil
+	}
+}
+
+func splitTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -1,25 +1,29 @@
 package cmd
 
 import (
-	"fmt"
-
+	"github.com/snippetaccumulator/snac/internal/log"
 	"github.com/spf13/cobra"
 )
 
+var deleteYesParameter bool
+
 var deleteCmd = &cobra.Command{
-	Use:     "delete <ID>",
+	Use:     "delete [ID or title]",
 	Aliases: []string{"d"},
-	Args:    cobra.ExactArgs(1),
+	Args:    cobra.MaximumNArgs(1),
 	Short:   "Deletes a snippet by ID",
-	Long:    `Deletes a snippet by ID. Nothing more, nothing less. Why are you looking at this? This just deletes...`,
+	Long: `Deletes a snippet by ID. Nothing more, nothing less. Why are you looking at this? This just deletes...
+` + snippetArgHelp + `
+A snippet that is not given by its exact ID or title has to be confirmed, unless --yes is given.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		id := args[0]
-		fmt.Printf("delete called with id: %s\n", id)
-		fmt.Println("TODO: Implement delete command")
+		id := confirmedSnippetID(args, "Delete", deleteYesParameter)
+		execute(teamRequest(false).BuildDelete(id))
+		log.Success("Deleted snippet %s", id)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(deleteCmd)
 
+	deleteCmd.Flags().BoolVarP(&deleteYesParameter, "yes", "y", false, "Delete a snippet matched by a part of its title without asking for confirmation")
 }
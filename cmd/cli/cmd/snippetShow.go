@@ -2,7 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/charmbracelet/lipgloss"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/format"
+	"github.com/snippetaccumulator/snac/internal/cli/render"
+	"github.com/snippetaccumulator/snac/internal/log"
 	"github.com/spf13/cobra"
 )
 
@@ -10,32 +17,107 @@ var (
 	shortParameter       bool
 	contentOnlyParameter bool
 	cutOffParameter      int
-	showFormatParameter  formatParameterValue
+	lineNumbersParameter bool
+	themeParameter       string
+	showOutputParameters outputParameters
 
 	showCmd = &cobra.Command{
-		Use:     "show <ID>",
+		Use:     "show [ID or title]",
 		Aliases: []string{"s", "get", "g"},
-		Args:    cobra.ExactArgs(1),
-		Short:   "",
-		Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
+		Args:    cobra.MaximumNArgs(1),
+		Short:   "Show a snippet",
+		Long: `Shows the details and the content of a snippet, highlighted for its language when stdout is a terminal.
+` + snippetArgHelp + `
+The theme of the highlighting is the chroma style of --theme or of theme in the config, NO_COLOR turns it off.
+The fields for --columns and --template are id, team, title, description, language, tags, content and last_modified.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("show called")
+			theme := config.Theme
+			if themeParameter != "" {
+				theme = themeParameter
+			}
+			if theme != "" && !render.IsStyle(theme) {
+				log.Error(true, "Unknown theme '%s', use one of: %s", theme, strings.Join(render.Styles(), ", "))
+			}
+
+			id := snippetID(args)
+			snippet, err := teamRequest(false).BuildGet(id).ExecuteWith(pipeline, db)
+			log.Err(true, err)
+
+			content, cut := render.Cut(snippet.Content, cutOffParameter)
+			snippet.Content = content
+
+			if showOutputParameters.print(format.Snippet(snippet), "id", "title", "language", "tags") {
+				return
+			}
+			if shortParameter {
+				printSnippetShort(snippet)
+				return
+			}
+
+			rendered, err := render.Content(snippet.Content, snippet.Language, render.Options{
+				Style:       theme,
+				Color:       colorOutput(),
+				LineNumbers: lineNumbersParameter,
+			})
+			if err != nil {
+				log.Debug("Could not highlight snippet: %v", err)
+			}
+			if !contentOnlyParameter {
+				printSnippetHeader(snippet)
+			}
+			fmt.Println(rendered)
+			if cut {
+				fmt.Fprintf(os.Stderr, "… cut off after %d characters\n", cutOffParameter)
+			}
 		},
 	}
 )
 
+var (
+	showTitleStyle = lipgloss.NewStyle().Bold(true)
+	showDimStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+)
+
+func printSnippetHeader(snippet model.Snippet) {
+	fmt.Printf("%s %s\n", showTitleStyle.Render(snippet.Title), showDimStyle.Render("("+string(snippet.ID)+")"))
+	if snippet.Description != "" {
+		fmt.Println(snippet.Description)
+	}
+	details := []string{}
+	if snippet.Language != "" {
+		details = append(details, "Language: "+snippet.Language)
+	}
+	if len(snippet.Tags) > 0 {
+		details = append(details, "Tags: "+strings.Join(snippet.Tags, ", "))
+	}
+	if !snippet.LastModified.IsZero() {
+		details = append(details, "Last modified: "+snippet.LastModified.Format("2006-01-02 15:04"))
+	}
+	fmt.Println(showDimStyle.Render(strings.Join(details, " · ")))
+	fmt.Println()
+}
+
+func printSnippetShort(snippet model.Snippet) {
+	line := []string{string(snippet.ID), snippet.Title}
+	if snippet.Language != "" {
+		line = append(line, "["+snippet.Language+"]")
+	}
+	if len(snippet.Tags) > 0 {
+		line = append(line, strings.Join(snippet.Tags, ", "))
+	}
+	fmt.Println(strings.Join(line, "  "))
+}
+
 func init() {
 	rootCmd.AddCommand(showCmd)
 
-	showCmd.Flags().BoolVarP(&shortParameter, "short", "s", false, "Show only minimal information about the snippet")
+	showCmd.Flags().BoolVarP(&shortParameter, "short", "s", false, "Show only ID, title, language and tags of the snippet")
 	showCmd.Flags().BoolVar(&contentOnlyParameter, "content-only", false, "Show only the content of the snippet")
 	showCmd.Flags().IntVar(&cutOffParameter, "cutoff", -1, "Cut off the content after a certain number of characters")
-	showCmd.Flags().Var(&showFormatParameter, "format", "Output format (allowed values: 'json', 'yaml', 'default')")
+	showCmd.Flags().BoolVarP(&lineNumbersParameter, "line-numbers", "N", false, "Number the lines of the content")
+	showCmd.Flags().StringVar(&themeParameter, "theme", "", "Chroma style to highlight the content with (default is theme of the config or "+render.DefaultStyle+")")
+	showOutputParameters.addFlags(showCmd)
+	showCmd.MarkFlagsMutuallyExclusive("short", "content-only", "format", "template", "template-file", "columns")
 
 	showCmd.Flags().SortFlags = false
 }
@@ -13,17 +13,20 @@ var (
 	updateLanguageParameter       string
 	updateTagsToAddParameter      []string
 	updateTagsToRemoveParameter   []string
+	updateYesParameter            bool
 
 	updateCmd = &cobra.Command{
-		Use:     "update <ID>",
+		Use:     "update [ID or title]",
 		Aliases: []string{"u"},
-		Args:    cobra.ExactArgs(1),
+		Args:    cobra.MaximumNArgs(1),
 		Short:   "Update a snippet by ID",
 		Long: `Interactively update a snippet by ID.
 Can be used non-interactively by providing all fields to override.
-Can add and remove tags.`,
+Can add and remove tags.
+` + snippetArgHelp + `
+A snippet that is not given by its exact ID or title has to be confirmed, unless --yes is given.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			id := args[0]
+			id := confirmedSnippetID(args, "Update", updateYesParameter)
 			fmt.Printf("update called with id: %s\n", id)
 			fmt.Println("TODO: Implement update command")
 		},
@@ -39,6 +42,7 @@ func init() {
 	updateCmd.Flags().StringVar(&updateLanguageParameter, "language", "", "New language of the snippet")
 	updateCmd.Flags().StringArrayVar(&updateTagsToAddParameter, "tag", []string{}, "Add a single tag to the snippet, can be used multiple times")
 	updateCmd.Flags().StringArrayVar(&updateTagsToRemoveParameter, "untag", []string{}, "Remove a single tag from the snippet, can be used multiple times")
+	updateCmd.Flags().BoolVarP(&updateYesParameter, "yes", "y", false, "Update a snippet matched by a part of its title without asking for confirmation")
 
 	createCmd.Flags().SortFlags = false
 }
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"github.com/snippetaccumulator/snac/internal/backend/request"
 	"github.com/snippetaccumulator/snac/internal/log"
 	"github.com/spf13/cobra"
 )
@@ -11,21 +10,20 @@ var statusCmd = &cobra.Command{
 	Args:  cobra.NoArgs,
 	Short: "Checks the status to the backend with current credentials",
 	Long: `Checks the status to the backend, using team name and password.
-Will also show other relevant information like config file location`,
+Will also show other relevant information like the profile in use and the config file location`,
 	Run: func(cmd *cobra.Command, args []string) {
 		log.Info("Checking status...")
-		reqBuilder := request.NewRequestBuilder()
-		req := reqBuilder.
-			ForTeamByID(config.TeamName, config.Password, false).
-			Check().Build()
-		_ = req
-		retData, retType, err := req.Execute(db)
-		log.Err(true, err)
-		err = request.TypeCheck(retData, retType)
+		req := teamRequest(false).BuildCheck()
+		_, err := req.ExecuteWith(pipeline, db)
 		log.Err(true, err)
 
 		log.Success("Connection check for team '%s' successful", config.TeamName)
 
+		if config.Profile != "" {
+			log.Info("Profile: %s (%s)", config.Profile, backendOf(config.CurrentProfile()))
+		} else {
+			log.Info("Profile: none, using the team and backend at the top of the config (%s)", backendOf(config.CurrentProfile()))
+		}
 		log.Info("Config file location: %s", configLoc)
 	},
 }
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/backup"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var backupOutputParameter string
+
+var teamBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Args:  cobra.NoArgs,
+	Short: "Write all snippets of the team into an archive",
+	Long: `Writes the team and all its snippets into a gzipped tar archive, which snac team restore reads.
+The archive has a manifest.json with the team, the version of the archive and the checksum of every snippet,
+and a JSON file per snippet in snippets/. Passwords, sessions and API keys are not part of it.
+The archive is written to snac-<team>-<time>.tar.gz in the current directory, or to --output, - writes it to stdout.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		team, err := teamRequest(false).BuildGetTeam().ExecuteWith(pipeline, db)
+		log.Err(true, err)
+		snippets, err := teamRequest(false).BuildGetAll().ExecuteWith(pipeline, db)
+		log.Err(true, err)
+
+		now := time.Now()
+		if backupOutputParameter == "-" {
+			log.Err(true, backup.Write(os.Stdout, team, snippets, now))
+			return
+		}
+
+		path := backupOutputParameter
+		if path == "" {
+			path = fmt.Sprintf("snac-%s-%s.tar.gz", team.Name, now.Format("20060102-150405"))
+		}
+		file, err := os.Create(path)
+		log.Err(true, err)
+		err = backup.Write(file, team, snippets, now)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+			log.Error(true, "Could not write %s: %v", path, err)
+		}
+		log.Success("Backed up %d snippets of team '%s' to %s", len(snippets), team.Name, path)
+	},
+}
+
+// fetchSnippets returns the whole snippets of the partials in their order, they are fetched at once.
+func fetchSnippets(partials []model.PartialSnippet) []model.Snippet {
+	all, err := teamRequest(false).BuildGetAll().ExecuteWith(pipeline, db)
+	log.Err(true, err)
+	byID := make(map[model.ID]model.Snippet, len(all))
+	for _, snippet := range all {
+		byID[snippet.ID] = snippet
+	}
+	snippets := make([]model.Snippet, 0, len(partials))
+	for _, partial := range partials {
+		if snippet, ok := byID[partial.ID]; ok {
+			snippets = append(snippets, snippet)
+		}
+	}
+	return snippets
+}
+
+func init() {
+	teamCmd.AddCommand(teamBackupCmd)
+
+	teamBackupCmd.Flags().StringVarP(&backupOutputParameter, "output", "o", "", "Path of the archive, - writes it to stdout")
+}
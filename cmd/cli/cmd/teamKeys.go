@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keyNameParameter    string
+	keyScopeParameter   string
+	keyTagsParameter    []string
+	keyExpiresParameter time.Duration
+)
+
+var teamKeysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage the API keys of the team",
+	Long: `API keys give scripts and CI jobs access to a team without its password.
+Pass a key with --api-key or set api_key in the config. Managing keys needs the admin password.`,
+}
+
+var teamKeysCreateCmd = &cobra.Command{
+	Use:   "create",
+	Args:  cobra.NoArgs,
+	Short: "Create an API key and print it",
+	Long: `Creates an API key for the team and prints it. The key is only shown once, only a hash of it is stored.
+The scope is either read (snippets can be listed and shown) or write (snippets can also be created, updated and deleted).
+With --tag the key only reaches snippets with any of the given tags.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var scope model.Role
+		switch keyScopeParameter {
+		case "read":
+			scope = model.RoleReadOnly
+		case "write":
+			scope = model.RoleMember
+		default:
+			log.Error(true, "Scope needs to be read or write, got '%s'", keyScopeParameter)
+		}
+
+		var expires time.Time
+		if keyExpiresParameter > 0 {
+			expires = time.Now().Add(keyExpiresParameter)
+		}
+
+		key, token, err := model.NewAPIKey(config.TeamName, keyNameParameter, scope, keyTagsParameter, expires)
+		log.Err(true, err)
+
+		execute(teamRequest(true).BuildCreateAPIKey(key))
+
+		log.Success("Created API key '%s' (%s) for team '%s', it will not be shown again:", key.Name, key.ID, config.TeamName)
+		fmt.Println(token)
+	},
+}
+
+var teamKeysListCmd = &cobra.Command{
+	Use:   "list",
+	Args:  cobra.NoArgs,
+	Short: "List the API keys of the team",
+	Run: func(cmd *cobra.Command, args []string) {
+		keys, err := teamRequest(true).BuildListAPIKeys().ExecuteWith(pipeline, db)
+		log.Err(true, err)
+
+		if len(keys) == 0 {
+			log.Info("Team '%s' has no API keys", config.TeamName)
+			return
+		}
+		for _, key := range keys {
+			tags := "all tags"
+			if len(key.Tags) > 0 {
+				tags = strings.Join(key.Tags, ", ")
+			}
+			fmt.Printf("%s  %-20s %-9s %-20s expires %-16s last used %s\n", key.ID, key.Name, key.Scope, tags, formatKeyTime(key.Expires, "never"), formatKeyTime(key.LastUsed, "never"))
+		}
+	},
+}
+
+var teamKeysRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Revoke an API key",
+	Run: func(cmd *cobra.Command, args []string) {
+		execute(teamRequest(true).BuildRevokeAPIKey(args[0]))
+
+		log.Success("Revoked API key '%s'", args[0])
+	},
+}
+
+func formatKeyTime(t time.Time, zero string) string {
+	if t.IsZero() {
+		return zero
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+func init() {
+	teamCmd.AddCommand(teamKeysCmd)
+	teamKeysCmd.AddCommand(teamKeysCreateCmd)
+	teamKeysCmd.AddCommand(teamKeysListCmd)
+	teamKeysCmd.AddCommand(teamKeysRevokeCmd)
+
+	teamKeysCreateCmd.Flags().StringVar(&keyNameParameter, "name", "", "Name of the key, e.g. the job that uses it")
+	teamKeysCreateCmd.Flags().StringVar(&keyScopeParameter, "scope", "read", "Scope of the key: read or write")
+	teamKeysCreateCmd.Flags().StringSliceVar(&keyTagsParameter, "tag", nil, "Restrict the key to snippets with this tag, can be repeated")
+	teamKeysCreateCmd.Flags().DurationVar(&keyExpiresParameter, "expires", 0, "Duration after which the key expires, e.g. 720h; never expires by default")
+	teamKeysCreateCmd.MarkFlagRequired("name")
+}
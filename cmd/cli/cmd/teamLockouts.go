@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/request"
+	"github.com/snippetaccumulator/snac/internal/cli"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var unlockClientParameter string
+
+var teamLockoutsCmd = &cobra.Command{
+	Use:   "lockouts [name]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "Show the lockouts of a team or of all teams",
+	Long: `Shows the failed password checks per team and client, and until when they are locked out.
+Needs the instance-admin role, the database auth token or the instance_token of the server.`,
+	Annotations: map[string]string{credentialsAnnotation: cli.UsesInstance},
+	Run: func(cmd *cobra.Command, args []string) {
+		teamID := ""
+		if len(args) == 1 {
+			teamID = args[0]
+		}
+
+		lockouts, err := request.NewRequestBuilder().WithInstanceToken(instanceToken()).BuildListLockouts(teamID).ExecuteWith(pipeline, db)
+		log.Err(true, err)
+
+		if len(lockouts) == 0 {
+			log.Info("No failed password checks")
+			return
+		}
+		for _, lockout := range lockouts {
+			status := "not locked"
+			if lockout.Locked(time.Now()) {
+				status = "locked until " + lockout.LockedUntil.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-20s %-20s %3d failed attempts, last at %s, %s\n", lockout.TeamID, lockout.Client, lockout.Failures, lockout.LastFailure.Format("2006-01-02 15:04:05"), status)
+		}
+	},
+}
+
+var teamUnlockCmd = &cobra.Command{
+	Use:         "unlock <name>",
+	Args:        cobra.ExactArgs(1),
+	Short:       "Clear the lockouts of a team",
+	Long:        `Clears the failed password checks of a team for all clients, or for the one of --client. Needs the instance-admin role.`,
+	Annotations: map[string]string{credentialsAnnotation: cli.UsesInstance},
+	Run: func(cmd *cobra.Command, args []string) {
+		execute(request.NewRequestBuilder().WithInstanceToken(instanceToken()).BuildClearLockouts(args[0], unlockClientParameter))
+
+		if unlockClientParameter != "" {
+			log.Success("Cleared lockouts of team '%s' for client '%s'", args[0], unlockClientParameter)
+			return
+		}
+		log.Success("Cleared lockouts of team '%s'", args[0])
+	},
+}
+
+func init() {
+	teamCmd.AddCommand(teamLockoutsCmd)
+	teamCmd.AddCommand(teamUnlockCmd)
+
+	teamUnlockCmd.Flags().StringVar(&unlockClientParameter, "client", "", "Clear only the lockout of this client, as shown by team lockouts")
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/backend/request"
+	"github.com/snippetaccumulator/snac/internal/cli"
+	"github.com/snippetaccumulator/snac/internal/cli/backup"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreCreateParameter bool
+	restoreYesParameter    bool
+)
+
+var teamRestoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Restore the snippets of an archive of snac team backup",
+	Long: `Restores the snippets of an archive written by snac team backup into the team of the config, - reads it from stdin.
+The checksums of the archive are verified first, nothing is restored from a damaged archive or one that decompresses to over 512 MiB.
+
+The snippets are merged into the team: those it has already are left alone, and those whose ID is taken
+by another snippet get a new one. All snippets are restored at once, or none if that fails.
+With --create the team is created first, with the display name of the archive and new passwords,
+which needs the instance admin token and are asked for unless --new-password and --new-admin-password give them.`,
+	Annotations: map[string]string{credentialsAnnotation: cli.UsesTeam + "," + cli.UsesInstance},
+	Run: func(cmd *cobra.Command, args []string) {
+		archive := readArchive(args[0])
+		manifest := archive.Manifest
+		log.Info("Backup of team '%s' from %s with %d snippets, checksums verified", manifest.Team.Name, manifest.Created.Local().Format("2006-01-02 15:04"), len(archive.Snippets))
+		if manifest.Team.Name != config.TeamName {
+			log.Info("Restoring into team '%s'", config.TeamName)
+		}
+
+		if restoreCreateParameter {
+			createRestoredTeam(manifest.Team)
+		}
+
+		existing, err := teamRequest(false).BuildGetAll().ExecuteWith(pipeline, db)
+		log.Err(true, err)
+		taken := make(map[model.ID]bool, len(existing))
+		for _, snippet := range existing {
+			taken[snippet.ID] = true
+		}
+		plan := backup.Merge(archive.Snippets, config.TeamName, existing, func(id model.ID) bool { return taken[id] })
+
+		printRestorePlan(plan)
+		if len(plan.Insert) == 0 {
+			log.Success("Team '%s' has all snippets of the backup already", config.TeamName)
+			return
+		}
+		if dryRunParameter {
+			execute(teamRequest(false).BuildInsertBatch(plan.Insert))
+		}
+		if !restoreYesParameter && !confirm(fmt.Sprintf("Restore %d snippets into team '%s'?", len(plan.Insert), config.TeamName)) {
+			log.Error(true, "Cancelled")
+		}
+
+		inserted, err := teamRequest(false).BuildInsertBatch(plan.Insert).ExecuteWith(pipeline, db)
+		if errors.Is(err, errs.ErrConflict) {
+			// a server does not show the snippets of other teams, so their IDs are only found to be taken now
+			log.Warn("IDs of the backup are taken by snippets of other teams, restoring them with new IDs")
+			plan = backup.Merge(archive.Snippets, config.TeamName, existing, func(id model.ID) bool {
+				return slices.ContainsFunc(archive.Snippets, func(snippet model.Snippet) bool { return snippet.ID == id })
+			})
+			printRestorePlan(plan)
+			inserted, err = teamRequest(false).BuildInsertBatch(plan.Insert).ExecuteWith(pipeline, db)
+		}
+		log.Err(true, err)
+		log.Success("Restored %d snippets into team '%s'", len(inserted), config.TeamName)
+	},
+}
+
+func readArchive(path string) backup.Archive {
+	file := os.Stdin
+	if path != "-" {
+		var err error
+		file, err = os.Open(path)
+		log.Err(true, err)
+		defer file.Close()
+	}
+	archive, err := backup.Read(file)
+	log.Err(true, err)
+	return archive
+}
+
+// createRestoredTeam creates the team of the config for the restore and uses its new password from then on.
+func createRestoredTeam(archived backup.Team) {
+	password := newPasswordParameter
+	if password == "" {
+		password = promptNewPassword("Password of the new team")
+	}
+	adminPassword := newAdminPasswordParameter
+	if adminPassword == "" {
+		adminPassword = promptNewPassword("Admin password of the new team")
+	}
+	team, err := model.NewTeam(config.TeamName, archived.DisplayName, password, adminPassword)
+	log.Err(true, err)
+	team.Created = archived.Created
+
+	// a dry run stops here, the team it would create cannot be restored into
+	execute(request.NewRequestBuilder().WithInstanceToken(instanceToken()).BuildNewTeam(team))
+	log.Success("Created team '%s'", team.Name)
+
+	config.Password = password
+	config.Session = ""
+	config.APIKey = ""
+	db.Close()
+	openDatabase()
+}
+
+func printRestorePlan(plan backup.Plan) {
+	fmt.Printf("To restore:     %d\n", len(plan.Insert))
+	fmt.Printf("Already there:  %d\n", len(plan.Unchanged))
+	fmt.Printf("With a new ID:  %d\n", len(plan.Remapped))
+	ids := make([]model.ID, 0, len(plan.Remapped))
+	for id := range plan.Remapped {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids {
+		fmt.Printf("  %s -> %s\n", id, plan.Remapped[id])
+	}
+}
+
+func init() {
+	teamCmd.AddCommand(teamRestoreCmd)
+
+	teamRestoreCmd.Flags().BoolVar(&restoreCreateParameter, "create", false, "Create the team before restoring into it")
+	teamRestoreCmd.Flags().StringVar(&newPasswordParameter, "new-password", "", "Password of the team created with --create")
+	teamRestoreCmd.Flags().StringVar(&newAdminPasswordParameter, "new-admin-password", "", "Admin password of the team created with --create")
+	teamRestoreCmd.Flags().BoolVarP(&restoreYesParameter, "yes", "y", false, "Restore without asking for confirmation")
+}
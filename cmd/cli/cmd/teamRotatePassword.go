@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/backend/request"
+	"github.com/snippetaccumulator/snac/internal/common"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	newPasswordParameter         string
+	newAdminPasswordParameter    string
+	newReadOnlyPasswordParameter string
+)
+
+var teamRotatePasswordCmd = &cobra.Command{
+	Use:   "rotate-password",
+	Args:  cobra.NoArgs,
+	Short: "Replace the passwords of the team",
+	Long: `Replaces the regular, admin and read-only password of the team, passwords that are not given are kept.
+Needs the admin password, a session or API key is not enough. It is asked for if the config has no password.
+Asks for the new passwords if none is given with flags.
+All sessions of the team end, so everyone has to log in again with the new passwords.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		passwords := model.TeamPasswords{
+			Password:         newPasswordParameter,
+			AdminPassword:    newAdminPasswordParameter,
+			ReadOnlyPassword: newReadOnlyPasswordParameter,
+		}
+		if passwords.Empty() {
+			passwords.Password = promptNewPassword("New password (empty keeps it)")
+			passwords.AdminPassword = promptNewPassword("New admin password (empty keeps it)")
+			if passwords.Empty() {
+				log.Error(true, "No new password given")
+			}
+		}
+
+		adminPassword := config.Password
+		if adminPassword == "" {
+			var err error
+			adminPassword, err = promptPassword("Current admin password")
+			log.Err(true, err)
+		}
+		execute(request.NewRequestBuilder().ForTeamByID(config.TeamName, adminPassword, true).BuildRotatePassword(passwords))
+
+		saveSession(func(credentials *common.CommonConfig) {
+			credentials.Session = ""
+			if credentials.Password != "" {
+				log.Warn("The password in the config file may be outdated, use snac team use to replace it")
+			}
+		})
+
+		log.Success("Rotated the passwords of team '%s', all its sessions have ended", config.TeamName)
+	},
+}
+
+// promptNewPassword asks for a password twice, an empty one is returned as is.
+func promptNewPassword(label string) string {
+	password, err := promptPassword(label)
+	log.Err(true, err)
+	if password == "" {
+		return ""
+	}
+	repeated, err := promptPassword("Repeat it")
+	log.Err(true, err)
+	if repeated != password {
+		log.Error(true, "The passwords do not match")
+	}
+	return password
+}
+
+func init() {
+	teamCmd.AddCommand(teamRotatePasswordCmd)
+
+	teamRotatePasswordCmd.Flags().StringVar(&newPasswordParameter, "new-password", "", "New regular password")
+	teamRotatePasswordCmd.Flags().StringVar(&newAdminPasswordParameter, "new-admin-password", "", "New admin password")
+	teamRotatePasswordCmd.Flags().StringVar(&newReadOnlyPasswordParameter, "new-read-only-password", "", "New read-only password")
+}
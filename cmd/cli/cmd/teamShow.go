@@ -3,33 +3,37 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/snippetaccumulator/snac/internal/cli/format"
+	"github.com/snippetaccumulator/snac/internal/log"
 	"github.com/spf13/cobra"
 )
 
+var teamShowOutputParameters outputParameters
+
 var teamShowCmd = &cobra.Command{
 	Use:   "show",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
+	Args:  cobra.NoArgs,
+	Short: "Show the team and how many snippets it has",
+	Long: `Shows name, display name, creation and last change of the team and the number of its snippets.
+The fields for --columns and --template are name, display_name, created, last_modified and snippets.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("show called")
+		team, err := teamRequest(false).BuildGetTeam().ExecuteWith(pipeline, db)
+		log.Err(true, err)
+		partials, err := teamRequest(false).BuildGetAllPartials().ExecuteWith(pipeline, db)
+		log.Err(true, err)
+
+		if teamShowOutputParameters.print(format.Team(team, len(partials))) {
+			return
+		}
+		fmt.Printf("%s (%s)\n", team.DisplayName, team.Name)
+		fmt.Printf("Snippets:      %d\n", len(partials))
+		fmt.Printf("Created:       %s\n", team.Created.Format("2006-01-02 15:04"))
+		fmt.Printf("Last modified: %s\n", team.LastModified.Format("2006-01-02 15:04"))
 	},
 }
 
 func init() {
 	teamCmd.AddCommand(teamShowCmd)
 
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// showCmd.PersistentFlags().String("foo", "", "A help for foo")
-
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// showCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	teamShowOutputParameters.addFlags(teamShowCmd)
 }
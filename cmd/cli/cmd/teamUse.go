@@ -1,35 +1,66 @@
 package cmd
 
 import (
-	"fmt"
+	"strings"
 
+	"github.com/snippetaccumulator/snac/internal/cli"
+	"github.com/snippetaccumulator/snac/internal/log"
 	"github.com/spf13/cobra"
 )
 
 var teamUseCmd = &cobra.Command{
-	Use:   "use",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
+	Use:   "use <name> [password]",
+	Args:  cobra.RangeArgs(1, 2),
+	Short: "Switch to another team",
+	Long: `Switches to the profile with the name, or else to the only profile of the team with the name.
+Without one, a profile for the team is added on the backend in use and made the current one.
+Its password is asked for unless it is given, an empty one leaves it out, e.g. to use snac login instead.`,
+	Annotations: map[string]string{offlineAnnotation: "true"},
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("use called")
+		name := args[0]
+		fileConfig, err := cli.LoadFile(configLoc)
+		log.Err(true, err)
+
+		if _, ok := fileConfig.Profiles[name]; ok {
+			useProfile(name)
+			return
+		}
+		var matching []string
+		for _, profileName := range fileConfig.ProfileNames() {
+			if fileConfig.Profiles[profileName].TeamName == name {
+				matching = append(matching, profileName)
+			}
+		}
+		if len(matching) == 1 {
+			useProfile(matching[0])
+			return
+		}
+		if len(matching) > 1 {
+			log.Error(true, "The profiles %s are for team '%s', use one of them with snac profile use", strings.Join(matching, ", "), name)
+		}
+
+		profile := config.CurrentProfile()
+		profile.TeamName = name
+		profile.Session = ""
+		profile.APIKey = ""
+		if len(args) == 2 {
+			profile.Password = args[1]
+		} else {
+			profile.Password, err = promptPassword("Password for team '" + name + "'")
+			log.Err(true, err)
+		}
+
+		updateConfigFile(true, func(fileConfig *cli.Config) {
+			if fileConfig.Profiles == nil {
+				fileConfig.Profiles = map[string]*cli.Profile{}
+			}
+			fileConfig.Profiles[name] = &profile
+			fileConfig.Profile = name
+		})
+		log.Success("Added profile '%s' for team '%s' on %s and switched to it", name, name, backendOf(profile))
 	},
 }
 
 func init() {
 	teamCmd.AddCommand(teamUseCmd)
-
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// useCmd.PersistentFlags().String("foo", "", "A help for foo")
-
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// useCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
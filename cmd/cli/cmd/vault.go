@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/cli"
+	"github.com/snippetaccumulator/snac/internal/cli/vault"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	credentialStore *vault.Store
+
+	vaultKeyFileParameter string
+)
+
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "Keep the credentials in an encrypted store instead of the config file",
+	Long: `Keeps passwords, sessions, API keys and tokens of the config and its profiles in a file encrypted
+with a passphrase or a key file, credentials.vault next to the config file unless vault.path is set.
+The store is unlocked with vault.key_file if it is set, else with the passphrase of SNAC_PASSPHRASE,
+else the passphrase is asked for. Store and key file must only be readable by the user (chmod 600).`,
+}
+
+var vaultInitCmd = &cobra.Command{
+	Use:   "init",
+	Args:  cobra.NoArgs,
+	Short: "Create the credential store and move the credentials of the config file into it",
+	Long: `Creates the credential store, locked with a passphrase that is asked for, or with --key-file.
+A key file that does not exist is created with random bytes, keep a copy of it somewhere safe.
+The credentials of the config file and its profiles are moved into the store.`,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		keyFile := ""
+		if vaultKeyFileParameter != "" {
+			var err error
+			keyFile, err = filepath.Abs(vaultKeyFileParameter)
+			log.Err(true, err)
+			if !vault.Exists(keyFile) {
+				log.Err(true, vault.NewKeyFile(keyFile))
+				log.Info("Created the key file %s, the credentials cannot be unlocked without it", keyFile)
+			}
+			config.Vault.KeyFile = keyFile
+		}
+
+		path := config.StorePath(configLoc)
+		secret, err := storeSecret(true)
+		log.Err(true, err)
+		credentialStore, err = vault.Create(path, secret)
+		log.Err(true, err)
+
+		plaintext := plaintextSecrets()
+		updateConfigFile(true, func(fileConfig *cli.Config) {
+			if keyFile != "" {
+				fileConfig.Vault.KeyFile = keyFile
+			}
+		})
+		log.Success("Created the credential store at %s", path)
+		printMoved(plaintext)
+	},
+}
+
+var vaultMigrateCmd = &cobra.Command{
+	Use:         "migrate",
+	Args:        cobra.NoArgs,
+	Short:       "Move credentials of the config file into the credential store",
+	Long:        `Moves credentials that were written to the config file by hand into the credential store, they replace the ones in the store.`,
+	Annotations: map[string]string{offlineAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if openStore() == nil {
+			log.Err(true, errs.Errorf(errs.ErrNotFound, "There is no credential store at %s, create one with snac vault init", config.StorePath(configLoc)))
+		}
+		plaintext := plaintextSecrets()
+		if len(plaintext) == 0 {
+			log.Info("There are no credentials in the config file")
+			return
+		}
+		updateConfigFile(true, func(fileConfig *cli.Config) {})
+		printMoved(plaintext)
+	},
+}
+
+var vaultStatusCmd = &cobra.Command{
+	Use:         "status",
+	Args:        cobra.NoArgs,
+	Short:       "Show where the credentials are and check the permissions of their files",
+	Annotations: map[string]string{offlineAnnotation: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		path := config.StorePath(configLoc)
+		if plaintext := plaintextSecrets(); len(plaintext) > 0 {
+			log.Warn("The config file has credentials in plaintext: %s", strings.Join(plaintext, ", "))
+		}
+		if vault.Exists(configLoc) {
+			log.Err(false, vault.CheckPermissions(configLoc))
+		}
+		// the store cannot be unlocked with a key file others can read, so there is nothing more to show
+		if config.Vault.KeyFile != "" {
+			log.Err(true, vault.CheckPermissions(config.Vault.KeyFile))
+		}
+		if !vault.Exists(path) {
+			log.Info("There is no credential store at %s, create one with snac vault init", path)
+			return
+		}
+
+		fmt.Printf("Store:    %s\n", path)
+		if config.Vault.KeyFile != "" {
+			fmt.Printf("Key file: %s\n", config.Vault.KeyFile)
+		} else {
+			fmt.Println("Key file: none, unlocked with a passphrase")
+		}
+		store := openStore()
+		for _, scope := range store.Scopes() {
+			keys := make([]string, 0, len(store.Scope(scope)))
+			for key := range store.Scope(scope) {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			name := "(top of the config)"
+			if scope != "" {
+				name = "profile " + scope
+			}
+			fmt.Printf("  %-25s %s\n", name, strings.Join(keys, ", "))
+		}
+		log.Success("The credential store is unlocked and the permissions of its files are fine")
+	},
+}
+
+// openStore unlocks the credential store, once per run. It returns nil if there is none.
+func openStore() *vault.Store {
+	if credentialStore != nil {
+		return credentialStore
+	}
+	path := config.StorePath(configLoc)
+	if !vault.Exists(path) {
+		return nil
+	}
+	secret, err := storeSecret(false)
+	log.Err(true, err)
+	credentialStore, err = vault.Open(path, secret)
+	log.Err(true, err)
+	log.Debug("Unlocked the credential store at %s", path)
+	return credentialStore
+}
+
+// unlockSecrets sets the credentials in use from the credential store, if there is one.
+// Credentials of flags and the environment are kept.
+func unlockSecrets() {
+	if store := openStore(); store != nil {
+		config.FillSecrets(store)
+	}
+}
+
+// storeSecret returns what unlocks the credential store: the key file of the config, SNAC_PASSPHRASE
+// or a passphrase that is asked for, twice for a new store.
+func storeSecret(create bool) ([]byte, error) {
+	if config.Vault.KeyFile != "" {
+		return vault.ReadKeyFile(config.Vault.KeyFile)
+	}
+	if passphrase, ok := os.LookupEnv(cli.PassphraseEnv); ok {
+		return []byte(passphrase), nil
+	}
+	if create {
+		return []byte(promptNewPassword("Passphrase of the credential store")), nil
+	}
+	passphrase, err := promptPassword("Passphrase of the credential store")
+	if err != nil {
+		return nil, errs.Errorf(errs.ErrUnauthorized, "%v, set %s or vault.key_file", err, cli.PassphraseEnv)
+	}
+	return []byte(passphrase), nil
+}
+
+// plaintextSecrets returns the keys of the credentials in the config file on disk.
+func plaintextSecrets() []string {
+	fileConfig, err := cli.LoadFile(configLoc)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	log.Err(true, err)
+	return fileConfig.PlaintextSecrets()
+}
+
+func printMoved(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	log.Success("Moved %d credentials out of the config file: %s", len(keys), strings.Join(keys, ", "))
+}
+
+func init() {
+	rootCmd.AddCommand(vaultCmd)
+	vaultCmd.AddCommand(vaultInitCmd)
+	vaultCmd.AddCommand(vaultMigrateCmd)
+	vaultCmd.AddCommand(vaultStatusCmd)
+
+	vaultInitCmd.Flags().StringVar(&vaultKeyFileParameter, "key-file", "", "Lock the store with this key file instead of a passphrase, it is created if it does not exist")
+}
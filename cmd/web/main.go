@@ -1 +1,64 @@
-package web
+package main
+
+import (
+	"flag"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/snippetaccumulator/configloader"
+	"github.com/snippetaccumulator/snac/internal/backend/database"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/backend/request"
+	"github.com/snippetaccumulator/snac/internal/backend/server"
+	"github.com/snippetaccumulator/snac/internal/log"
+	"github.com/snippetaccumulator/snac/internal/web"
+)
+
+func main() {
+	configFile := flag.String("config", "config.yaml", "Path of the server config file")
+	flag.Parse()
+
+	cfgLoader := configloader.NewConfigLoader(filepath.Base(*configFile),
+		configloader.WithPath(filepath.Dir(*configFile)),
+		configloader.WithDeserializer(&configloader.YAMLDeserializer{}),
+	)
+
+	var config web.Config
+	err := cfgLoader.Load(&config)
+	if err != nil {
+		log.Error(true, "Error while loading config file: %s", err)
+	}
+	if config.Address == "" {
+		config.Address = ":8080"
+	}
+
+	log.SetLevel(log.FromString(config.LogLevel))
+
+	db, err := database.NewDB(config.Database)
+	if err != nil {
+		log.Error(true, "Error while creating database connection: %s", err)
+	}
+	defer db.Close()
+
+	middlewares := []request.Middleware{
+		request.InstanceAdmin(config.InstanceToken),
+		request.Audit(func(entry request.AuditEntry) {
+			if entry.Err != nil {
+				log.Warn("%s for team '%s' failed after %s: %v", entry.Operation, entry.TeamID, entry.Duration, entry.Err)
+				return
+			}
+			log.Debug("%s for team '%s' took %s", entry.Operation, entry.TeamID, entry.Duration)
+		}),
+	}
+	middlewares = append(middlewares, request.NewGuard(request.DefaultLockoutPolicy(), func(lockout model.Lockout) {
+		log.Warn("Failed password check for team '%s' from %s (%d failed attempts)", lockout.TeamID, lockout.Client, lockout.Failures)
+	}).Middleware())
+	if config.RequestsPerMinute > 0 {
+		middlewares = append(middlewares, request.NewRateLimiter(config.RequestsPerMinute, time.Minute).Middleware())
+	}
+
+	log.Info("Listening on %s", config.Address)
+	err = http.ListenAndServe(config.Address, server.NewHandler(db, middlewares...))
+	log.Err(true, err)
+}
@@ -5,7 +5,11 @@ import "github.com/snippetaccumulator/snac/internal/backend/model"
 type Database interface {
 	GetByID(id model.ID) (model.Snippet, error)
 	GetByTeamID(teamID string) ([]model.PartialSnippet, error)
+	// GetSnippetsByTeamID returns the snippets of a team with their content, GetByTeamID only the partials.
+	GetSnippetsByTeamID(teamID string) ([]model.Snippet, error)
 	InsertSnippet(snippet model.Snippet) (model.Snippet, error)
+	// InsertSnippets inserts all of the snippets or, if one of them fails, none.
+	InsertSnippets(snippets []model.Snippet) ([]model.Snippet, error)
 	UpdateSnippet(snippet model.Snippet) error
 	DeleteSnippet(id model.ID) error
 	GetTeamByID(teamID string) (model.Team, error)
@@ -13,5 +17,26 @@ type Database interface {
 	UpdateTeam(team model.Team) error
 	DeleteTeam(teamID string) error
 	CheckTeamPassword(teamID string, password string, admin bool) (bool, error)
+	CheckTeamRole(teamID string, password string) (model.Role, error)
+	CreateSession(teamID string, role model.Role) (model.Session, error)
+	CheckSession(teamID string, token string) (model.Role, error)
+	RevokeSession(teamID string, token string) error
+	InsertAPIKey(key model.APIKey) error
+	GetAPIKeys(teamID string) ([]model.APIKey, error)
+	DeleteAPIKey(teamID string, id string) error
+	CheckAPIKey(teamID string, token string) (model.APIKey, error)
+	// SetTeamPasswords checks the non-empty passwords against the password policy and replaces the stored ones.
+	SetTeamPasswords(teamID string, passwords model.TeamPasswords) error
+	// GetLockouts returns the lockouts of a team, or of all teams if teamID is empty.
+	GetLockouts(teamID string) ([]model.Lockout, error)
+	SaveLockout(lockout model.Lockout) error
+	// ClearLockouts removes the lockouts of a team for client, or for all clients if client is empty.
+	ClearLockouts(teamID string, client string) error
 	Close()
 }
+
+// Transactional is implemented by databases that can try out changes without committing them.
+type Transactional interface {
+	// DryRun runs fn against a view of the database whose changes are discarded afterwards.
+	DryRun(fn func(db Database) error) error
+}
@@ -0,0 +1,247 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/backend/wire"
+)
+
+// Remote is a Database that forwards every call to a snac server.
+// The team credentials are sent with each call, so the server can scope it to the team.
+type Remote struct {
+	url           string
+	teamID        string
+	password      string
+	session       string
+	apiKey        string
+	instanceToken string
+	dryRun        bool
+	client        *http.Client
+}
+
+func NewRemote(url, teamID, password string) *Remote {
+	return &Remote{
+		url:      strings.TrimSuffix(url, "/"),
+		teamID:   teamID,
+		password: password,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// WithInstanceToken sets the token sent to the server to be granted the instance-admin role.
+func (r *Remote) WithInstanceToken(token string) *Remote {
+	r.instanceToken = token
+	return r
+}
+
+// WithSession makes the remote authenticate with a session token instead of the password.
+func (r *Remote) WithSession(token string) *Remote {
+	r.session = token
+	return r
+}
+
+// WithAPIKey makes the remote authenticate with an API key instead of the password.
+func (r *Remote) WithAPIKey(token string) *Remote {
+	r.apiKey = token
+	return r
+}
+
+// DryRun runs fn against a copy of the remote whose calls are rolled back by the server.
+func (r *Remote) DryRun(fn func(db Database) error) error {
+	dryRun := *r
+	dryRun.dryRun = true
+	return fn(&dryRun)
+}
+
+func (r *Remote) Close() {
+	r.client.CloseIdleConnections()
+}
+
+func (r *Remote) call(method string, args any, result any) error {
+	body, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, r.url+wire.Prefix+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.apiKey != "" {
+		req.Header.Set("Authorization", wire.APIKeyScheme+r.apiKey)
+		req.Header.Set(wire.TeamHeader, r.teamID)
+	} else if r.session != "" {
+		req.Header.Set("Authorization", "Bearer "+r.session)
+		req.Header.Set(wire.TeamHeader, r.teamID)
+	} else {
+		req.SetBasicAuth(r.teamID, r.password)
+	}
+	if r.instanceToken != "" {
+		req.Header.Set(wire.InstanceTokenHeader, r.instanceToken)
+	}
+	if r.dryRun {
+		req.Header.Set(wire.DryRunHeader, "true")
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return errs.Errorf(errs.ErrUnavailable, "Server at %s is not reachable: %w", r.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// the kind of the error is restored from the status code, its message is kept as is
+		message := fmt.Sprintf("Server responded with status %s", resp.Status)
+		var wireErr wire.Error
+		if err := json.NewDecoder(resp.Body).Decode(&wireErr); err == nil && wireErr.Error != "" {
+			message = wireErr.Error
+		}
+		if kind := errs.FromHTTPStatus(resp.StatusCode); kind != nil {
+			return errs.New(kind, message)
+		}
+		return errors.New(message)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (r *Remote) GetByID(id model.ID) (model.Snippet, error) {
+	var snippet model.Snippet
+	err := r.call(wire.GetByID, wire.IDArgs{ID: id}, &snippet)
+	return snippet, err
+}
+
+func (r *Remote) GetByTeamID(teamID string) ([]model.PartialSnippet, error) {
+	var partials []model.PartialSnippet
+	err := r.call(wire.GetByTeamID, wire.TeamIDArgs{TeamID: teamID}, &partials)
+	return partials, err
+}
+
+func (r *Remote) GetSnippetsByTeamID(teamID string) ([]model.Snippet, error) {
+	var snippets []model.Snippet
+	err := r.call(wire.GetSnippetsByTeamID, wire.TeamIDArgs{TeamID: teamID}, &snippets)
+	return snippets, err
+}
+
+func (r *Remote) InsertSnippet(snippet model.Snippet) (model.Snippet, error) {
+	var inserted model.Snippet
+	err := r.call(wire.InsertSnippet, wire.SnippetArgs{Snippet: snippet}, &inserted)
+	return inserted, err
+}
+
+func (r *Remote) InsertSnippets(snippets []model.Snippet) ([]model.Snippet, error) {
+	var inserted []model.Snippet
+	err := r.call(wire.InsertSnippets, wire.SnippetsArgs{Snippets: snippets}, &inserted)
+	return inserted, err
+}
+
+func (r *Remote) UpdateSnippet(snippet model.Snippet) error {
+	return r.call(wire.UpdateSnippet, wire.SnippetArgs{Snippet: snippet}, nil)
+}
+
+func (r *Remote) DeleteSnippet(id model.ID) error {
+	return r.call(wire.DeleteSnippet, wire.IDArgs{ID: id}, nil)
+}
+
+func (r *Remote) GetTeamByID(teamID string) (model.Team, error) {
+	var team model.Team
+	err := r.call(wire.GetTeamByID, wire.TeamIDArgs{TeamID: teamID}, &team)
+	return team, err
+}
+
+func (r *Remote) InsertTeam(teamID, displayName, password, adminPassword string) error {
+	return r.call(wire.InsertTeam, wire.InsertTeamArgs{
+		TeamID:        teamID,
+		DisplayName:   displayName,
+		Password:      password,
+		AdminPassword: adminPassword,
+	}, nil)
+}
+
+func (r *Remote) UpdateTeam(team model.Team) error {
+	return r.call(wire.UpdateTeam, wire.TeamArgs{Team: team}, nil)
+}
+
+func (r *Remote) DeleteTeam(teamID string) error {
+	return r.call(wire.DeleteTeam, wire.TeamIDArgs{TeamID: teamID}, nil)
+}
+
+func (r *Remote) CheckTeamPassword(teamID string, password string, admin bool) (bool, error) {
+	var result wire.CheckTeamPasswordResult
+	err := r.call(wire.CheckTeamPassword, wire.CheckTeamPasswordArgs{TeamID: teamID, Password: password, Admin: admin}, &result)
+	return result.Correct, err
+}
+
+func (r *Remote) CheckTeamRole(teamID string, password string) (model.Role, error) {
+	var result wire.CheckTeamRoleResult
+	err := r.call(wire.CheckTeamRole, wire.CheckTeamRoleArgs{TeamID: teamID, Password: password}, &result)
+	return result.Role, err
+}
+
+// CreateSession asks the server for a session of the authenticated team, the server decides about the role.
+func (r *Remote) CreateSession(teamID string, role model.Role) (model.Session, error) {
+	var session model.Session
+	err := r.call(wire.CreateSession, wire.CreateSessionArgs{TeamID: teamID, Role: role}, &session)
+	return session, err
+}
+
+func (r *Remote) CheckSession(teamID string, token string) (model.Role, error) {
+	var result wire.CheckTeamRoleResult
+	err := r.call(wire.CheckSession, wire.SessionArgs{TeamID: teamID, Token: token}, &result)
+	return result.Role, err
+}
+
+func (r *Remote) RevokeSession(teamID string, token string) error {
+	return r.call(wire.RevokeSession, wire.SessionArgs{TeamID: teamID, Token: token}, nil)
+}
+
+func (r *Remote) InsertAPIKey(key model.APIKey) error {
+	return r.call(wire.InsertAPIKey, wire.APIKeyArgs{Key: key}, nil)
+}
+
+func (r *Remote) GetAPIKeys(teamID string) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.call(wire.GetAPIKeys, wire.TeamIDArgs{TeamID: teamID}, &keys)
+	return keys, err
+}
+
+func (r *Remote) DeleteAPIKey(teamID string, id string) error {
+	return r.call(wire.DeleteAPIKey, wire.APIKeyIDArgs{TeamID: teamID, ID: id}, nil)
+}
+
+func (r *Remote) CheckAPIKey(teamID string, token string) (model.APIKey, error) {
+	var key model.APIKey
+	err := r.call(wire.CheckAPIKey, wire.CheckAPIKeyArgs{TeamID: teamID, Token: token}, &key)
+	return key, err
+}
+
+func (r *Remote) GetLockouts(teamID string) ([]model.Lockout, error) {
+	var lockouts []model.Lockout
+	err := r.call(wire.GetLockouts, wire.TeamIDArgs{TeamID: teamID}, &lockouts)
+	return lockouts, err
+}
+
+// SaveLockout is not possible through a server, failed attempts are tracked by the server itself.
+func (r *Remote) SaveLockout(lockout model.Lockout) error {
+	return errs.Errorf(errs.ErrInvalid, "Lockouts are tracked by the server at %s", r.url)
+}
+
+func (r *Remote) ClearLockouts(teamID string, client string) error {
+	return r.call(wire.ClearLockouts, wire.LockoutArgs{TeamID: teamID, Client: client}, nil)
+}
+
+func (r *Remote) SetTeamPasswords(teamID string, passwords model.TeamPasswords) error {
+	return r.call(wire.SetTeamPasswords, wire.SetTeamPasswordsArgs{TeamID: teamID, Passwords: passwords}, nil)
+}
@@ -1,13 +1,18 @@
 package database
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
 	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/backend/session"
 	"github.com/snippetaccumulator/snac/internal/common"
 	"github.com/tursodatabase/go-libsql"
 	"golang.org/x/crypto/bcrypt"
@@ -16,9 +21,20 @@ import (
 type DB struct {
 	dir       string
 	connector *libsql.Connector
+	sessions  *session.Signer
+	passwords model.PasswordPolicy
+	// conn runs the queries, the database itself or a transaction of it, see DryRun.
+	conn conn
 	*sql.DB
 }
 
+// conn is what sql.DB and sql.Tx have in common.
+type conn interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 func (db *DB) Close() {
 	os.RemoveAll(db.dir)
 	db.connector.Close()
@@ -38,14 +54,118 @@ func NewDB(dbCfg common.Database) (*DB, error) {
 		return nil, err
 	}
 
+	sessionKey := []byte(dbCfg.SessionKey)
+	if len(sessionKey) == 0 {
+		derived := sha256.Sum256([]byte("snac-session:" + dbCfg.AuthToken))
+		sessionKey = derived[:]
+	}
+
 	db := &DB{
 		dir:       dir,
 		connector: connector,
+		sessions:  session.NewSigner(sessionKey, session.DefaultTTL),
+		passwords: dbCfg.PasswordPolicy,
 		DB:        sql.OpenDB(connector),
 	}
+	db.conn = db.DB
+	if err := db.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
 	return db, nil
 }
 
+// schema creates the tables that do not exist yet, teams first as the others refer to them.
+var schema = []string{
+	model.TeamTableSql,
+	model.SnippetTableSql,
+	model.RevokedSessionTableSql,
+	model.APIKeyTableSql,
+	model.LockoutTableSql,
+}
+
+// migrate creates missing tables and adds the columns that tables of older versions lack,
+// so a database keeps working after an update of snac.
+func (db *DB) migrate() error {
+	for _, statement := range schema {
+		if _, err := db.conn.Exec(statement); err != nil {
+			return wrap(err, "Schema")
+		}
+	}
+	for _, statement := range model.TeamMigrationsSql {
+		_, err := db.conn.Exec(statement)
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return wrap(err, "Schema")
+		}
+	}
+	return nil
+}
+
+// DryRun runs fn in a transaction that is rolled back afterwards, whatever fn returns.
+func (db *DB) DryRun(fn func(db Database) error) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return wrap(err, "Transaction")
+	}
+	defer tx.Rollback()
+
+	txDB := *db
+	txDB.conn = tx
+	return fn(&txDB)
+}
+
+// transaction runs fn in a transaction that is committed if fn succeeds.
+// Within a transaction already, e.g. of DryRun, fn is run in that one.
+func (db *DB) transaction(fn func(tx *DB) error) error {
+	if _, ok := db.conn.(*sql.Tx); ok {
+		return fn(db)
+	}
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return wrap(err, "Transaction")
+	}
+	defer tx.Rollback()
+
+	txDB := *db
+	txDB.conn = tx
+	if err := fn(&txDB); err != nil {
+		return err
+	}
+	return wrap(tx.Commit(), "Transaction")
+}
+
+// wrap turns an error of the driver into one of the errs kinds, subject names the affected row for the message.
+// Errors that are neither a missing row nor a constraint violation mean the database is not usable.
+func wrap(err error, subject string, a ...any) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return errs.Errorf(errs.ErrNotFound, "%s was not found", fmt.Sprintf(subject, a...))
+	case strings.Contains(err.Error(), "UNIQUE constraint failed"):
+		return errs.Errorf(errs.ErrConflict, "%s already exists", fmt.Sprintf(subject, a...))
+	case strings.Contains(err.Error(), "constraint failed"):
+		return errs.Errorf(errs.ErrInvalid, "Invalid %s: %w", fmt.Sprintf(subject, a...), err)
+	default:
+		return errs.Errorf(errs.ErrUnavailable, "Database error: %w", err)
+	}
+}
+
+// affected checks that a statement changed a row, see wrap.
+func affected(result sql.Result, err error, subject string, a ...any) error {
+	if err != nil {
+		return wrap(err, subject, a...)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return wrap(err, subject, a...)
+	}
+	if n == 0 {
+		return wrap(sql.ErrNoRows, subject, a...)
+	}
+	return nil
+}
+
 var fullSnippetSqlFields = "id, team_id, title, description, tags, language, content, last_modified"
 
 func scanRowToDBSnippet(scanner interface {
@@ -96,14 +216,14 @@ func fullRowsToSnippet(rows *sql.Rows) ([]model.Snippet, error) {
 	return snippets, nil
 }
 
-var partialSnippetSqlFields = "id, team_id, title, tags"
+var partialSnippetSqlFields = "id, team_id, title, tags, language"
 
 func partialRowsToSnippets(rows *sql.Rows) ([]model.PartialSnippet, error) {
 	var partialSnippets []model.PartialSnippet
 
 	for rows.Next() {
 		var dbPartialSnippet model.DBPartialSnippet
-		err := rows.Scan(&dbPartialSnippet.ID, &dbPartialSnippet.TeamID, &dbPartialSnippet.Title, &dbPartialSnippet.Tags)
+		err := rows.Scan(&dbPartialSnippet.ID, &dbPartialSnippet.TeamID, &dbPartialSnippet.Title, &dbPartialSnippet.Tags, &dbPartialSnippet.Language)
 		if err != nil {
 			return nil, err
 		}
@@ -121,49 +241,81 @@ func partialRowsToSnippets(rows *sql.Rows) ([]model.PartialSnippet, error) {
 
 func (db *DB) GetByID(id model.ID) (model.Snippet, error) {
 	query := `SELECT ` + fullSnippetSqlFields + ` FROM snippets WHERE id = ?`
-	row := db.QueryRow(query, id)
+	row := db.conn.QueryRow(query, id)
 
-	return fullRowToSnippet(row)
+	snippet, err := fullRowToSnippet(row)
+	return snippet, wrap(err, "Snippet '%s'", id)
 }
 
 func (db *DB) GetByTeamID(teamID string) ([]model.PartialSnippet, error) {
 	query := `SELECT ` + partialSnippetSqlFields + ` FROM snippets WHERE team_id = ?`
-	rows, err := db.Query(query, teamID)
+	rows, err := db.conn.Query(query, teamID)
 	if err != nil {
-		return nil, err
+		return nil, wrap(err, "Snippets of team '%s'", teamID)
 	}
 	defer rows.Close()
 
-	return partialRowsToSnippets(rows)
+	partials, err := partialRowsToSnippets(rows)
+	return partials, wrap(err, "Snippets of team '%s'", teamID)
+}
+
+func (db *DB) GetSnippetsByTeamID(teamID string) ([]model.Snippet, error) {
+	query := `SELECT ` + fullSnippetSqlFields + ` FROM snippets WHERE team_id = ?`
+	rows, err := db.conn.Query(query, teamID)
+	if err != nil {
+		return nil, wrap(err, "Snippets of team '%s'", teamID)
+	}
+	defer rows.Close()
+
+	snippets, err := fullRowsToSnippet(rows)
+	return snippets, wrap(err, "Snippets of team '%s'", teamID)
 }
 
 func (db *DB) InsertSnippet(snippet model.Snippet) (model.Snippet, error) {
 	snippet.LastModified = time.Now()
 	dbSnippet := snippet.ToDBSnippet()
 	query := `INSERT INTO snippets (id, team_id, title, description, tags, language, content, last_modified) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err := db.Exec(query, dbSnippet.ID, dbSnippet.TeamID, dbSnippet.Title, dbSnippet.Description, dbSnippet.Tags, dbSnippet.Language, dbSnippet.Content, dbSnippet.LastModified)
-	return snippet, err
+	_, err := db.conn.Exec(query, dbSnippet.ID, dbSnippet.TeamID, dbSnippet.Title, dbSnippet.Description, dbSnippet.Tags, dbSnippet.Language, dbSnippet.Content, dbSnippet.LastModified)
+	return snippet, wrap(err, "Snippet '%s'", snippet.ID)
+}
+
+func (db *DB) InsertSnippets(snippets []model.Snippet) ([]model.Snippet, error) {
+	inserted := make([]model.Snippet, 0, len(snippets))
+	err := db.transaction(func(tx *DB) error {
+		for _, snippet := range snippets {
+			snippet, err := tx.InsertSnippet(snippet)
+			if err != nil {
+				return err
+			}
+			inserted = append(inserted, snippet)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inserted, nil
 }
 
 func (db *DB) UpdateSnippet(snippet model.Snippet) error {
 	query := `UPDATE snippets SET team_id = ?, title = ?, description = ?, tags = ?, language = ?, content = ?, last_modified = ? WHERE id = ?`
-	_, err := db.Exec(query, snippet.TeamID, snippet.Title, snippet.Description, snippet.Tags, snippet.Language, snippet.Content, snippet.LastModified, snippet.ID)
-	return err
+	result, err := db.conn.Exec(query, snippet.TeamID, snippet.Title, snippet.Description, snippet.Tags, snippet.Language, snippet.Content, snippet.LastModified, snippet.ID)
+	return affected(result, err, "Snippet '%s'", snippet.ID)
 }
 
 func (db *DB) DeleteSnippet(id model.ID) error {
 	query := `DELETE FROM snippets WHERE id = ?`
-	_, err := db.Exec(query, id)
-	return err
+	result, err := db.conn.Exec(query, id)
+	return affected(result, err, "Snippet '%s'", id)
 }
 
 func (db *DB) GetTeamByID(teamID string) (model.Team, error) {
-	query := `SELECT name, display_name, created, last_modified, password_hash, admin_hash FROM teams WHERE name = ?`
-	row := db.QueryRow(query, teamID)
+	query := `SELECT name, display_name, created, last_modified, password_hash, admin_hash, readonly_hash, generation FROM teams WHERE name = ?`
+	row := db.conn.QueryRow(query, teamID)
 	var dbTeam model.DBTeam
-	err := row.Scan(&dbTeam.Name, &dbTeam.DisplayName, &dbTeam.Created, &dbTeam.LastModified, &dbTeam.PasswordHash, &dbTeam.AdminHash)
+	err := row.Scan(&dbTeam.Name, &dbTeam.DisplayName, &dbTeam.Created, &dbTeam.LastModified, &dbTeam.PasswordHash, &dbTeam.AdminHash, &dbTeam.ReadOnlyHash, &dbTeam.Generation)
 	if err != nil {
-		return model.Team{}, err
+		return model.Team{}, wrap(err, "Team with name '%s'", teamID)
 	}
 	return dbTeam.ToTeam(), nil
 }
@@ -171,31 +323,34 @@ func (db *DB) GetTeamByID(teamID string) (model.Team, error) {
 func (db *DB) InsertTeam(teamId, displayName, password, adminPassword string) error {
 	lastModified := time.Now().Format(time.RFC3339)
 	created := lastModified
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err := db.passwords.Check(password, adminPassword); err != nil {
+		return err
+	}
+	hashedPassword, err := db.passwords.Hash(password)
 	if err != nil {
 		return err
 	}
-	hashedAdminPassword, err := bcrypt.GenerateFromPassword([]byte(adminPassword), bcrypt.DefaultCost)
+	hashedAdminPassword, err := db.passwords.Hash(adminPassword)
 	if err != nil {
 		return err
 	}
 	query := `INSERT INTO teams (name, display_name, created, last_modified, password_hash, admin_hash) VALUES (?, ?, ?, ?, ?, ?)`
-	_, err = db.Exec(query, teamId, displayName, created, lastModified, hashedPassword, hashedAdminPassword)
-	return err
+	_, err = db.conn.Exec(query, teamId, displayName, created, lastModified, hashedPassword, hashedAdminPassword)
+	return wrap(err, "Team with name '%s'", teamId)
 }
 
 func (db *DB) UpdateTeam(team model.Team) error {
 	team.LastModified = time.Now()
 	dbTeam := team.ToDBTeam()
-	query := `UPDATE teams SET display_name = ?, created = ?, last_modified = ?, password_hash = ?, admin_hash = ? WHERE name = ?`
-	_, err := db.Exec(query, dbTeam.DisplayName, dbTeam.Created, dbTeam.LastModified, dbTeam.PasswordHash, dbTeam.AdminHash, dbTeam.Name)
-	return err
+	query := `UPDATE teams SET display_name = ?, created = ?, last_modified = ?, password_hash = ?, admin_hash = ?, readonly_hash = ?, generation = ? WHERE name = ?`
+	result, err := db.conn.Exec(query, dbTeam.DisplayName, dbTeam.Created, dbTeam.LastModified, dbTeam.PasswordHash, dbTeam.AdminHash, dbTeam.ReadOnlyHash, dbTeam.Generation, dbTeam.Name)
+	return affected(result, err, "Team with name '%s'", team.Name)
 }
 
 func (db *DB) DeleteTeam(teamID string) error {
 	query := `DELETE FROM teams WHERE name = ?`
-	_, err := db.Exec(query, teamID)
-	return err
+	result, err := db.conn.Exec(query, teamID)
+	return affected(result, err, "Team with name '%s'", teamID)
 }
 
 func (db *DB) CheckTeamPassword(teamID string, password string, admin bool) (bool, error) {
@@ -210,13 +365,10 @@ func (db *DB) CheckTeamPassword(teamID string, password string, admin bool) (boo
 	var hash string
 
 	query := `SELECT display_name, ` + hash_field + ` FROM teams WHERE name = ?`
-	row := db.QueryRow(query, teamID)
+	row := db.conn.QueryRow(query, teamID)
 	err := row.Scan(&displayName, &hash)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return false, fmt.Errorf("Team with name '%s' was not found", teamID)
-		}
-		return false, err
+		return false, wrap(err, "Team with name '%s'", teamID)
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
@@ -229,3 +381,277 @@ func (db *DB) CheckTeamPassword(teamID string, password string, admin bool) (boo
 
 	return true, nil
 }
+
+// CheckTeamRole returns the highest role the password grants in the team, RoleNone if it matches no password.
+// A matching hash with a lower cost than the password policy asks for is replaced with a new one, which keeps
+// the sessions of the team, see session.Fingerprint.
+func (db *DB) CheckTeamRole(teamID string, password string) (model.Role, error) {
+	var adminHash, passwordHash, readOnlyHash string
+
+	query := `SELECT admin_hash, password_hash, readonly_hash FROM teams WHERE name = ?`
+	row := db.conn.QueryRow(query, teamID)
+	err := row.Scan(&adminHash, &passwordHash, &readOnlyHash)
+	if err != nil {
+		return model.RoleNone, wrap(err, "Team with name '%s'", teamID)
+	}
+
+	candidates := []struct {
+		hash   string
+		role   model.Role
+		column string
+	}{
+		{adminHash, model.RoleAdmin, "admin_hash"},
+		{passwordHash, model.RoleMember, "password_hash"},
+		{readOnlyHash, model.RoleReadOnly, "readonly_hash"},
+	}
+	for _, candidate := range candidates {
+		if candidate.hash == "" {
+			continue
+		}
+		err = bcrypt.CompareHashAndPassword([]byte(candidate.hash), []byte(password))
+		if err == nil {
+			if db.passwords.NeedsRehash(candidate.hash) {
+				db.rehash(teamID, candidate.column, candidate.hash, password)
+			}
+			return candidate.role, nil
+		}
+		if err != bcrypt.ErrMismatchedHashAndPassword {
+			return model.RoleNone, err
+		}
+	}
+
+	return model.RoleNone, nil
+}
+
+// rehash replaces the hash in column with one of the current cost, unless the password was rotated meanwhile.
+// The password was correct either way, so a failure is not reported and the rehash is tried again next time.
+func (db *DB) rehash(teamID string, column string, old string, password string) {
+	hash, err := db.passwords.Hash(password)
+	if err != nil {
+		return
+	}
+	query := `UPDATE teams SET ` + column + ` = ? WHERE name = ? AND ` + column + ` = ?`
+	db.conn.Exec(query, hash, teamID, old)
+}
+
+func (db *DB) CreateSession(teamID string, role model.Role) (model.Session, error) {
+	team, err := db.GetTeamByID(teamID)
+	if err != nil {
+		return model.Session{}, err
+	}
+	return db.sessions.Issue(team, role)
+}
+
+// CheckSession returns the role of a session token, RoleNone if it is invalid, expired, revoked,
+// issued for another team or issued before a password of the team changed.
+func (db *DB) CheckSession(teamID string, token string) (model.Role, error) {
+	claims, err := db.sessions.Parse(token)
+	if err != nil || claims.TeamID != teamID {
+		return model.RoleNone, nil
+	}
+
+	var id string
+	row := db.conn.QueryRow(`SELECT id FROM revoked_sessions WHERE id = ?`, claims.ID)
+	err = row.Scan(&id)
+	if err == nil {
+		return model.RoleNone, nil
+	}
+	if err != sql.ErrNoRows {
+		return model.RoleNone, wrap(err, "Session")
+	}
+
+	team, err := db.GetTeamByID(teamID)
+	if err != nil {
+		return model.RoleNone, err
+	}
+	if session.Fingerprint(team) != claims.Fingerprint {
+		return model.RoleNone, nil
+	}
+
+	return claims.Role, nil
+}
+
+func (db *DB) RevokeSession(teamID string, token string) error {
+	claims, err := db.sessions.Parse(token)
+	if err != nil {
+		return errs.Errorf(errs.ErrUnauthorized, "Invalid session: %w", err)
+	}
+	if claims.TeamID != teamID {
+		return errs.Errorf(errs.ErrUnauthorized, "Session does not belong to team '%s'", teamID)
+	}
+
+	query := `INSERT OR IGNORE INTO revoked_sessions (id, expires) VALUES (?, ?)`
+	_, err = db.conn.Exec(query, claims.ID, time.Unix(claims.Expires, 0).Format(time.RFC3339))
+	if err != nil {
+		return wrap(err, "Session")
+	}
+	return db.purgeRevokedSessions(time.Now())
+}
+
+// purgeRevokedSessions deletes the revocations of sessions that expired before now, they are refused anyway.
+func (db *DB) purgeRevokedSessions(now time.Time) error {
+	_, err := db.conn.Exec(`DELETE FROM revoked_sessions WHERE julianday(expires) < julianday(?)`, now.Format(time.RFC3339))
+	return wrap(err, "Revoked sessions")
+}
+
+func (db *DB) InsertAPIKey(key model.APIKey) error {
+	dbKey := key.ToDBAPIKey()
+	query := `INSERT INTO api_keys (id, team_id, name, scope, tags, hash, created, expires, last_used) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := db.conn.Exec(query, dbKey.ID, dbKey.TeamID, dbKey.Name, dbKey.Scope, dbKey.Tags, dbKey.Hash, dbKey.Created, dbKey.Expires, dbKey.LastUsed)
+	return wrap(err, "API key '%s'", key.ID)
+}
+
+// GetAPIKeys returns the keys of a team without their hashes.
+func (db *DB) GetAPIKeys(teamID string) ([]model.APIKey, error) {
+	query := `SELECT id, team_id, name, scope, tags, created, expires, last_used FROM api_keys WHERE team_id = ?`
+	rows, err := db.conn.Query(query, teamID)
+	if err != nil {
+		return nil, wrap(err, "API keys of team '%s'", teamID)
+	}
+	defer rows.Close()
+
+	keys := []model.APIKey{}
+	for rows.Next() {
+		var dbKey model.DBAPIKey
+		err := rows.Scan(&dbKey.ID, &dbKey.TeamID, &dbKey.Name, &dbKey.Scope, &dbKey.Tags, &dbKey.Created, &dbKey.Expires, &dbKey.LastUsed)
+		if err != nil {
+			return nil, wrap(err, "API keys of team '%s'", teamID)
+		}
+		keys = append(keys, dbKey.ToAPIKey())
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrap(err, "API keys of team '%s'", teamID)
+	}
+
+	return keys, nil
+}
+
+func (db *DB) DeleteAPIKey(teamID string, id string) error {
+	query := `DELETE FROM api_keys WHERE team_id = ? AND id = ?`
+	result, err := db.conn.Exec(query, teamID, id)
+	return affected(result, err, "API key '%s'", id)
+}
+
+// CheckAPIKey returns the key a token belongs to and records its use.
+// Unknown, expired and foreign keys are returned as a key with the RoleNone scope.
+func (db *DB) CheckAPIKey(teamID string, token string) (model.APIKey, error) {
+	id, secret, ok := model.ParseAPIKey(token)
+	if !ok {
+		return model.APIKey{}, nil
+	}
+
+	query := `SELECT id, team_id, name, scope, tags, hash, created, expires, last_used FROM api_keys WHERE id = ? AND team_id = ?`
+	row := db.conn.QueryRow(query, id, teamID)
+	var dbKey model.DBAPIKey
+	err := row.Scan(&dbKey.ID, &dbKey.TeamID, &dbKey.Name, &dbKey.Scope, &dbKey.Tags, &dbKey.Hash, &dbKey.Created, &dbKey.Expires, &dbKey.LastUsed)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.APIKey{}, nil
+		}
+		return model.APIKey{}, wrap(err, "API key '%s'", id)
+	}
+
+	key := dbKey.ToAPIKey()
+	now := time.Now()
+	if !key.Matches(secret) || key.Expired(now) {
+		return model.APIKey{}, nil
+	}
+
+	_, err = db.conn.Exec(`UPDATE api_keys SET last_used = ? WHERE id = ?`, now.Format(time.RFC3339), key.ID)
+	if err != nil {
+		return model.APIKey{}, wrap(err, "API key '%s'", id)
+	}
+	key.LastUsed = now
+	key.Hash = ""
+	return key, nil
+}
+
+func (db *DB) GetLockouts(teamID string) ([]model.Lockout, error) {
+	query := `SELECT team_id, client, failures, last_failure, locked_until FROM lockouts WHERE ? = '' OR team_id = ?`
+	rows, err := db.conn.Query(query, teamID, teamID)
+	if err != nil {
+		return nil, wrap(err, "Lockouts")
+	}
+	defer rows.Close()
+
+	lockouts := []model.Lockout{}
+	for rows.Next() {
+		var dbLockout model.DBLockout
+		err := rows.Scan(&dbLockout.TeamID, &dbLockout.Client, &dbLockout.Failures, &dbLockout.LastFailure, &dbLockout.LockedUntil)
+		if err != nil {
+			return nil, wrap(err, "Lockouts")
+		}
+		lockouts = append(lockouts, dbLockout.ToLockout())
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrap(err, "Lockouts")
+	}
+
+	return lockouts, nil
+}
+
+func (db *DB) SaveLockout(lockout model.Lockout) error {
+	dbLockout := lockout.ToDBLockout()
+	query := `INSERT INTO lockouts (team_id, client, failures, last_failure, locked_until) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (team_id, client) DO UPDATE SET failures = excluded.failures, last_failure = excluded.last_failure, locked_until = excluded.locked_until`
+	_, err := db.conn.Exec(query, dbLockout.TeamID, dbLockout.Client, dbLockout.Failures, dbLockout.LastFailure, dbLockout.LockedUntil)
+	return wrap(err, "Lockout")
+}
+
+func (db *DB) ClearLockouts(teamID string, client string) error {
+	query := `DELETE FROM lockouts WHERE team_id = ? AND (? = '' OR client = ?)`
+	_, err := db.conn.Exec(query, teamID, client, client)
+	return wrap(err, "Lockouts")
+}
+
+func (db *DB) SetTeamPasswords(teamID string, passwords model.TeamPasswords) error {
+	team, err := db.GetTeamByID(teamID)
+	if err != nil {
+		return err
+	}
+
+	for _, password := range []string{passwords.Password, passwords.AdminPassword, passwords.ReadOnlyPassword} {
+		if password == "" {
+			continue
+		}
+		if err := db.passwords.CheckPassword(password); err != nil {
+			return err
+		}
+	}
+	if !db.passwords.AllowSameAdmin {
+		same := passwords.Password != "" && passwords.Password == passwords.AdminPassword
+		if passwords.Password != "" && passwords.AdminPassword == "" {
+			same = bcrypt.CompareHashAndPassword([]byte(team.AdminHash), []byte(passwords.Password)) == nil
+		}
+		if passwords.AdminPassword != "" && passwords.Password == "" {
+			same = bcrypt.CompareHashAndPassword([]byte(team.PasswordHash), []byte(passwords.AdminPassword)) == nil
+		}
+		if same {
+			return errs.Errorf(errs.ErrInvalid, "Admin password needs to be different from the regular password")
+		}
+	}
+
+	hashes := []struct {
+		password string
+		hash     *string
+	}{
+		{passwords.Password, &team.PasswordHash},
+		{passwords.AdminPassword, &team.AdminHash},
+		{passwords.ReadOnlyPassword, &team.ReadOnlyHash},
+	}
+	for _, h := range hashes {
+		if h.password == "" {
+			continue
+		}
+		hash, err := db.passwords.Hash(h.password)
+		if err != nil {
+			return err
+		}
+		*h.hash = hash
+	}
+	team.Generation++
+
+	return db.UpdateTeam(team)
+}
@@ -2,14 +2,17 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/snippetaccumulator/configloader"
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
 	"github.com/snippetaccumulator/snac/internal/backend/model"
 	"github.com/snippetaccumulator/snac/internal/common"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func connect(t *testing.T) Database {
@@ -88,7 +91,7 @@ func teamCreateIfNotExist(connection Database, t *testing.T) string {
 		return teamName
 	}
 
-	err = connection.InsertTeam(teamName, teamName, "password", "password")
+	err = connection.InsertTeam(teamName, teamName, "password", "admin-password")
 	if err != nil {
 		t.Errorf("Error inserting team-testA: %+v", err)
 	}
@@ -119,6 +122,36 @@ func TestInsertSnippet(t *testing.T) {
 	insert(snippet, connection, t)
 }
 
+func TestInsertSnippets(t *testing.T) {
+	connection := connect(t)
+	defer connection.Close()
+	teamName := teamCreateIfNotExist(connection, t)
+
+	first := model.NewSnippetBuilder("batch1", teamName).Build()
+	second := model.NewSnippetBuilder("batch2", teamName).Build()
+	inserted, err := connection.InsertSnippets([]model.Snippet{first, second})
+	if err != nil {
+		t.Fatalf("InsertSnippets() error = %v", err)
+	}
+	if len(inserted) != 2 {
+		t.Errorf("InsertSnippets() inserted %d snippets, want 2", len(inserted))
+	}
+	if _, err = connection.GetByID(second.ID); err != nil {
+		t.Errorf("GetByID() after InsertSnippets() error = %v", err)
+	}
+
+	// a conflict rolls back the whole batch
+	third := model.NewSnippetBuilder("batch3", teamName).Build()
+	_, err = connection.InsertSnippets([]model.Snippet{third, first})
+	if !errors.Is(err, errs.ErrConflict) {
+		t.Errorf("InsertSnippets() with a duplicate error = %v, want ErrConflict", err)
+	}
+	_, err = connection.GetByID(third.ID)
+	if !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("GetByID() after failed InsertSnippets() error = %v, want ErrNotFound", err)
+	}
+}
+
 func TestGetSnippet(t *testing.T) {
 	connection := connect(t)
 	defer connection.Close()
@@ -163,7 +196,7 @@ func TestGetByTeam(t *testing.T) {
 	deleteAll(connection, t)
 	teamName := teamCreateIfNotExist(connection, t)
 
-	snippet := model.NewSnippetBuilder("test1", teamName).Build()
+	snippet := model.NewSnippetBuilder("test1", teamName).WithLanguage("go").Build()
 	insert(snippet, connection, t)
 
 	partials, err := connection.GetByTeamID(teamName)
@@ -184,11 +217,35 @@ func TestGetByTeam(t *testing.T) {
 	if partials[0].Title != snippet.Title {
 		t.Errorf("Got unexpected Title exp: %v, act: %v", snippet.Title, partials[0].Title)
 	}
+	if partials[0].Language != snippet.Language {
+		t.Errorf("Got unexpected Language exp: %v, act: %v", snippet.Language, partials[0].Language)
+	}
 	if reflect.DeepEqual(partials[0].Tags, snippet.Tags) {
 		t.Errorf("Got unexpected Tags exp: %v, act: %v", snippet.Tags, partials[0].Tags)
 	}
 }
 
+func TestGetSnippetsByTeam(t *testing.T) {
+	connection := connect(t)
+	defer connection.Close()
+	deleteAll(connection, t)
+	teamName := teamCreateIfNotExist(connection, t)
+
+	snippet := model.NewSnippetBuilder("test1", teamName).WithContent("echo test").Build()
+	insert(snippet, connection, t)
+
+	snippets, err := connection.GetSnippetsByTeamID(teamName)
+	if err != nil {
+		t.Errorf("error getting snippets: %+v", err)
+	}
+	if len(snippets) != 1 {
+		t.Fatalf("Got wrong number of snippets: exp %d, act: %d", 1, len(snippets))
+	}
+	if snippets[0].Content != snippet.Content {
+		t.Errorf("Got unexpected Content exp: %v, act: %v", snippet.Content, snippets[0].Content)
+	}
+}
+
 func TestCheckTeamPassword(t *testing.T) {
 	connection := connect(t)
 	defer connection.Close()
@@ -202,7 +259,7 @@ func TestCheckTeamPassword(t *testing.T) {
 	}
 
 	// test correct password admin
-	correct, err = connection.CheckTeamPassword(teamName, "password", true)
+	correct, err = connection.CheckTeamPassword(teamName, "admin-password", true)
 	if !correct || err != nil {
 		t.Errorf("Got wrong password: exp: true, act: %v, err: %v", correct, err)
 	}
@@ -219,3 +276,124 @@ func TestCheckTeamPassword(t *testing.T) {
 		t.Errorf("Got wrong password: exp: false, act: %v, err: %v", correct, err)
 	}
 }
+
+func TestErrorKinds(t *testing.T) {
+	connection := connect(t)
+	defer connection.Close()
+	teamName := teamCreateIfNotExist(connection, t)
+
+	_, err := connection.GetByID("does-not-exist")
+	if !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("GetByID() error = %v, want ErrNotFound", err)
+	}
+
+	err = connection.DeleteSnippet("does-not-exist")
+	if !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("DeleteSnippet() error = %v, want ErrNotFound", err)
+	}
+
+	_, err = connection.CheckTeamRole("does-not-exist", "password")
+	if !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("CheckTeamRole() error = %v, want ErrNotFound", err)
+	}
+
+	snippet := model.NewSnippetBuilder("test1", teamName).Build()
+	insert(snippet, connection, t)
+	_, err = connection.InsertSnippet(snippet)
+	if !errors.Is(err, errs.ErrConflict) {
+		t.Errorf("InsertSnippet() error = %v, want ErrConflict", err)
+	}
+}
+
+func TestSetTeamPasswords(t *testing.T) {
+	connection := connect(t)
+	defer connection.Close()
+	teamName := teamCreateIfNotExist(connection, t)
+
+	err := connection.SetTeamPasswords(teamName, model.TeamPasswords{Password: "short"})
+	if !errors.Is(err, errs.ErrInvalid) {
+		t.Errorf("SetTeamPasswords() error = %v, want ErrInvalid", err)
+	}
+
+	err = connection.SetTeamPasswords(teamName, model.TeamPasswords{ReadOnlyPassword: "read-only-password"})
+	if err != nil {
+		t.Errorf("SetTeamPasswords() error = %v", err)
+	}
+	role, err := connection.CheckTeamRole(teamName, "read-only-password")
+	if err != nil || role != model.RoleReadOnly {
+		t.Errorf("CheckTeamRole() = %v, %v, want %v", role, err, model.RoleReadOnly)
+	}
+}
+
+func TestCheckTeamRole_Rehash(t *testing.T) {
+	connection := connect(t)
+	defer connection.Close()
+	teamName := teamCreateIfNotExist(connection, t)
+
+	err := connection.SetTeamPasswords(teamName, model.TeamPasswords{Password: "password"})
+	if err != nil {
+		t.Fatalf("SetTeamPasswords() error = %v", err)
+	}
+	cheap, err := model.PasswordPolicy{Cost: bcrypt.MinCost}.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	_, err = connection.(*DB).Exec(`UPDATE teams SET password_hash = ? WHERE name = ?`, cheap, teamName)
+	if err != nil {
+		t.Fatalf("Error lowering the cost: %v", err)
+	}
+	session, err := connection.CreateSession(teamName, model.RoleMember)
+	if err != nil {
+		t.Fatalf("CreateSession() error = %v", err)
+	}
+
+	role, err := connection.CheckTeamRole(teamName, "password")
+	if err != nil || role != model.RoleMember {
+		t.Errorf("CheckTeamRole() = %v, %v, want %v", role, err, model.RoleMember)
+	}
+	team, err := connection.GetTeamByID(teamName)
+	if err != nil {
+		t.Fatalf("GetTeamByID() error = %v", err)
+	}
+	if cost, _ := bcrypt.Cost([]byte(team.PasswordHash)); cost != bcrypt.DefaultCost {
+		t.Errorf("cost after CheckTeamRole() = %d, want %d", cost, bcrypt.DefaultCost)
+	}
+
+	// a rehash keeps the sessions, a rotation ends them
+	role, err = connection.CheckSession(teamName, session.Token)
+	if err != nil || role != model.RoleMember {
+		t.Errorf("CheckSession() after rehash = %v, %v, want %v", role, err, model.RoleMember)
+	}
+	err = connection.SetTeamPasswords(teamName, model.TeamPasswords{Password: "password"})
+	if err != nil {
+		t.Fatalf("SetTeamPasswords() error = %v", err)
+	}
+	role, err = connection.CheckSession(teamName, session.Token)
+	if err != nil || role != model.RoleNone {
+		t.Errorf("CheckSession() after rotation = %v, %v, want %v", role, err, model.RoleNone)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	connection := connect(t)
+	defer connection.Close()
+	teamName := teamCreateIfNotExist(connection, t)
+
+	snippet := model.NewSnippetBuilder("dry run", teamName).Build()
+	err := connection.(Transactional).DryRun(func(db Database) error {
+		inserted, err := db.InsertSnippet(snippet)
+		if err != nil {
+			return err
+		}
+		_, err = db.GetByID(inserted.ID)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("DryRun() error = %v", err)
+	}
+
+	_, err = connection.GetByID(snippet.ID)
+	if !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("GetByID() after DryRun() error = %v, want ErrNotFound", err)
+	}
+}
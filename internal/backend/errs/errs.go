@@ -0,0 +1,108 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Kind is the category of an error. Check for it with errors.Is, e.g. errors.Is(err, errs.ErrNotFound).
+// It decides the HTTP status code of the error on a server and the exit code of the CLI.
+type Kind struct {
+	message string
+	status  int
+	exit    int
+}
+
+func (k *Kind) Error() string {
+	return k.message
+}
+
+// HTTPStatus returns the status code a server responds with for errors of the kind.
+func (k *Kind) HTTPStatus() int {
+	return k.status
+}
+
+// ExitCode returns the exit code of the CLI for errors of the kind.
+func (k *Kind) ExitCode() int {
+	return k.exit
+}
+
+var (
+	ErrInvalid      = &Kind{message: "Invalid", status: http.StatusBadRequest, exit: 2}
+	ErrUnauthorized = &Kind{message: "Unauthorized", status: http.StatusUnauthorized, exit: 3}
+	ErrForbidden    = &Kind{message: "Forbidden", status: http.StatusForbidden, exit: 4}
+	ErrNotFound     = &Kind{message: "Not found", status: http.StatusNotFound, exit: 5}
+	ErrConflict     = &Kind{message: "Conflict", status: http.StatusConflict, exit: 6}
+	ErrLimited      = &Kind{message: "Too many requests", status: http.StatusTooManyRequests, exit: 7}
+	ErrUnavailable  = &Kind{message: "Unavailable", status: http.StatusServiceUnavailable, exit: 8}
+)
+
+var kinds = []*Kind{ErrInvalid, ErrUnauthorized, ErrForbidden, ErrNotFound, ErrConflict, ErrLimited, ErrUnavailable}
+
+type kindError struct {
+	err  error
+	kind *Kind
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the kind first, so it takes precedence over the kinds of wrapped errors in KindOf.
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// Errorf formats an error like fmt.Errorf, including %w, that is also of kind.
+// Its message does not mention the kind.
+func Errorf(kind *Kind, format string, a ...any) error {
+	return &kindError{err: fmt.Errorf(format, a...), kind: kind}
+}
+
+// New returns a sentinel error that is also of kind, for errors that need to be told apart within a kind.
+func New(kind *Kind, message string) error {
+	return &kindError{err: errors.New(message), kind: kind}
+}
+
+// KindOf returns the outermost kind of err, nil if it has none.
+func KindOf(err error) *Kind {
+	var kind *Kind
+	if errors.As(err, &kind) {
+		return kind
+	}
+	return nil
+}
+
+// HTTPStatus returns the status code for err, 500 if it has no kind.
+func HTTPStatus(err error) int {
+	if kind := KindOf(err); kind != nil {
+		return kind.status
+	}
+	return http.StatusInternalServerError
+}
+
+// FromHTTPStatus returns the kind of errors a server responds with status for, nil if there is none.
+// Server errors without a kind are unavailable.
+func FromHTTPStatus(status int) *Kind {
+	for _, kind := range kinds {
+		if kind.status == status {
+			return kind
+		}
+	}
+	if status >= http.StatusInternalServerError {
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// ExitCode returns the exit code for err, 0 for nil and 1 if it has no kind.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if kind := KindOf(err); kind != nil {
+		return kind.exit
+	}
+	return 1
+}
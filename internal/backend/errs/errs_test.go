@@ -0,0 +1,51 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorf(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := Errorf(ErrUnavailable, "Database error: %w", cause)
+
+	assert.EqualError(t, err, "Database error: connection refused")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, http.StatusServiceUnavailable, HTTPStatus(err))
+	assert.Equal(t, 8, ExitCode(err))
+}
+
+func TestKindOf(t *testing.T) {
+	notFound := Errorf(ErrNotFound, "Team with name 'team1' was not found")
+	// the outermost kind decides
+	err := Errorf(ErrUnauthorized, "Incorrect password: %w", notFound)
+	assert.Equal(t, ErrUnauthorized, KindOf(err))
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.Equal(t, ErrNotFound, KindOf(fmt.Errorf("Error while executing Get: %w", notFound)))
+	assert.Nil(t, KindOf(errors.New("plain")))
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatus(errors.New("plain")))
+	assert.Equal(t, 1, ExitCode(errors.New("plain")))
+	assert.Equal(t, 0, ExitCode(nil))
+}
+
+func TestNew(t *testing.T) {
+	sentinel := New(ErrUnauthorized, "Incorrect password")
+	err := fmt.Errorf("%w for team 'team1'", sentinel)
+
+	assert.EqualError(t, err, "Incorrect password for team 'team1'")
+	assert.ErrorIs(t, err, sentinel)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.NotErrorIs(t, err, ErrForbidden)
+}
+
+func TestFromHTTPStatus(t *testing.T) {
+	assert.Equal(t, ErrNotFound, FromHTTPStatus(http.StatusNotFound))
+	assert.Equal(t, ErrUnavailable, FromHTTPStatus(http.StatusBadGateway))
+	assert.Nil(t, FromHTTPStatus(http.StatusTeapot))
+}
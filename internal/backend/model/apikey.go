@@ -0,0 +1,173 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const apiKeyPrefix = "snac_"
+
+// APIKey grants automation access to a team without its password.
+// The key itself is only shown once when it is created, only a hash of its secret is stored.
+type APIKey struct {
+	ID     string
+	TeamID string
+	Name   string
+	// Scope is the role the key grants, at most RoleMember.
+	Scope Role
+	// Tags restricts the key to snippets with any of them, empty means all snippets.
+	Tags     []string
+	Hash     string
+	Created  time.Time
+	Expires  time.Time
+	LastUsed time.Time
+}
+
+type DBAPIKey struct {
+	ID       string
+	TeamID   string
+	Name     string
+	Scope    int
+	Tags     string
+	Hash     string
+	Created  string
+	Expires  string
+	LastUsed string
+}
+
+const APIKeyTableSql = `
+CREATE TABLE IF NOT EXISTS api_keys (
+	id TEXT PRIMARY KEY,
+	team_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	scope INTEGER NOT NULL,
+	tags TEXT NOT NULL DEFAULT '',
+	hash TEXT NOT NULL,
+	created TEXT NOT NULL,
+	expires TEXT NOT NULL DEFAULT '',
+	last_used TEXT NOT NULL DEFAULT '',
+	FOREIGN KEY (team_id) REFERENCES teams(name)
+);
+`
+
+// NewAPIKey creates a key and returns it together with the token to hand out, which is not stored anywhere.
+// A zero expires means the key never expires.
+func NewAPIKey(teamID, name string, scope Role, tags []string, expires time.Time) (APIKey, string, error) {
+	if scope != RoleReadOnly && scope != RoleMember {
+		return APIKey{}, "", fmt.Errorf("API keys can only have the %s or %s scope", RoleReadOnly, RoleMember)
+	}
+
+	id := make([]byte, 6)
+	secret := make([]byte, 24)
+	if _, err := rand.Read(id); err != nil {
+		return APIKey{}, "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return APIKey{}, "", err
+	}
+
+	key := APIKey{
+		ID:      hex.EncodeToString(id),
+		TeamID:  teamID,
+		Name:    name,
+		Scope:   scope,
+		Tags:    tags,
+		Hash:    HashAPIKeySecret(hex.EncodeToString(secret)),
+		Created: time.Now(),
+		Expires: expires,
+	}
+	return key, apiKeyPrefix + key.ID + "_" + hex.EncodeToString(secret), nil
+}
+
+// ParseAPIKey splits a token from NewAPIKey into the ID of its key and its secret.
+func ParseAPIKey(token string) (id string, secret string, ok bool) {
+	rest, ok := strings.CutPrefix(token, apiKeyPrefix)
+	if !ok {
+		return "", "", false
+	}
+	return strings.Cut(rest, "_")
+}
+
+// HashAPIKeySecret hashes the random secret of a key. Unlike passwords it has enough entropy for a plain hash.
+func HashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Matches reports whether secret belongs to the key.
+func (k APIKey) Matches(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(k.Hash), []byte(HashAPIKeySecret(secret))) == 1
+}
+
+// Expired reports whether the key has expired at now.
+func (k APIKey) Expired(now time.Time) bool {
+	return !k.Expires.IsZero() && !now.Before(k.Expires)
+}
+
+// Allows reports whether a snippet with tags is within the tags of the key.
+func (k APIKey) Allows(tags []string) bool {
+	if len(k.Tags) == 0 {
+		return true
+	}
+	for _, allowed := range k.Tags {
+		for _, tag := range tags {
+			if tag == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func parseOptionalTime(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+func (k APIKey) ToDBAPIKey() DBAPIKey {
+	return DBAPIKey{
+		ID:       k.ID,
+		TeamID:   k.TeamID,
+		Name:     k.Name,
+		Scope:    int(k.Scope),
+		Tags:     strings.Join(k.Tags, ","),
+		Hash:     k.Hash,
+		Created:  k.Created.Format(time.RFC3339),
+		Expires:  formatOptionalTime(k.Expires),
+		LastUsed: formatOptionalTime(k.LastUsed),
+	}
+}
+
+func (k DBAPIKey) ToAPIKey() APIKey {
+	tags := []string{}
+	if k.Tags != "" {
+		tags = strings.Split(k.Tags, ",")
+	}
+	created, _ := time.Parse(time.RFC3339, k.Created)
+	return APIKey{
+		ID:       k.ID,
+		TeamID:   k.TeamID,
+		Name:     k.Name,
+		Scope:    Role(k.Scope),
+		Tags:     tags,
+		Hash:     k.Hash,
+		Created:  created,
+		Expires:  parseOptionalTime(k.Expires),
+		LastUsed: parseOptionalTime(k.LastUsed),
+	}
+}
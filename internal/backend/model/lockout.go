@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// LockoutAllClients is the client of the lockout that counts the failed attempts of all clients of a team.
+const LockoutAllClients = "*"
+
+// Lockout tracks failed password checks for a team from one client, or from all of them, see LockoutAllClients.
+type Lockout struct {
+	TeamID      string
+	Client      string
+	Failures    int
+	LastFailure time.Time
+	// LockedUntil is the time until which password checks from the client are refused.
+	LockedUntil time.Time
+}
+
+// Locked reports whether the lockout is still active at now.
+func (l Lockout) Locked(now time.Time) bool {
+	return now.Before(l.LockedUntil)
+}
+
+type DBLockout struct {
+	TeamID      string
+	Client      string
+	Failures    int
+	LastFailure string
+	LockedUntil string
+}
+
+const LockoutTableSql = `
+CREATE TABLE IF NOT EXISTS lockouts (
+	team_id TEXT NOT NULL,
+	client TEXT NOT NULL,
+	failures INTEGER NOT NULL,
+	last_failure TEXT NOT NULL,
+	locked_until TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (team_id, client)
+);
+`
+
+func (l Lockout) ToDBLockout() DBLockout {
+	return DBLockout{
+		TeamID:      l.TeamID,
+		Client:      l.Client,
+		Failures:    l.Failures,
+		LastFailure: formatOptionalTime(l.LastFailure),
+		LockedUntil: formatOptionalTime(l.LockedUntil),
+	}
+}
+
+func (l DBLockout) ToLockout() Lockout {
+	return Lockout{
+		TeamID:      l.TeamID,
+		Client:      l.Client,
+		Failures:    l.Failures,
+		LastFailure: parseOptionalTime(l.LastFailure),
+		LockedUntil: parseOptionalTime(l.LockedUntil),
+	}
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const DefaultMinPasswordLength = 8
+
+// PasswordPolicy restricts the passwords of teams and sets how they are hashed.
+// Zero values use the defaults, DefaultMinPasswordLength and bcrypt.DefaultCost.
+type PasswordPolicy struct {
+	MinLength int `yaml:"min_length" json:"min_length"`
+	// AllowSameAdmin allows the admin password to be the same as the regular one.
+	AllowSameAdmin bool `yaml:"allow_same_admin" json:"allow_same_admin"`
+	// Cost is the bcrypt cost of new hashes. Stored hashes with a lower cost are rehashed when their password is used.
+	Cost int `yaml:"bcrypt_cost" json:"bcrypt_cost"`
+}
+
+func (p PasswordPolicy) minLength() int {
+	if p.MinLength <= 0 {
+		return DefaultMinPasswordLength
+	}
+	return p.MinLength
+}
+
+func (p PasswordPolicy) cost() int {
+	if p.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return p.Cost
+}
+
+// CheckPassword checks a single password of a team against the policy.
+func (p PasswordPolicy) CheckPassword(password string) error {
+	if len(password) < p.minLength() {
+		return errs.Errorf(errs.ErrInvalid, "Password needs to be at least %d characters long", p.minLength())
+	}
+	return nil
+}
+
+// Check checks the regular and admin password of a team against the policy.
+func (p PasswordPolicy) Check(password string, adminPassword string) error {
+	if err := p.CheckPassword(password); err != nil {
+		return err
+	}
+	if err := p.CheckPassword(adminPassword); err != nil {
+		return errs.Errorf(errs.ErrInvalid, "Admin password is invalid: %w", err)
+	}
+	if !p.AllowSameAdmin && password == adminPassword {
+		return errs.Errorf(errs.ErrInvalid, "Admin password needs to be different from the regular password")
+	}
+	return nil
+}
+
+func (p PasswordPolicy) Hash(password string) (string, error) {
+	if p.cost() < bcrypt.MinCost || p.cost() > bcrypt.MaxCost {
+		return "", errs.Errorf(errs.ErrInvalid, "bcrypt cost needs to be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, p.cost())
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), p.cost())
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// NeedsRehash reports whether hash was created with a lower cost than the policy asks for.
+func (p PasswordPolicy) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost < p.cost()
+}
+
+// TeamPasswords are new plain passwords of a team, empty ones are kept as they are.
+type TeamPasswords struct {
+	Password      string `json:"password"`
+	AdminPassword string `json:"admin_password"`
+	// ReadOnlyPassword is optional, see Team.ReadOnlyHash.
+	ReadOnlyPassword string `json:"readonly_password"`
+}
+
+// Empty reports whether no password is changed.
+func (p TeamPasswords) Empty() bool {
+	return p.Password == "" && p.AdminPassword == "" && p.ReadOnlyPassword == ""
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	var policy PasswordPolicy
+
+	assert.ErrorIs(t, policy.Check("", "admin-password"), errs.ErrInvalid)
+	assert.EqualError(t, policy.Check("short", "admin-password"), "Password needs to be at least 8 characters long")
+	assert.EqualError(t, policy.Check("password", "password"), "Admin password needs to be different from the regular password")
+	assert.Nil(t, policy.Check("password", "admin-password"))
+
+	policy = PasswordPolicy{MinLength: 4, AllowSameAdmin: true}
+	assert.Nil(t, policy.Check("pass", "pass"))
+}
+
+func TestPasswordPolicy_Rehash(t *testing.T) {
+	low := PasswordPolicy{Cost: bcrypt.MinCost}
+	hash, err := low.Hash("password")
+	assert.Nil(t, err)
+
+	assert.False(t, low.NeedsRehash(hash))
+	assert.True(t, PasswordPolicy{Cost: bcrypt.MinCost + 1}.NeedsRehash(hash))
+
+	_, err = PasswordPolicy{Cost: bcrypt.MaxCost + 1}.Hash("password")
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+}
+
+func TestNewTeam(t *testing.T) {
+	_, err := NewTeam("team1", "Team", "", "admin-password")
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+
+	team, err := NewTeam("team1", "Team", "password", "admin-password")
+	assert.Nil(t, err)
+	assert.Equal(t, "team1", team.Name)
+	assert.Nil(t, bcrypt.CompareHashAndPassword([]byte(team.AdminHash), []byte("admin-password")))
+}
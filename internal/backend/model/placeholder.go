@@ -0,0 +1,168 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+)
+
+// Placeholder is a variable in the content of a snippet that is filled in when it is used:
+//
+//	${name:default}          proposes default
+//	${name:}                 asks for a value, proposing none
+//	${name|one,two,three}    allows only the choices, the first one is the default
+//	${name:two|one,two,three}
+//
+// Without a default or choices, ${name} is left alone, as are the ${name:-default}, ${name:=default},
+// ${name:?error} and ${name:+other} of shell scripts, and defaults starting with a space like ${name: -1}.
+// $${ is a literal ${ for other cases, e.g. ${name:2} of a shell script.
+type Placeholder struct {
+	Name       string
+	Default    string
+	HasDefault bool
+	Choices    []string
+	// Start and End are the byte offsets of the placeholder in the content.
+	Start int
+	End   int
+}
+
+var placeholderPattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_.-]*)(?::((?:[^-=?+\s|}][^|}]*)?))?(?:\|([^}]*))?\}`)
+
+// Placeholders returns every placeholder in content, in order.
+func Placeholders(content string) []Placeholder {
+	var placeholders []Placeholder
+	for _, part := range Parts(content) {
+		if part.Placeholder != nil {
+			placeholders = append(placeholders, *part.Placeholder)
+		}
+	}
+	return placeholders
+}
+
+// Part is a piece of content, either literal text or a placeholder.
+type Part struct {
+	Text        string
+	Placeholder *Placeholder
+}
+
+// Parts splits content into literal text, in which $${ is a plain ${, and placeholders, in order.
+func Parts(content string) []Part {
+	var parts []Part
+	var text strings.Builder
+	last := 0
+	for _, match := range placeholderPattern.FindAllStringSubmatchIndex(content, -1) {
+		text.WriteString(content[last:match[0]])
+		last = match[1]
+		// escaped placeholders have no name
+		if match[2] < 0 {
+			text.WriteString("${")
+			continue
+		}
+		// without a default or choices it is a variable of a shell script
+		if match[4] < 0 && match[6] < 0 {
+			text.WriteString(content[match[0]:match[1]])
+			continue
+		}
+		if text.Len() > 0 {
+			parts = append(parts, Part{Text: text.String()})
+			text.Reset()
+		}
+		placeholder := parsePlaceholder(content, match)
+		parts = append(parts, Part{Placeholder: &placeholder})
+	}
+	text.WriteString(content[last:])
+	if text.Len() > 0 {
+		parts = append(parts, Part{Text: text.String()})
+	}
+	return parts
+}
+
+// parsePlaceholder returns the placeholder of a match of placeholderPattern in content.
+func parsePlaceholder(content string, match []int) Placeholder {
+	placeholder := Placeholder{Name: content[match[2]:match[3]], Start: match[0], End: match[1]}
+	// ${name:} only marks a placeholder, it proposes no default
+	if match[4] >= 0 && match[5] > match[4] {
+		placeholder.Default = content[match[4]:match[5]]
+		placeholder.HasDefault = true
+	}
+	if match[6] >= 0 {
+		for _, choice := range strings.Split(content[match[6]:match[7]], ",") {
+			if choice = strings.TrimSpace(choice); choice != "" {
+				placeholder.Choices = append(placeholder.Choices, choice)
+			}
+		}
+		if !placeholder.HasDefault && len(placeholder.Choices) > 0 {
+			placeholder.Default = placeholder.Choices[0]
+			placeholder.HasDefault = true
+		}
+	}
+	return placeholder
+}
+
+// Variables returns the placeholders of content once per name, in the order they first appear.
+// A name used several times takes the default and choices of its first placeholder that has them.
+func Variables(content string) []Placeholder {
+	var variables []Placeholder
+	index := map[string]int{}
+	for _, placeholder := range Placeholders(content) {
+		i, ok := index[placeholder.Name]
+		if !ok {
+			index[placeholder.Name] = len(variables)
+			variables = append(variables, placeholder)
+			continue
+		}
+		if !variables[i].HasDefault && placeholder.HasDefault {
+			variables[i].Default = placeholder.Default
+			variables[i].HasDefault = true
+		}
+		if len(variables[i].Choices) == 0 {
+			variables[i].Choices = placeholder.Choices
+		}
+	}
+	return variables
+}
+
+// Check returns an ErrInvalid error if value is not one of the choices of the placeholder.
+func (p Placeholder) Check(value string) error {
+	if len(p.Choices) == 0 {
+		return nil
+	}
+	for _, choice := range p.Choices {
+		if choice == value {
+			return nil
+		}
+	}
+	return errs.Errorf(errs.ErrInvalid, "'%s' is not a choice for %s, use one of: %s", value, p.Name, strings.Join(p.Choices, ", "))
+}
+
+// Fill replaces the placeholders of content with the values of their names, or else their defaults.
+// It returns an ErrInvalid error for placeholders with neither, and for values that are not one of the choices.
+func Fill(content string, values map[string]string) (string, error) {
+	variables := map[string]Placeholder{}
+	for _, variable := range Variables(content) {
+		variables[variable.Name] = variable
+	}
+
+	var result strings.Builder
+	for _, part := range Parts(content) {
+		if part.Placeholder == nil {
+			result.WriteString(part.Text)
+			continue
+		}
+
+		variable := variables[part.Placeholder.Name]
+		value, ok := values[variable.Name]
+		if !ok && !variable.HasDefault {
+			return "", errs.Errorf(errs.ErrInvalid, "Missing a value for %s", variable.Name)
+		}
+		if !ok {
+			value = variable.Default
+		}
+		if err := variable.Check(value); err != nil {
+			return "", err
+		}
+		result.WriteString(value)
+	}
+	return result.String(), nil
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaceholders(t *testing.T) {
+	content := "ssh ${user:root}@${host:} -- kubectl -n ${namespace:staging|dev,staging, prod} get ${kind|pods,nodes} $${HOME}"
+
+	placeholders := Placeholders(content)
+	assert.Equal(t, []Placeholder{
+		{Name: "user", Default: "root", HasDefault: true, Start: 4, End: 16},
+		{Name: "host", Start: 17, End: 25},
+		{Name: "namespace", Default: "staging", HasDefault: true, Choices: []string{"dev", "staging", "prod"}, Start: 40, End: 78},
+		{Name: "kind", Default: "pods", HasDefault: true, Choices: []string{"pods", "nodes"}, Start: 83, End: 101},
+	}, placeholders)
+	assert.Equal(t, "${host:}", content[placeholders[1].Start:placeholders[1].End])
+	assert.Equal(t, "${kind|pods,nodes}", content[placeholders[3].Start:placeholders[3].End])
+
+	assert.Empty(t, Placeholders("echo $HOME $${HOME} ${1invalid:x} ${unclosed:x"))
+}
+
+func TestPlaceholders_Shell(t *testing.T) {
+	script := `echo "${HOME}" ${name:-default} ${name:=default} ${name:?missing} ${name:+set} ${name: -1} ${#name} ${name/a/b}`
+	assert.Empty(t, Placeholders(script))
+
+	parts := Parts(script)
+	assert.Equal(t, []Part{{Text: script}}, parts)
+}
+
+func TestVariables(t *testing.T) {
+	variables := Variables("${host:} ${port|80,443} ${host:localhost} ${port:80}")
+	assert.Equal(t, []Placeholder{
+		{Name: "host", Default: "localhost", HasDefault: true, Start: 0, End: 8},
+		{Name: "port", Default: "80", HasDefault: true, Choices: []string{"80", "443"}, Start: 9, End: 23},
+	}, variables)
+}
+
+func TestParts(t *testing.T) {
+	parts := Parts("echo $${HOME}/${dir:tmp}${file|a.txt} ${done} done")
+	assert.Equal(t, []Part{
+		{Text: "echo ${HOME}/"},
+		{Placeholder: &Placeholder{Name: "dir", Default: "tmp", HasDefault: true, Start: 14, End: 24}},
+		{Placeholder: &Placeholder{Name: "file", Default: "a.txt", HasDefault: true, Choices: []string{"a.txt"}, Start: 24, End: 37}},
+		{Text: " ${done} done"},
+	}, parts)
+
+	assert.Empty(t, Parts(""))
+}
+
+func TestFill(t *testing.T) {
+	content := "ssh ${user:root}@${host|} -p ${port|22,2222} # ${host|} ${HOME}"
+
+	filled, err := Fill(content, map[string]string{"host": "example.com"})
+	assert.Nil(t, err)
+	assert.Equal(t, "ssh root@example.com -p 22 # example.com ${HOME}", filled)
+
+	filled, err = Fill(content, map[string]string{"host": "example.com", "user": "admin", "port": "2222"})
+	assert.Nil(t, err)
+	assert.Equal(t, "ssh admin@example.com -p 2222 # example.com ${HOME}", filled)
+
+	_, err = Fill(content, map[string]string{})
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+	assert.ErrorContains(t, err, "host")
+
+	_, err = Fill(content, map[string]string{"host": "example.com", "port": "80"})
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+	assert.ErrorContains(t, err, "22, 2222")
+
+	filled, err = Fill("no placeholders", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "no placeholders", filled)
+}
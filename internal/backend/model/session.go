@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// Session is a signed, expiring token that is used instead of a team password.
+type Session struct {
+	Token   string
+	TeamID  string
+	Role    Role
+	Expires time.Time
+}
+
+const RevokedSessionTableSql = `
+CREATE TABLE IF NOT EXISTS revoked_sessions (
+	id TEXT PRIMARY KEY,
+	expires TEXT NOT NULL
+);
+`
@@ -35,10 +35,11 @@ type Snippet struct {
 }
 
 type PartialSnippet struct {
-	ID     ID
-	TeamID string
-	Title  string
-	Tags   []string
+	ID       ID
+	TeamID   string
+	Title    string
+	Tags     []string
+	Language string
 }
 
 // DBSnippet is a piece of code with a title, description, tags, language, and content, but with a string ID.
@@ -63,15 +64,16 @@ CREATE TABLE IF NOT EXISTS snippets (
     language TEXT,
     content TEXT NOT NULL,
     last_modified TEXT NOT NULL,
-    FOREIGN KEY (team_id) REFERENCES teams(id)
+    FOREIGN KEY (team_id) REFERENCES teams(name)
 );
 `
 
 type DBPartialSnippet struct {
-	ID     string
-	TeamID string
-	Title  string
-	Tags   string
+	ID       string
+	TeamID   string
+	Title    string
+	Tags     string
+	Language string
 }
 
 func join(tags []string) string {
@@ -128,9 +130,10 @@ func (s DBPartialSnippet) ToPartialSnippet() PartialSnippet {
 		tags = []string{}
 	}
 	return PartialSnippet{
-		ID:     ID(s.ID),
-		TeamID: s.TeamID,
-		Title:  s.Title,
-		Tags:   tags,
+		ID:       ID(s.ID),
+		TeamID:   s.TeamID,
+		Title:    s.Title,
+		Tags:     tags,
+		Language: s.Language,
 	}
 }
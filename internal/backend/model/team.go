@@ -2,10 +2,34 @@ package model
 
 import (
 	"time"
+)
+
+// Role is what a password grants within a team. Higher roles include everything the lower ones may do.
+type Role int
 
-	"golang.org/x/crypto/bcrypt"
+const (
+	RoleNone Role = iota
+	RoleReadOnly
+	RoleMember
+	RoleAdmin
+	RoleInstanceAdmin
 )
 
+func (r Role) String() string {
+	switch r {
+	case RoleReadOnly:
+		return "read-only"
+	case RoleMember:
+		return "member"
+	case RoleAdmin:
+		return "admin"
+	case RoleInstanceAdmin:
+		return "instance-admin"
+	default:
+		return "none"
+	}
+}
+
 type Team struct {
 	Name         string
 	DisplayName  string
@@ -13,6 +37,11 @@ type Team struct {
 	LastModified time.Time
 	PasswordHash string
 	AdminHash    string
+	// ReadOnlyHash is optional, if set its password may only read snippets.
+	ReadOnlyHash string
+	// Generation counts the rotations of the passwords, sessions of an older one are ended.
+	// A rehash of the same password keeps it.
+	Generation int
 }
 
 type DBTeam struct {
@@ -22,6 +51,8 @@ type DBTeam struct {
 	LastModified string
 	PasswordHash string
 	AdminHash    string
+	ReadOnlyHash string
+	Generation   int
 }
 
 const TeamTableSql = `
@@ -31,10 +62,19 @@ CREATE TABLE IF NOT EXISTS teams (
 	created TEXT NOT NULL,
 	last_modified TEXT NOT NULL,
 	password_hash TEXT NOT NULL,
-	admin_hash TEXT NOT NULL
+	admin_hash TEXT NOT NULL,
+	readonly_hash TEXT NOT NULL DEFAULT '',
+	generation INTEGER NOT NULL DEFAULT 0
 );
 `
 
+// TeamMigrationsSql add the columns of later versions to teams tables created before them, in order.
+// They fail with a duplicate column on tables that have the column already.
+var TeamMigrationsSql = []string{
+	`ALTER TABLE teams ADD COLUMN readonly_hash TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE teams ADD COLUMN generation INTEGER NOT NULL DEFAULT 0`,
+}
+
 func (t Team) ToDBTeam() DBTeam {
 	return DBTeam{
 		Name:         t.Name,
@@ -43,6 +83,8 @@ func (t Team) ToDBTeam() DBTeam {
 		LastModified: t.LastModified.Format(time.RFC3339),
 		PasswordHash: t.PasswordHash,
 		AdminHash:    t.AdminHash,
+		ReadOnlyHash: t.ReadOnlyHash,
+		Generation:   t.Generation,
 	}
 }
 
@@ -56,21 +98,31 @@ func (t DBTeam) ToTeam() Team {
 		LastModified: lastModified,
 		PasswordHash: t.PasswordHash,
 		AdminHash:    t.AdminHash,
+		ReadOnlyHash: t.ReadOnlyHash,
+		Generation:   t.Generation,
 	}
 }
 
+// NewTeam creates a team with the passwords hashed with the default policy, see PasswordPolicy.
 func NewTeam(name, displayName, password, adminPassword string) (Team, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	hashedAdminPassword, err := bcrypt.GenerateFromPassword([]byte(adminPassword), bcrypt.DefaultCost)
+	var policy PasswordPolicy
+	if err := policy.Check(password, adminPassword); err != nil {
+		return Team{}, err
+	}
+	hashedPassword, err := policy.Hash(password)
+	if err != nil {
+		return Team{}, err
+	}
+	hashedAdminPassword, err := policy.Hash(adminPassword)
 	if err != nil {
 		return Team{}, err
 	}
 	return Team{
-		Name:         displayName,
+		Name:         name,
 		DisplayName:  displayName,
 		Created:      time.Now(),
 		LastModified: time.Now(),
-		PasswordHash: string(hashedPassword),
-		AdminHash:    string(hashedAdminPassword),
+		PasswordHash: hashedPassword,
+		AdminHash:    hashedAdminPassword,
 	}, nil
 }
@@ -0,0 +1,243 @@
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/database"
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+)
+
+// Effect describes what a request would change, it is the result of requests built with DryRun.
+type Effect struct {
+	Operation string `json:"operation"`
+	// Target is the ID of the snippet, team or API key that is changed.
+	Target string `json:"target,omitempty"`
+	// Action is create, update, delete or none.
+	Action  string   `json:"action"`
+	Changes []Change `json:"changes"`
+}
+
+// Change is a field that is changed by a request. Password hashes are never shown, only whether they change.
+type Change struct {
+	Field string `json:"field"`
+	Old   any    `json:"old,omitempty"`
+	New   any    `json:"new,omitempty"`
+}
+
+const hiddenValue = "(hidden)"
+
+// explain is the innermost Handler of dry-run requests. It runs the operation in a transaction that is rolled back,
+// so it fails like the request would, and returns the Effect of the request instead of its result.
+func explain(r Request, db database.Database) (any, RequestReturn, error) {
+	tx, ok := db.(database.Transactional)
+	if !ok {
+		return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Dry runs are not supported by %T", db)
+	}
+
+	var effect Effect
+	err := tx.DryRun(func(db database.Database) error {
+		target, before, err := currentState(r, db)
+		if err != nil {
+			return err
+		}
+		data, _, err := dispatch(r, db)
+		if err != nil {
+			return err
+		}
+		if snippet, ok := data.(model.Snippet); ok && r.Operation == Insert {
+			target = snippet.ID.String()
+		}
+		if snippets, ok := data.([]model.Snippet); ok && r.Operation == InsertBatch {
+			target = strings.Join(snippetIDs(snippets), ",")
+		}
+		effect = newEffect(r, target, before, expectedState(r, before, data))
+		return nil
+	})
+	if err != nil {
+		return nil, ReturnNone, err
+	}
+	return effect, ReturnEffect, nil
+}
+
+// currentState returns the ID and the stored state of what the request changes, nil if it does not exist (yet).
+func currentState(r Request, db database.Database) (string, any, error) {
+	var target string
+	var state any
+	var err error
+	switch r.Operation {
+	case Insert, Update:
+		snippet, _ := r.Data.(model.Snippet)
+		target = snippet.ID.String()
+		state, err = db.GetByID(snippet.ID)
+	case Delete:
+		id, _ := r.Data.(model.ID)
+		target = id.String()
+		state, err = db.GetByID(id)
+	case InsertTeam, UpdateTeam:
+		team, _ := r.Data.(model.Team)
+		target = team.Name
+		state, err = db.GetTeamByID(team.Name)
+	case DeleteTeam:
+		target, _ = r.Data.(string)
+		state, err = db.GetTeamByID(target)
+	case RotatePassword:
+		target = r.teamID
+		state, err = db.GetTeamByID(target)
+	case CreateAPIKey, RevokeAPIKey:
+		if key, ok := r.Data.(model.APIKey); ok {
+			target = key.ID
+		} else {
+			target, _ = r.Data.(string)
+		}
+		var keys []model.APIKey
+		keys, err = db.GetAPIKeys(r.teamID)
+		for _, key := range keys {
+			if key.ID == target {
+				state = key
+			}
+		}
+	case ClearLockouts:
+		filter, _ := r.Data.(model.Lockout)
+		target = filter.TeamID
+		var lockouts, cleared []model.Lockout
+		lockouts, err = db.GetLockouts(target)
+		for _, lockout := range lockouts {
+			if filter.Client == "" || lockout.Client == filter.Client {
+				cleared = append(cleared, lockout)
+			}
+		}
+		if len(cleared) > 0 {
+			state = cleared
+		}
+	}
+
+	if errors.Is(err, errs.ErrNotFound) {
+		return target, nil, nil
+	}
+	return target, state, err
+}
+
+// expectedState returns the state after the request, data is the result of the request.
+func expectedState(r Request, before any, data any) any {
+	switch r.Operation {
+	case Insert:
+		if snippet, ok := data.(model.Snippet); ok {
+			return snippet
+		}
+		return r.Data
+	case InsertBatch:
+		if snippets, ok := data.([]model.Snippet); ok {
+			return snippets
+		}
+		return r.Data
+	case Update, InsertTeam, UpdateTeam:
+		return r.Data
+	case RotatePassword:
+		team, _ := before.(model.Team)
+		passwords, _ := r.Data.(model.TeamPasswords)
+		for _, change := range []struct {
+			password string
+			hash     *string
+		}{
+			{passwords.Password, &team.PasswordHash},
+			{passwords.AdminPassword, &team.AdminHash},
+			{passwords.ReadOnlyPassword, &team.ReadOnlyHash},
+		} {
+			if change.password != "" {
+				*change.hash = "new " + *change.hash
+			}
+		}
+		return team
+	case CreateAPIKey:
+		key, _ := r.Data.(model.APIKey)
+		key.TeamID = r.teamID
+		return key
+	case Delete, DeleteTeam, RevokeAPIKey, ClearLockouts:
+		return nil
+	}
+	return before
+}
+
+func newEffect(r Request, target string, before any, after any) Effect {
+	effect := Effect{
+		Operation: r.Operation.String(),
+		Target:    target,
+		Action:    "none",
+		Changes:   []Change{},
+	}
+	switch {
+	case before == nil && after == nil:
+		return effect
+	case before == nil:
+		effect.Action = "create"
+	case after == nil:
+		effect.Action = "delete"
+	default:
+		effect.Action = "update"
+	}
+
+	old, updated := fields(before), fields(after)
+	names := map[string]bool{}
+	for name := range old {
+		names[name] = true
+	}
+	for name := range updated {
+		names[name] = true
+	}
+	for name := range names {
+		if reflect.DeepEqual(old[name], updated[name]) {
+			continue
+		}
+		change := Change{Field: name, Old: old[name], New: updated[name]}
+		if strings.HasSuffix(name, "Hash") {
+			change.Old, change.New = hide(change.Old), hide(change.New)
+		}
+		effect.Changes = append(effect.Changes, change)
+	}
+	sort.Slice(effect.Changes, func(i, j int) bool {
+		return effect.Changes[i].Field < effect.Changes[j].Field
+	})
+
+	if effect.Action == "update" && len(effect.Changes) == 0 {
+		effect.Action = "none"
+	}
+	return effect
+}
+
+// fields returns the fields of a struct by name, or the value itself under "value" if it is no struct.
+func fields(value any) map[string]any {
+	if value == nil {
+		return map[string]any{}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return map[string]any{}
+	}
+	var result map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		var plain any
+		json.Unmarshal(data, &plain)
+		return map[string]any{"value": plain}
+	}
+	return result
+}
+
+func hide(value any) any {
+	if value == nil || value == "" {
+		return value
+	}
+	return hiddenValue
+}
+
+func snippetIDs(snippets []model.Snippet) []string {
+	ids := make([]string, len(snippets))
+	for i, snippet := range snippets {
+		ids[i] = snippet.ID.String()
+	}
+	return ids
+}
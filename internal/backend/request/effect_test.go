@@ -0,0 +1,120 @@
+package request
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/database"
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// transactionalDatabase runs dry runs directly on the mock and records that they were rolled back.
+type transactionalDatabase struct {
+	*MockDatabase
+	rolledBack bool
+}
+
+func (t *transactionalDatabase) DryRun(fn func(db database.Database) error) error {
+	defer func() { t.rolledBack = true }()
+	return fn(t.MockDatabase)
+}
+
+func TestExplain_Update(t *testing.T) {
+	db := &transactionalDatabase{MockDatabase: new(MockDatabase)}
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	old := model.Snippet{ID: "1", TeamID: "team1", Title: "Title", Content: "Sample", Tags: []string{"go"}}
+	updated := model.Snippet{ID: "1", TeamID: "team1", Title: "Title", Content: "Updated Sample", Tags: []string{"go", "sql"}}
+	db.On("GetByID", model.ID("1")).Return(old, nil)
+	db.On("UpdateSnippet", updated).Return(nil)
+
+	effect, err := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildUpdate(updated).Explain(db)
+	assert.Nil(t, err)
+	assert.True(t, db.rolledBack)
+	assert.Equal(t, Effect{
+		Operation: "Update",
+		Target:    "1",
+		Action:    "update",
+		Changes: []Change{
+			{Field: "Content", Old: "Sample", New: "Updated Sample"},
+			{Field: "Tags", Old: []any{"go"}, New: []any{"go", "sql"}},
+		},
+	}, effect)
+
+	db.AssertExpectations(t)
+}
+
+func TestExplain_InsertAndDelete(t *testing.T) {
+	db := &transactionalDatabase{MockDatabase: new(MockDatabase)}
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	snippet := model.Snippet{TeamID: "team1", Title: "New"}
+	inserted := model.Snippet{ID: "2", TeamID: "team1", Title: "New"}
+	db.On("GetByID", model.ID("")).Return(model.Snippet{}, errs.Errorf(errs.ErrNotFound, "Snippet '' was not found"))
+	db.On("InsertSnippet", snippet).Return(inserted, nil)
+	db.On("GetByID", model.ID("2")).Return(inserted, nil)
+	db.On("DeleteSnippet", model.ID("2")).Return(nil)
+
+	effect, err := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildInsert(snippet).Explain(db)
+	assert.Nil(t, err)
+	assert.Equal(t, "create", effect.Action)
+	assert.Equal(t, "2", effect.Target)
+	assert.Contains(t, effect.Changes, Change{Field: "Title", New: "New"})
+
+	effect, err = NewRequestBuilder().ForTeamByID("team1", "password", false).BuildDelete("2").Explain(db)
+	assert.Nil(t, err)
+	assert.Equal(t, "delete", effect.Action)
+	assert.Contains(t, effect.Changes, Change{Field: "Title", Old: "New"})
+
+	db.AssertExpectations(t)
+}
+
+func TestExplain_HidesHashes(t *testing.T) {
+	db := &transactionalDatabase{MockDatabase: new(MockDatabase)}
+	db.On("CheckTeamRole", "team1", "admin").Return(model.RoleAdmin, nil)
+	team := model.Team{Name: "team1", PasswordHash: "hash", AdminHash: "adminhash"}
+	passwords := model.TeamPasswords{Password: "new-password"}
+	db.On("GetTeamByID", "team1").Return(team, nil)
+	db.On("SetTeamPasswords", "team1", passwords).Return(nil)
+
+	effect, err := NewRequestBuilder().ForTeamByID("team1", "admin", true).BuildRotatePassword(passwords).Explain(db)
+	assert.Nil(t, err)
+	assert.Equal(t, "update", effect.Action)
+	assert.Equal(t, []Change{{Field: "PasswordHash", Old: hiddenValue, New: hiddenValue}}, effect.Changes)
+
+	db.AssertExpectations(t)
+}
+
+func TestExplain_Errors(t *testing.T) {
+	// the operation fails in the dry run like it would without it
+	db := &transactionalDatabase{MockDatabase: new(MockDatabase)}
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	db.On("GetByID", model.ID("1")).Return(model.Snippet{ID: "1", TeamID: "team1"}, nil)
+	db.On("DeleteSnippet", model.ID("1")).Return(errs.Errorf(errs.ErrNotFound, "Snippet '1' was not found"))
+
+	_, err := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildDelete("1").Explain(db)
+	assert.True(t, errors.Is(err, errs.ErrNotFound))
+	assert.True(t, db.rolledBack)
+
+	// without transactions nothing is run
+	plain := new(MockDatabase)
+	plain.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	plain.On("GetByID", model.ID("1")).Return(model.Snippet{ID: "1", TeamID: "team1"}, nil)
+
+	_, err = NewRequestBuilder().ForTeamByID("team1", "password", false).BuildDelete("1").Explain(plain)
+	assert.True(t, errors.Is(err, errs.ErrInvalid))
+	plain.AssertNotCalled(t, "DeleteSnippet", model.ID("1"))
+}
+
+func TestExplain_Dynamic(t *testing.T) {
+	db := &transactionalDatabase{MockDatabase: new(MockDatabase)}
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	db.On("GetByID", model.ID("1")).Return(model.Snippet{ID: "1", TeamID: "team1"}, nil)
+	db.On("DeleteSnippet", model.ID("1")).Return(nil)
+
+	r := NewRequestBuilder().ForTeamByID("team1", "password", false).DryRun().Delete("1").Build()
+	data, returnType, err := r.Execute(db)
+	assert.Nil(t, err)
+	assert.Equal(t, ReturnEffect, returnType)
+	assert.Nil(t, TypeCheck(data, returnType))
+}
@@ -0,0 +1,151 @@
+package request
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/database"
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+)
+
+// ErrLockedOut is returned by the Guard for requests from a client or for a team that failed too many password checks.
+var ErrLockedOut = errs.New(errs.ErrLimited, "Locked out")
+
+// LocalClient identifies the user and machine of requests that do not set a client, e.g. the ones of the CLI,
+// so that users sharing a database are not locked out together. It falls back to "local".
+func LocalClient() string {
+	name := ""
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" || name == "" {
+		return "local"
+	}
+	return name + "@" + host
+}
+
+// LockoutPolicy configures the Guard.
+type LockoutPolicy struct {
+	// Backoff is the time a client has to wait after its first failed attempt, it doubles with every further one up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// ClientAttempts failed attempts of one client lock it out for Lockout.
+	// From TeamAttempts failed attempts of all clients on, every client has to wait the backoff of the team,
+	// which doubles like the one of a client. A team is never locked out, so nobody can lock out a whole team.
+	ClientAttempts int
+	TeamAttempts   int
+	Lockout        time.Duration
+	// Window is the time after the last failed attempt after which the count starts over.
+	Window time.Duration
+}
+
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		Backoff:        time.Second,
+		MaxBackoff:     time.Minute,
+		ClientAttempts: 5,
+		TeamAttempts:   20,
+		Lockout:        15 * time.Minute,
+		Window:         time.Hour,
+	}
+}
+
+// Guard tracks failed checks of passwords, sessions and API keys per team and client in the database
+// and refuses requests of clients while they are locked out.
+type Guard struct {
+	policy LockoutPolicy
+	now    func() time.Time
+	record func(lockout model.Lockout)
+}
+
+// NewGuard creates a Guard that calls record with the lockout of the client after every failed attempt, record may be nil.
+func NewGuard(policy LockoutPolicy, record func(lockout model.Lockout)) *Guard {
+	return &Guard{
+		policy: policy,
+		now:    time.Now,
+		record: record,
+	}
+}
+
+// fail counts a failed attempt at now. From attempts failures on, the client is locked out for Lockout if lock is set,
+// else it has to wait a backoff that doubles with every further failure. start is the failure of the first backoff.
+func (g *Guard) fail(lockout model.Lockout, now time.Time, start int, attempts int, lock bool) model.Lockout {
+	if now.Sub(lockout.LastFailure) >= g.policy.Window {
+		lockout.Failures = 0
+	}
+	lockout.Failures++
+	lockout.LastFailure = now
+
+	if lock && lockout.Failures >= attempts {
+		lockout.LockedUntil = now.Add(g.policy.Lockout)
+	} else if lockout.Failures >= start && g.policy.Backoff > 0 {
+		delay := g.policy.Backoff << (lockout.Failures - start)
+		if delay > g.policy.MaxBackoff || delay <= 0 {
+			delay = g.policy.MaxBackoff
+		}
+		lockout.LockedUntil = now.Add(delay)
+	}
+	return lockout
+}
+
+// Middleware has to run before Authenticate.
+func (g *Guard) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(r Request, db database.Database) (any, RequestReturn, error) {
+			if !passwordCheckNeeded(r.Operation) {
+				return next(r, db)
+			}
+
+			client := r.client
+			if client == "" {
+				client = LocalClient()
+			}
+			lockouts, err := db.GetLockouts(r.teamID)
+			if err != nil {
+				return nil, ReturnNone, fmt.Errorf("Error while checking lockouts: %w", err)
+			}
+			own := model.Lockout{TeamID: r.teamID, Client: client}
+			team := model.Lockout{TeamID: r.teamID, Client: model.LockoutAllClients}
+			for _, lockout := range lockouts {
+				switch lockout.Client {
+				case client:
+					own = lockout
+				case model.LockoutAllClients:
+					team = lockout
+				}
+			}
+
+			now := g.now()
+			for _, lockout := range []model.Lockout{team, own} {
+				if lockout.Locked(now) {
+					wait := lockout.LockedUntil.Sub(now).Round(time.Second)
+					return nil, ReturnNone, fmt.Errorf("%w: too many failed attempts for team '%s', try again in %s", ErrLockedOut, r.teamID, wait)
+				}
+			}
+
+			data, retType, err := next(r, db)
+			if invalidCredentials(err) {
+				own = g.fail(own, now, 1, g.policy.ClientAttempts, true)
+				team = g.fail(team, now, g.policy.TeamAttempts, g.policy.TeamAttempts, false)
+				if saveErr := db.SaveLockout(own); saveErr != nil {
+					return nil, ReturnNone, fmt.Errorf("Error while saving lockout: %w", saveErr)
+				}
+				if saveErr := db.SaveLockout(team); saveErr != nil {
+					return nil, ReturnNone, fmt.Errorf("Error while saving lockout: %w", saveErr)
+				}
+				if g.record != nil {
+					g.record(own)
+				}
+			} else if err == nil && own.Failures > 0 {
+				if clearErr := db.ClearLockouts(r.teamID, client); clearErr != nil {
+					return nil, ReturnNone, fmt.Errorf("Error while clearing lockout: %w", clearErr)
+				}
+			}
+			return data, retType, err
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package request
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// lockoutDatabase keeps lockouts in memory, everything else is mocked.
+type lockoutDatabase struct {
+	*MockDatabase
+	lockouts map[string]model.Lockout
+}
+
+func newLockoutDatabase() *lockoutDatabase {
+	db := &lockoutDatabase{MockDatabase: new(MockDatabase), lockouts: map[string]model.Lockout{}}
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	db.On("CheckTeamRole", "team1", "wrong").Return(model.RoleNone, nil)
+	return db
+}
+
+func (db *lockoutDatabase) GetLockouts(teamID string) ([]model.Lockout, error) {
+	lockouts := []model.Lockout{}
+	for _, lockout := range db.lockouts {
+		if lockout.TeamID == teamID {
+			lockouts = append(lockouts, lockout)
+		}
+	}
+	return lockouts, nil
+}
+
+func (db *lockoutDatabase) SaveLockout(lockout model.Lockout) error {
+	db.lockouts[lockout.TeamID+"/"+lockout.Client] = lockout
+	return nil
+}
+
+func (db *lockoutDatabase) ClearLockouts(teamID string, client string) error {
+	for key, lockout := range db.lockouts {
+		if lockout.TeamID == teamID && (client == "" || lockout.Client == client) {
+			delete(db.lockouts, key)
+		}
+	}
+	return nil
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestGuard(policy LockoutPolicy, record func(model.Lockout)) (*Pipeline, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	guard := NewGuard(policy, record)
+	guard.now = clock.Now
+	return NewPipeline(guard.Middleware()).Use(Defaults()...), clock
+}
+
+func check(p *Pipeline, db *lockoutDatabase, client string, password string) error {
+	_, err := NewRequestBuilder().ForTeamByID("team1", password, false).FromClient(client).BuildCheck().ExecuteWith(p, db)
+	return err
+}
+
+func TestGuard_Backoff(t *testing.T) {
+	db := newLockoutDatabase()
+	p, clock := newTestGuard(DefaultLockoutPolicy(), nil)
+
+	assert.ErrorIs(t, check(p, db, "client1", "wrong"), ErrIncorrectPassword)
+	// even the correct password is refused during the backoff
+	assert.ErrorIs(t, check(p, db, "client1", "password"), ErrLockedOut)
+	// other clients are not affected
+	assert.Nil(t, check(p, db, "client2", "password"))
+
+	clock.Advance(time.Second)
+	assert.ErrorIs(t, check(p, db, "client1", "wrong"), ErrIncorrectPassword)
+	clock.Advance(time.Second)
+	assert.ErrorContains(t, check(p, db, "client1", "password"), "Locked out: too many failed attempts for team 'team1', try again in 1s")
+
+	clock.Advance(time.Second)
+	assert.Nil(t, check(p, db, "client1", "password"))
+	// a correct password resets the count of the client
+	assert.NotContains(t, db.lockouts, "team1/client1")
+}
+
+func TestGuard_Lockout(t *testing.T) {
+	db := newLockoutDatabase()
+	var recorded []model.Lockout
+	p, clock := newTestGuard(DefaultLockoutPolicy(), func(l model.Lockout) {
+		recorded = append(recorded, l)
+	})
+
+	for i := 0; i < 5; i++ {
+		assert.ErrorIs(t, check(p, db, "client1", "wrong"), ErrIncorrectPassword)
+		clock.Advance(time.Minute)
+	}
+	assert.Len(t, recorded, 5)
+	assert.Equal(t, 5, recorded[4].Failures)
+
+	clock.Advance(10 * time.Minute)
+	assert.ErrorIs(t, check(p, db, "client1", "password"), ErrLockedOut)
+
+	clock.Advance(5 * time.Minute)
+	assert.Nil(t, check(p, db, "client1", "password"))
+}
+
+func TestGuard_TeamLockout(t *testing.T) {
+	db := newLockoutDatabase()
+	policy := DefaultLockoutPolicy()
+	policy.TeamAttempts = 3
+	p, clock := newTestGuard(policy, nil)
+
+	for _, client := range []string{"client1", "client2", "client3"} {
+		assert.ErrorIs(t, check(p, db, client, "wrong"), ErrIncorrectPassword)
+	}
+	assert.ErrorIs(t, check(p, db, "client4", "password"), ErrLockedOut)
+
+	// the team only backs off, it is never locked out for good
+	for i := 0; i < 10; i++ {
+		clock.Advance(time.Minute)
+		assert.ErrorIs(t, check(p, db, fmt.Sprintf("attacker%d", i), "wrong"), ErrIncorrectPassword)
+	}
+	assert.ErrorIs(t, check(p, db, "client4", "password"), ErrLockedOut)
+	clock.Advance(time.Minute)
+	assert.Nil(t, check(p, db, "client4", "password"))
+
+	assert.ErrorIs(t, check(p, db, "client5", "wrong"), ErrIncorrectPassword)
+	assert.ErrorIs(t, check(p, db, "client4", "password"), ErrLockedOut)
+
+	// an instance admin lifts the lockout
+	_, err := NewRequestBuilder().BuildClearLockouts("team1", "").ExecuteWith(NewPipeline(InstanceAdmin("secret")).Use(Defaults()...), db)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = NewRequestBuilder().WithInstanceToken("secret").BuildClearLockouts("team1", "").ExecuteWith(NewPipeline(InstanceAdmin("secret")).Use(Defaults()...), db)
+	assert.Nil(t, err)
+	assert.Nil(t, check(p, db, "client4", "password"))
+}
+
+func TestClearLockouts_Client(t *testing.T) {
+	db := newLockoutDatabase()
+	p, _ := newTestGuard(DefaultLockoutPolicy(), nil)
+	assert.ErrorIs(t, check(p, db, "client1", "wrong"), ErrIncorrectPassword)
+	assert.ErrorIs(t, check(p, db, "client2", "wrong"), ErrIncorrectPassword)
+
+	// only the lockout of the client is lifted
+	_, err := NewRequestBuilder().WithInstanceToken("secret").BuildClearLockouts("team1", "client1").ExecuteWith(NewPipeline(InstanceAdmin("secret")).Use(Defaults()...), db)
+	assert.Nil(t, err)
+	assert.NotContains(t, db.lockouts, "team1/client1")
+	assert.Contains(t, db.lockouts, "team1/client2")
+
+	_, err = NewRequestBuilder().WithInstanceToken("secret").BuildClearLockouts("", "client2").ExecuteWith(NewPipeline(InstanceAdmin("secret")).Use(Defaults()...), db)
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+}
+
+func TestGuard_Window(t *testing.T) {
+	db := newLockoutDatabase()
+	p, clock := newTestGuard(DefaultLockoutPolicy(), nil)
+
+	for i := 0; i < 4; i++ {
+		assert.ErrorIs(t, check(p, db, "client1", "wrong"), ErrIncorrectPassword)
+		clock.Advance(time.Minute)
+	}
+	// the count starts over once the last failed attempt is older than the window
+	clock.Advance(time.Hour)
+	assert.ErrorIs(t, check(p, db, "client1", "wrong"), ErrIncorrectPassword)
+	assert.Equal(t, 1, db.lockouts["team1/client1"].Failures)
+}
+
+func TestGuard_SessionsAndAPIKeys(t *testing.T) {
+	db := newLockoutDatabase()
+	db.On("CheckSession", "team1", "guessed").Return(model.RoleNone, nil)
+	db.On("CheckAPIKey", "team1", "guessed").Return(model.APIKey{}, nil)
+	p, clock := newTestGuard(DefaultLockoutPolicy(), nil)
+
+	_, err := NewRequestBuilder().ForTeamWithSession("team1", "guessed").FromClient("client1").BuildCheck().ExecuteWith(p, db)
+	assert.ErrorIs(t, err, ErrInvalidSession)
+	assert.Equal(t, 1, db.lockouts["team1/client1"].Failures)
+
+	clock.Advance(time.Minute)
+	_, err = NewRequestBuilder().ForTeamWithAPIKey("team1", "guessed").FromClient("client1").BuildCheck().ExecuteWith(p, db)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	assert.Equal(t, 2, db.lockouts["team1/client1"].Failures)
+
+	// the backoff applies to every kind of credential
+	assert.ErrorIs(t, check(p, db, "client1", "password"), ErrLockedOut)
+}
+
+func TestGuard_UnknownTeam(t *testing.T) {
+	db := newLockoutDatabase()
+	db.On("CheckTeamRole", "nobody", "password").Return(model.RoleNone, errs.Errorf(errs.ErrNotFound, "Team with name 'nobody' was not found"))
+	p, _ := newTestGuard(DefaultLockoutPolicy(), nil)
+
+	_, err := NewRequestBuilder().ForTeamByID("nobody", "password", false).FromClient("client1").BuildCheck().ExecuteWith(p, db)
+	assert.ErrorIs(t, err, ErrIncorrectPassword)
+	assert.NotErrorIs(t, err, errs.ErrNotFound)
+	assert.Equal(t, 1, db.lockouts["nobody/client1"].Failures)
+}
+
+func TestLocalClient(t *testing.T) {
+	assert.NotEmpty(t, LocalClient())
+	assert.Equal(t, LocalClient(), LocalClient())
+}
@@ -0,0 +1,358 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/database"
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+)
+
+// Handler executes a request against a database.
+type Handler func(r Request, db database.Database) (any, RequestReturn, error)
+
+// Middleware wraps a Handler to add behavior around the execution of a request.
+type Middleware func(next Handler) Handler
+
+// Pipeline is a chain of middlewares around the execution of the operation itself.
+// The first middleware is the outermost one, so it sees the request first and the result last.
+type Pipeline struct {
+	middlewares []Middleware
+}
+
+func NewPipeline(middlewares ...Middleware) *Pipeline {
+	return &Pipeline{middlewares: middlewares}
+}
+
+// Use appends middlewares to the pipeline, they run inside the ones already added.
+func (p *Pipeline) Use(middlewares ...Middleware) *Pipeline {
+	p.middlewares = append(p.middlewares, middlewares...)
+	return p
+}
+
+// Execute runs the request through the middlewares. Dry-run requests end in explain instead of dispatch.
+func (p *Pipeline) Execute(r Request, db database.Database) (any, RequestReturn, error) {
+	handler := Handler(dispatch)
+	if r.dryRun {
+		handler = explain
+	}
+	for i := len(p.middlewares) - 1; i >= 0; i-- {
+		handler = p.middlewares[i](handler)
+	}
+	return handler(r, db)
+}
+
+// Defaults returns the middlewares Request.Execute uses: Validate, Authenticate, Permissions, Scope, TagScope and WrapErrors.
+func Defaults() []Middleware {
+	return []Middleware{Validate(), Authenticate(), Permissions(), Scope(), TagScope(), WrapErrors()}
+}
+
+// Validate rejects requests whose Data does not match their operation before anything else is done.
+func Validate() Middleware {
+	return func(next Handler) Handler {
+		return func(r Request, db database.Database) (any, RequestReturn, error) {
+			var ok bool
+			var expected string
+			switch r.Operation {
+			case Get, Delete:
+				_, ok = r.Data.(model.ID)
+				expected = "a string"
+			case Insert, Update:
+				_, ok = r.Data.(model.Snippet)
+				expected = "a snippet"
+			case InsertBatch:
+				var snippets []model.Snippet
+				snippets, ok = r.Data.([]model.Snippet)
+				expected = "a list of snippets"
+				ok = ok && len(snippets) > 0
+			case InsertTeam, UpdateTeam:
+				_, ok = r.Data.(model.Team)
+				expected = "a team"
+			case DeleteTeam:
+				_, ok = r.Data.(string)
+				expected = "a string"
+			case CreateAPIKey:
+				var key model.APIKey
+				key, ok = r.Data.(model.APIKey)
+				expected = "an API key with the read-only or member scope"
+				ok = ok && (key.Scope == model.RoleReadOnly || key.Scope == model.RoleMember)
+			case RotatePassword:
+				var passwords model.TeamPasswords
+				passwords, ok = r.Data.(model.TeamPasswords)
+				expected = "at least one new password"
+				ok = ok && !passwords.Empty()
+			case RevokeAPIKey, ListLockouts:
+				_, ok = r.Data.(string)
+				expected = "a string"
+			case ClearLockouts:
+				var lockout model.Lockout
+				lockout, ok = r.Data.(model.Lockout)
+				expected = "a lockout with a team"
+				ok = ok && lockout.TeamID != ""
+			default:
+				ok = true
+			}
+			if !ok {
+				return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Request.Data for %s operation needs to be %s", r.Operation, expected)
+			}
+			return next(r, db)
+		}
+	}
+}
+
+// ErrIncorrectPassword is returned by Authenticate for requests whose password does not grant any role.
+var ErrIncorrectPassword = errs.New(errs.ErrUnauthorized, "Incorrect password")
+
+// ErrInvalidSession and ErrInvalidAPIKey are returned by Authenticate for unknown, expired or revoked credentials.
+var (
+	ErrInvalidSession = errs.New(errs.ErrUnauthorized, "Invalid or expired session")
+	ErrInvalidAPIKey  = errs.New(errs.ErrUnauthorized, "Invalid or expired API key")
+)
+
+// invalidCredentials reports whether err is a failed check of a password, session or API key.
+func invalidCredentials(err error) bool {
+	return errors.Is(err, ErrIncorrectPassword) || errors.Is(err, ErrInvalidSession) || errors.Is(err, ErrInvalidAPIKey)
+}
+
+func passwordCheckNeeded(op Operation) bool {
+	switch op {
+	case Get, GetAllPartials, GetAll, Insert, Update, Delete, UpdateTeam, DeleteTeam, Check, Login, Refresh, Logout,
+		CreateAPIKey, ListAPIKeys, RevokeAPIKey, RotatePassword, GetTeam, InsertBatch, Identify:
+		return true
+	}
+	return false
+}
+
+// Authenticate checks the team password, session or API key for every operation that needs one, and grants the role it belongs to.
+// Requests made with the admin flag need the admin password. API keys cannot be exchanged for sessions,
+// and passwords can only be rotated with the admin password itself.
+func Authenticate() Middleware {
+	return func(next Handler) Handler {
+		return func(r Request, db database.Database) (any, RequestReturn, error) {
+			if !passwordCheckNeeded(r.Operation) {
+				return next(r, db)
+			}
+
+			if r.Operation == RotatePassword && r.password == "" {
+				return nil, ReturnNone, fmt.Errorf("%w: %s needs the admin password, not a session or API key", ErrForbidden, r.Operation)
+			}
+			if r.apiKey != "" {
+				if r.Operation == Login || r.Operation == Refresh {
+					return nil, ReturnNone, fmt.Errorf("%w: %s is not possible with an API key", ErrForbidden, r.Operation)
+				}
+				key, err := db.CheckAPIKey(r.teamID, r.apiKey)
+				if err != nil {
+					return nil, ReturnNone, fmt.Errorf("Error while checking API key: %w", err)
+				}
+				if key.Scope == model.RoleNone {
+					return nil, ReturnNone, fmt.Errorf("%w for team '%s'", ErrInvalidAPIKey, r.teamID)
+				}
+				key.Hash = ""
+				r.role = key.Scope
+				r.tags = key.Tags
+				r.key = key
+				return next(r, db)
+			}
+
+			if r.session != "" {
+				role, err := db.CheckSession(r.teamID, r.session)
+				if errors.Is(err, errs.ErrNotFound) {
+					role, err = model.RoleNone, nil
+				}
+				if err != nil {
+					return nil, ReturnNone, fmt.Errorf("Error while checking session: %w", err)
+				}
+				if role == model.RoleNone {
+					return nil, ReturnNone, fmt.Errorf("%w for team '%s', log in again", ErrInvalidSession, r.teamID)
+				}
+				r.role = role
+				return next(r, db)
+			}
+
+			// an unknown team fails like a wrong password, so it counts for the Guard and does not tell which teams exist
+			role, err := db.CheckTeamRole(r.teamID, r.password)
+			if errors.Is(err, errs.ErrNotFound) {
+				role, err = model.RoleNone, nil
+			}
+			if err != nil {
+				return nil, ReturnNone, fmt.Errorf("Error while checking team password: %w", err)
+			}
+			if role == model.RoleNone || (r.admin && role < model.RoleAdmin) {
+				return nil, ReturnNone, fmt.Errorf("%w for team '%s'", ErrIncorrectPassword, r.teamID)
+			}
+			r.role = role
+			return next(r, db)
+		}
+	}
+}
+
+// Authorize runs check before the request and aborts it with the returned error, if any.
+func Authorize(check func(r Request, db database.Database) error) Middleware {
+	return func(next Handler) Handler {
+		return func(r Request, db database.Database) (any, RequestReturn, error) {
+			if err := check(r, db); err != nil {
+				return nil, ReturnNone, err
+			}
+			return next(r, db)
+		}
+	}
+}
+
+// WrapErrors adds the operation and its data to errors of the operation.
+func WrapErrors() Middleware {
+	return func(next Handler) Handler {
+		return func(r Request, db database.Database) (any, RequestReturn, error) {
+			data, retType, err := next(r, db)
+			if err == nil {
+				return data, retType, nil
+			}
+			if r.Data == nil {
+				return nil, ReturnNone, fmt.Errorf("Error while executing %s operation: %w", r.Operation, err)
+			}
+			return nil, ReturnNone, fmt.Errorf("Error while executing %s for '%v': %w", r.Operation, r.Data, err)
+		}
+	}
+}
+
+// AuditEntry describes an executed request. It never contains the password.
+type AuditEntry struct {
+	Time      time.Time
+	Operation Operation
+	TeamID    string
+	Admin     bool
+	Duration  time.Duration
+	Err       error
+}
+
+// Audit calls record for every request after it was executed.
+func Audit(record func(entry AuditEntry)) Middleware {
+	return func(next Handler) Handler {
+		return func(r Request, db database.Database) (any, RequestReturn, error) {
+			start := time.Now()
+			data, retType, err := next(r, db)
+			record(AuditEntry{
+				Time:      start,
+				Operation: r.Operation,
+				TeamID:    r.teamID,
+				Admin:     r.admin,
+				Duration:  time.Since(start),
+				Err:       err,
+			})
+			return data, retType, err
+		}
+	}
+}
+
+// Trace calls start before every request, and the function it returns once the request is done.
+func Trace(start func(r Request) func(err error)) Middleware {
+	return func(next Handler) Handler {
+		return func(r Request, db database.Database) (any, RequestReturn, error) {
+			end := start(r)
+			data, retType, err := next(r, db)
+			end(err)
+			return data, retType, err
+		}
+	}
+}
+
+type OperationMetrics struct {
+	Calls    int
+	Errors   int
+	Duration time.Duration
+}
+
+// Metrics counts calls, errors and the total duration per operation.
+type Metrics struct {
+	mu         sync.Mutex
+	operations map[Operation]OperationMetrics
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{operations: map[Operation]OperationMetrics{}}
+}
+
+func (m *Metrics) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(r Request, db database.Database) (any, RequestReturn, error) {
+			start := time.Now()
+			data, retType, err := next(r, db)
+
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			op := m.operations[r.Operation]
+			op.Calls++
+			if err != nil {
+				op.Errors++
+			}
+			op.Duration += time.Since(start)
+			m.operations[r.Operation] = op
+
+			return data, retType, err
+		}
+	}
+}
+
+// Snapshot returns a copy of the current metrics.
+func (m *Metrics) Snapshot() map[Operation]OperationMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make(map[Operation]OperationMetrics, len(m.operations))
+	for op, metrics := range m.operations {
+		snapshot[op] = metrics
+	}
+	return snapshot
+}
+
+// RateLimiter allows at most limit requests per team in every window.
+type RateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	now    func() time.Time
+	teams  map[string]rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:  limit,
+		window: window,
+		now:    time.Now,
+		teams:  map[string]rateWindow{},
+	}
+}
+
+func (l *RateLimiter) allow(teamID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	w := l.teams[teamID]
+	if now.Sub(w.start) >= l.window {
+		w = rateWindow{start: now}
+	}
+	if w.count >= l.limit {
+		return false
+	}
+	w.count++
+	l.teams[teamID] = w
+	return true
+}
+
+func (l *RateLimiter) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(r Request, db database.Database) (any, RequestReturn, error) {
+			if !l.allow(r.teamID) {
+				return nil, ReturnNone, errs.Errorf(errs.ErrLimited, "Too many requests for team '%s', try again later", r.teamID)
+			}
+			return next(r, db)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package request
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/database"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func record(name string, calls *[]string) Middleware {
+	return func(next Handler) Handler {
+		return func(r Request, db database.Database) (any, RequestReturn, error) {
+			*calls = append(*calls, name)
+			return next(r, db)
+		}
+	}
+}
+
+func TestPipeline_Order(t *testing.T) {
+	db := new(MockDatabase)
+	var calls []string
+
+	p := NewPipeline(record("first", &calls)).Use(record("second", &calls))
+	_, _, err := p.Execute(NewRequestBuilder().Check().Build(), db)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"first", "second"}, calls)
+
+	db.AssertExpectations(t)
+}
+
+func TestPipeline_WithoutAuthenticate(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("GetByID", model.ID("1")).Return(model.Snippet{ID: "1"}, nil)
+
+	req := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildGet("1")
+	result, err := req.ExecuteWith(NewPipeline(Validate()), db)
+	assert.Nil(t, err)
+	assert.Equal(t, model.ID("1"), result.ID)
+
+	db.AssertExpectations(t)
+}
+
+func TestAuthorize(t *testing.T) {
+	db := new(MockDatabase)
+	forbidden := errors.New("forbidden")
+
+	p := NewPipeline(Authorize(func(r Request, db database.Database) error {
+		if r.TeamID() != "team1" {
+			return forbidden
+		}
+		return nil
+	}))
+	_, _, err := p.Execute(NewRequestBuilder().ForTeamByID("team2", "password", false).Check().Build(), db)
+	assert.Equal(t, forbidden, err)
+
+	db.AssertExpectations(t)
+}
+
+func TestWrapErrors(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	db.On("GetByID", model.ID("1")).Return(model.Snippet{ID: "1", TeamID: "team1"}, nil)
+	db.On("UpdateSnippet", model.Snippet{ID: "1", TeamID: "team1"}).Return(errors.New("update error"))
+
+	req := NewRequestBuilder().ForTeamByID("team1", "password", false).Update(model.Snippet{ID: "1", TeamID: "team1"}).Build()
+	_, _, err := req.Execute(db)
+	assert.ErrorContains(t, err, "Error while executing Update for")
+	assert.ErrorContains(t, err, "update error")
+
+	db.AssertExpectations(t)
+}
+
+func TestAuditAndMetrics(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleNone, nil)
+
+	var entries []AuditEntry
+	metrics := NewMetrics()
+	p := NewPipeline(Audit(func(entry AuditEntry) {
+		entries = append(entries, entry)
+	}), metrics.Middleware()).Use(Defaults()...)
+
+	_, _, err := p.Execute(NewRequestBuilder().ForTeamByID("team1", "password", false).Check().Build(), db)
+	assert.NotNil(t, err)
+
+	assert.Len(t, entries, 1)
+	assert.Equal(t, Check, entries[0].Operation)
+	assert.Equal(t, "team1", entries[0].TeamID)
+	assert.Equal(t, err, entries[0].Err)
+	assert.Equal(t, 1, metrics.Snapshot()[Check].Calls)
+	assert.Equal(t, 1, metrics.Snapshot()[Check].Errors)
+
+	db.AssertExpectations(t)
+}
+
+func TestRateLimiter(t *testing.T) {
+	db := new(MockDatabase)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+	p := NewPipeline(limiter.Middleware())
+
+	req := NewRequestBuilder().ForTeamByID("team1", "password", false).Check().Build()
+	for i := 0; i < 2; i++ {
+		_, _, err := p.Execute(req, db)
+		assert.Nil(t, err)
+	}
+	_, _, err := p.Execute(req, db)
+	assert.EqualError(t, err, "Too many requests for team 'team1', try again later")
+
+	// other teams have their own window
+	_, _, err = p.Execute(NewRequestBuilder().ForTeamByID("team2", "password", false).Check().Build(), db)
+	assert.Nil(t, err)
+
+	now = now.Add(time.Minute)
+	_, _, err = p.Execute(req, db)
+	assert.Nil(t, err)
+
+	db.AssertExpectations(t)
+}
@@ -0,0 +1,222 @@
+package request
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	"github.com/snippetaccumulator/snac/internal/backend/database"
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+)
+
+// ErrForbidden is returned for requests whose role does not allow the operation.
+var ErrForbidden = errs.ErrForbidden
+
+// requiredRoles is the permission matrix: the lowest role that may run each operation.
+var requiredRoles = map[Operation]model.Role{
+	Get:            model.RoleReadOnly,
+	GetAllPartials: model.RoleReadOnly,
+	GetAll:         model.RoleReadOnly,
+	Check:          model.RoleReadOnly,
+	Identify:       model.RoleReadOnly,
+	Login:          model.RoleReadOnly,
+	Refresh:        model.RoleReadOnly,
+	Logout:         model.RoleReadOnly,
+	GetTeam:        model.RoleReadOnly,
+	Insert:         model.RoleMember,
+	InsertBatch:    model.RoleMember,
+	Update:         model.RoleMember,
+	Delete:         model.RoleMember,
+	UpdateTeam:     model.RoleAdmin,
+	DeleteTeam:     model.RoleAdmin,
+	CreateAPIKey:   model.RoleAdmin,
+	ListAPIKeys:    model.RoleAdmin,
+	RevokeAPIKey:   model.RoleAdmin,
+	RotatePassword: model.RoleAdmin,
+	InsertTeam:     model.RoleInstanceAdmin,
+	ListLockouts:   model.RoleInstanceAdmin,
+	ClearLockouts:  model.RoleInstanceAdmin,
+}
+
+// RequiredRole returns the lowest role that may run op. Unknown operations need the instance-admin role.
+func RequiredRole(op Operation) model.Role {
+	role, ok := requiredRoles[op]
+	if !ok {
+		return model.RoleInstanceAdmin
+	}
+	return role
+}
+
+// Permissions rejects requests whose granted role is lower than RequiredRole with ErrForbidden.
+// It has to run after Authenticate and InstanceAdmin.
+func Permissions() Middleware {
+	return func(next Handler) Handler {
+		return func(r Request, db database.Database) (any, RequestReturn, error) {
+			required := RequiredRole(r.Operation)
+			if r.role < required {
+				return nil, ReturnNone, fmt.Errorf("%w: %s needs the %s role, but the request has the %s role", ErrForbidden, r.Operation, required, r.role)
+			}
+			return next(r, db)
+		}
+	}
+}
+
+// InstanceAdmin grants the instance-admin role to requests carrying token, see RequestBuilder.WithInstanceToken.
+// An empty token grants nothing.
+func InstanceAdmin(token string) Middleware {
+	return func(next Handler) Handler {
+		return func(r Request, db database.Database) (any, RequestReturn, error) {
+			if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(r.instanceToken)) == 1 {
+				r.role = model.RoleInstanceAdmin
+			}
+			return next(r, db)
+		}
+	}
+}
+
+// Scope restricts requests to the snippets and the team they are authenticated for.
+// Snippets of other teams are reported as missing, so their IDs are not leaked.
+// It has to run after Validate and Authenticate.
+func Scope() Middleware {
+	return func(next Handler) Handler {
+		return func(r Request, db database.Database) (any, RequestReturn, error) {
+			switch r.Operation {
+			case Get:
+				data, retType, err := next(r, db)
+				if err != nil {
+					return data, retType, err
+				}
+				if snippet, ok := data.(model.Snippet); ok && snippet.TeamID != r.teamID {
+					return nil, ReturnNone, errs.Errorf(errs.ErrNotFound, "Snippet '%s' was not found", snippet.ID)
+				}
+				return data, retType, nil
+			case Delete:
+				if err := ownSnippet(db, r.teamID, r.Data.(model.ID)); err != nil {
+					return nil, ReturnNone, err
+				}
+			case Update:
+				snippet := r.Data.(model.Snippet)
+				if err := ownSnippet(db, r.teamID, snippet.ID); err != nil {
+					return nil, ReturnNone, err
+				}
+				if snippet.TeamID != r.teamID {
+					return nil, ReturnNone, errs.Errorf(errs.ErrForbidden, "Not allowed to move snippets to team '%s'", snippet.TeamID)
+				}
+			case Insert:
+				if snippet := r.Data.(model.Snippet); snippet.TeamID != r.teamID {
+					return nil, ReturnNone, errs.Errorf(errs.ErrForbidden, "Not allowed to insert snippets for team '%s'", snippet.TeamID)
+				}
+			case InsertBatch:
+				for _, snippet := range r.Data.([]model.Snippet) {
+					if snippet.TeamID != r.teamID {
+						return nil, ReturnNone, errs.Errorf(errs.ErrForbidden, "Not allowed to insert snippets for team '%s'", snippet.TeamID)
+					}
+				}
+			case UpdateTeam:
+				if team := r.Data.(model.Team); team.Name != r.teamID {
+					return nil, ReturnNone, errs.Errorf(errs.ErrForbidden, "Not allowed to update team '%s'", team.Name)
+				}
+			case DeleteTeam:
+				if teamID := r.Data.(string); teamID != r.teamID {
+					return nil, ReturnNone, errs.Errorf(errs.ErrForbidden, "Not allowed to delete team '%s'", teamID)
+				}
+			}
+			return next(r, db)
+		}
+	}
+}
+
+// ownSnippet makes sure the snippet with the id belongs to the team.
+func ownSnippet(db database.Database, teamID string, id model.ID) error {
+	snippet, err := db.GetByID(id)
+	if err != nil && !errors.Is(err, errs.ErrNotFound) {
+		return err
+	}
+	if err != nil || snippet.TeamID != teamID {
+		return errs.Errorf(errs.ErrNotFound, "Snippet '%s' was not found", id)
+	}
+	return nil
+}
+
+// TagScope restricts requests made with a tag-scoped API key to snippets with any of its tags.
+// Partials outside the tags are filtered out, every other snippet operation outside them is rejected with ErrForbidden.
+// It has to run after Authenticate.
+func TagScope() Middleware {
+	return func(next Handler) Handler {
+		return func(r Request, db database.Database) (any, RequestReturn, error) {
+			if len(r.tags) == 0 {
+				return next(r, db)
+			}
+			key := model.APIKey{Tags: r.tags}
+			forbidden := fmt.Errorf("%w: %s is outside the tags %v of the API key", ErrForbidden, r.Operation, r.tags)
+
+			switch r.Operation {
+			case GetAllPartials:
+				data, retType, err := next(r, db)
+				if err != nil {
+					return data, retType, err
+				}
+				partials, _ := data.([]model.PartialSnippet)
+				allowed := []model.PartialSnippet{}
+				for _, partial := range partials {
+					if key.Allows(partial.Tags) {
+						allowed = append(allowed, partial)
+					}
+				}
+				return allowed, retType, nil
+			case GetAll:
+				data, retType, err := next(r, db)
+				if err != nil {
+					return data, retType, err
+				}
+				snippets, _ := data.([]model.Snippet)
+				allowed := []model.Snippet{}
+				for _, snippet := range snippets {
+					if key.Allows(snippet.Tags) {
+						allowed = append(allowed, snippet)
+					}
+				}
+				return allowed, retType, nil
+			case Get:
+				data, retType, err := next(r, db)
+				if err != nil {
+					return data, retType, err
+				}
+				if snippet, ok := data.(model.Snippet); ok && !key.Allows(snippet.Tags) {
+					return nil, ReturnNone, forbidden
+				}
+				return data, retType, nil
+			case Insert:
+				if snippet, ok := r.Data.(model.Snippet); ok && !key.Allows(snippet.Tags) {
+					return nil, ReturnNone, forbidden
+				}
+			case InsertBatch:
+				snippets, _ := r.Data.([]model.Snippet)
+				for _, snippet := range snippets {
+					if !key.Allows(snippet.Tags) {
+						return nil, ReturnNone, forbidden
+					}
+				}
+			case Update, Delete:
+				var id model.ID
+				if snippet, ok := r.Data.(model.Snippet); ok {
+					if !key.Allows(snippet.Tags) {
+						return nil, ReturnNone, forbidden
+					}
+					id = snippet.ID
+				} else {
+					id, _ = r.Data.(model.ID)
+				}
+				existing, err := db.GetByID(id)
+				if err != nil {
+					return nil, ReturnNone, err
+				}
+				if !key.Allows(existing.Tags) {
+					return nil, ReturnNone, forbidden
+				}
+			}
+			return next(r, db)
+		}
+	}
+}
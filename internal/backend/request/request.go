@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/snippetaccumulator/snac/internal/backend/database"
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
 	"github.com/snippetaccumulator/snac/internal/backend/model"
 )
 
@@ -19,17 +20,116 @@ const (
 	UpdateTeam
 	DeleteTeam
 	Check
+	Login
+	Refresh
+	Logout
+	CreateAPIKey
+	ListAPIKeys
+	RevokeAPIKey
+	ListLockouts
+	ClearLockouts
+	RotatePassword
+	GetTeam
+	InsertBatch
+	Identify
+	GetAll
 )
 
+func (o Operation) String() string {
+	switch o {
+	case Get:
+		return "Get"
+	case GetAllPartials:
+		return "GetAllPartials"
+	case Insert:
+		return "Insert"
+	case Update:
+		return "Update"
+	case Delete:
+		return "Delete"
+	case InsertTeam:
+		return "InsertTeam"
+	case UpdateTeam:
+		return "UpdateTeam"
+	case DeleteTeam:
+		return "DeleteTeam"
+	case Check:
+		return "Check"
+	case Login:
+		return "Login"
+	case Refresh:
+		return "Refresh"
+	case Logout:
+		return "Logout"
+	case CreateAPIKey:
+		return "CreateAPIKey"
+	case ListAPIKeys:
+		return "ListAPIKeys"
+	case RevokeAPIKey:
+		return "RevokeAPIKey"
+	case ListLockouts:
+		return "ListLockouts"
+	case ClearLockouts:
+		return "ClearLockouts"
+	case RotatePassword:
+		return "RotatePassword"
+	case GetTeam:
+		return "GetTeam"
+	case InsertBatch:
+		return "InsertBatch"
+	case Identify:
+		return "Identify"
+	case GetAll:
+		return "GetAll"
+	default:
+		return "Unknown"
+	}
+}
+
 type Request struct {
-	Operation Operation
-	teamID    string
-	password  string
-	admin     bool
+	Operation     Operation
+	teamID        string
+	password      string
+	session       string
+	apiKey        string
+	admin         bool
+	instanceToken string
+	// client identifies where the request comes from for the Guard.
+	client string
+	// dryRun makes the request return its Effect instead of committing it.
+	dryRun bool
+	// role is granted by Authenticate or InstanceAdmin, never by the caller.
+	role model.Role
+	// tags restricts the request to snippets with any of them, it is set by Authenticate for API keys.
+	tags []string
+	// key is the API key the request is authenticated with, without its hash.
+	key model.APIKey
 
 	Data any
 }
 
+// TeamID returns the team the request is made for.
+func (r Request) TeamID() string {
+	return r.teamID
+}
+
+// Admin reports whether the request is made with the admin password.
+func (r Request) Admin() bool {
+	return r.admin
+}
+
+// Role returns the role granted to the request so far, see RequiredRole.
+func (r Request) Role() model.Role {
+	return r.role
+}
+
+// Identity is what the credentials of a request grant, see Identify.
+type Identity struct {
+	Role model.Role
+	// Key is the API key of the request without its hash, empty for passwords and sessions.
+	Key model.APIKey
+}
+
 type RequestBuilder struct {
 	request Request
 }
@@ -47,6 +147,41 @@ func (b *RequestBuilder) ForTeamByID(teamID string, password string, admin bool)
 	return b
 }
 
+// ForTeamWithSession authenticates the request with a session token instead of a password, see Login.
+func (b *RequestBuilder) ForTeamWithSession(teamID string, token string) *RequestBuilder {
+	b.request.teamID = teamID
+	b.request.session = token
+	return b
+}
+
+// ForTeamWithAPIKey authenticates the request with an API key, see CreateAPIKey.
+// The request gets the scope of the key as its role and is restricted to the tags of the key.
+func (b *RequestBuilder) ForTeamWithAPIKey(teamID string, key string) *RequestBuilder {
+	b.request.teamID = teamID
+	b.request.apiKey = key
+	return b
+}
+
+// WithInstanceToken adds the token checked by the InstanceAdmin middleware.
+func (b *RequestBuilder) WithInstanceToken(token string) *RequestBuilder {
+	b.request.instanceToken = token
+	return b
+}
+
+// FromClient sets where the request comes from, e.g. the address of a server's caller.
+// Failed password checks are tracked per client by the Guard.
+func (b *RequestBuilder) FromClient(client string) *RequestBuilder {
+	b.request.client = client
+	return b
+}
+
+// DryRun makes the request validate, authenticate and compute its Effect without committing anything.
+// It needs a database.Transactional database.
+func (b *RequestBuilder) DryRun() *RequestBuilder {
+	b.request.dryRun = true
+	return b
+}
+
 func (b *RequestBuilder) Get(snippetID model.ID) *RequestBuilder {
 	b.request.Operation = Get
 	b.request.Data = snippetID
@@ -58,12 +193,25 @@ func (b *RequestBuilder) GetAllPartials() *RequestBuilder {
 	return b
 }
 
+// GetAll returns the snippets of the team with their content, for what GetAllPartials is not enough.
+func (b *RequestBuilder) GetAll() *RequestBuilder {
+	b.request.Operation = GetAll
+	return b
+}
+
 func (b *RequestBuilder) Insert(snippet model.Snippet) *RequestBuilder {
 	b.request.Operation = Insert
 	b.request.Data = snippet
 	return b
 }
 
+// InsertBatch inserts all of the snippets or, if one of them fails, none.
+func (b *RequestBuilder) InsertBatch(snippets []model.Snippet) *RequestBuilder {
+	b.request.Operation = InsertBatch
+	b.request.Data = snippets
+	return b
+}
+
 func (b *RequestBuilder) Update(snippet model.Snippet) *RequestBuilder {
 	b.request.Operation = Update
 	b.request.Data = snippet
@@ -99,6 +247,77 @@ func (b *RequestBuilder) Check() *RequestBuilder {
 	return b
 }
 
+// Identify returns what the credentials of the request grant: its role and, for an API key, the key.
+func (b *RequestBuilder) Identify() *RequestBuilder {
+	b.request.Operation = Identify
+	return b
+}
+
+// Login exchanges the password of the request for a session token.
+func (b *RequestBuilder) Login() *RequestBuilder {
+	b.request.Operation = Login
+	return b
+}
+
+// Refresh exchanges the session token of the request for a new one and revokes the old one.
+func (b *RequestBuilder) Refresh() *RequestBuilder {
+	b.request.Operation = Refresh
+	return b
+}
+
+// Logout revokes the session token of the request.
+func (b *RequestBuilder) Logout() *RequestBuilder {
+	b.request.Operation = Logout
+	return b
+}
+
+// CreateAPIKey stores a key created with model.NewAPIKey for the team of the request.
+func (b *RequestBuilder) CreateAPIKey(key model.APIKey) *RequestBuilder {
+	b.request.Operation = CreateAPIKey
+	b.request.Data = key
+	return b
+}
+
+// ListAPIKeys returns the keys of the team of the request, without their hashes.
+func (b *RequestBuilder) ListAPIKeys() *RequestBuilder {
+	b.request.Operation = ListAPIKeys
+	return b
+}
+
+func (b *RequestBuilder) RevokeAPIKey(id string) *RequestBuilder {
+	b.request.Operation = RevokeAPIKey
+	b.request.Data = id
+	return b
+}
+
+// ListLockouts returns the lockouts of a team, or of all teams if teamID is empty.
+func (b *RequestBuilder) ListLockouts(teamID string) *RequestBuilder {
+	b.request.Operation = ListLockouts
+	b.request.Data = teamID
+	return b
+}
+
+// ClearLockouts lifts the lockouts of a team for client, or for all clients if client is empty, see Guard.
+func (b *RequestBuilder) ClearLockouts(teamID string, client string) *RequestBuilder {
+	b.request.Operation = ClearLockouts
+	b.request.Data = model.Lockout{TeamID: teamID, Client: client}
+	return b
+}
+
+// RotatePassword replaces the non-empty passwords of the team of the request.
+// This ends all sessions of the team.
+func (b *RequestBuilder) RotatePassword(passwords model.TeamPasswords) *RequestBuilder {
+	b.request.Operation = RotatePassword
+	b.request.Data = passwords
+	return b
+}
+
+// GetTeam loads the team of the request, without its password hashes.
+func (b *RequestBuilder) GetTeam() *RequestBuilder {
+	b.request.Operation = GetTeam
+	return b
+}
+
 func (b *RequestBuilder) Build() Request {
 	r := b.request
 	b.Reset()
@@ -109,6 +328,8 @@ func (b *RequestBuilder) Reset() {
 	b.request = Request{}
 }
 
+// RequestReturn describes the type of the data returned by Execute.
+// Prefer the typed Build* methods or Execute[T], this is only needed where the operation is not known at compile time.
 type RequestReturn int
 
 const (
@@ -117,112 +338,217 @@ const (
 	ReturnPartials
 	ReturnTeam
 	ReturnBoolean
+	ReturnSession
+	ReturnAPIKeys
+	ReturnLockouts
+	ReturnEffect
+	ReturnIdentity
 	ReturnNone
 )
 
-func passwordCheckNeeded(op Operation) bool {
-	switch op {
-	case Get, GetAllPartials, Insert, Update, Delete, UpdateTeam, DeleteTeam, Check:
-		return true
-	}
-	return false
-}
-
+// Execute runs the request against db through the default pipeline and returns its data along with a description of its type.
+// Use Typed.Execute or Execute[T] to get the data typed.
 func (r Request) Execute(db database.Database) (any, RequestReturn, error) {
-	if passwordCheckNeeded(r.Operation) {
-		correctPassword, err := db.CheckTeamPassword(r.teamID, r.password, r.admin)
-		if err != nil {
-			return nil, ReturnNone, fmt.Errorf("Error while checking team password: %v", err)
-		}
-		if !correctPassword {
-			return nil, ReturnNone, fmt.Errorf("Incorrect password for team '%s'", r.teamID)
-		}
-	}
+	return NewPipeline(Defaults()...).Execute(r, db)
+}
 
+// dispatch is the innermost Handler of every pipeline, it runs the operation itself.
+func dispatch(r Request, db database.Database) (any, RequestReturn, error) {
 	switch r.Operation {
 	case GetAllPartials:
 		partials, err := db.GetByTeamID(r.teamID)
 		if err != nil {
-			return nil, ReturnNone, fmt.Errorf("Error while executing GetAllPartials operation: %v", err)
+			return nil, ReturnNone, err
 		}
 		return partials, ReturnPartials, nil
+	case GetAll:
+		snippets, err := db.GetSnippetsByTeamID(r.teamID)
+		if err != nil {
+			return nil, ReturnNone, err
+		}
+		return snippets, ReturnSnippetList, nil
 	case Get:
 		id, ok := r.Data.(model.ID)
 		if !ok {
-			return nil, ReturnNone, fmt.Errorf("Request.Data for Get operation needs to be a string")
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Request.Data for Get operation needs to be a string")
 		}
 		snippet, err := db.GetByID(id)
 		if err != nil {
-			return nil, ReturnNone, fmt.Errorf("Error while executing Get for '%s': %v", r.Data, err)
+			return nil, ReturnNone, err
 		}
 		return snippet, ReturnSingleSnippet, nil
 	case Insert:
 		snippet, ok := r.Data.(model.Snippet)
 		if !ok {
-			return false, ReturnBoolean, fmt.Errorf("Request.Data for Insert operation needs to be a snippet")
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Request.Data for Insert operation needs to be a snippet")
 		}
 		snippet, err := db.InsertSnippet(snippet)
 		if err != nil {
-			return nil, ReturnNone, fmt.Errorf("Error while executing Insert for '%v': %v", r.Data, err)
+			return nil, ReturnNone, err
 		}
 		return snippet, ReturnSingleSnippet, nil
 	case Update:
 		snippet, ok := r.Data.(model.Snippet)
 		if !ok {
-			return false, ReturnBoolean, fmt.Errorf("Request.Data for Update operation needs to be a snippet")
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Request.Data for Update operation needs to be a snippet")
 		}
 		err := db.UpdateSnippet(snippet)
 		if err != nil {
-			return false, ReturnBoolean, fmt.Errorf("Error while executing Insert for '%v': %v", r.Data, err)
+			return nil, ReturnNone, err
 		}
 		return true, ReturnBoolean, nil
 	case Delete:
 		snippetID, ok := r.Data.(model.ID)
 		if !ok {
-			return false, ReturnBoolean, fmt.Errorf("Request.Data for Delete operation needs to be a snippet")
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Request.Data for Delete operation needs to be a string")
 		}
 		err := db.DeleteSnippet(snippetID)
 		if err != nil {
-			return false, ReturnBoolean, fmt.Errorf("Error while executing Insert for '%v': %v", r.Data, err)
+			return nil, ReturnNone, err
 		}
 		return true, ReturnBoolean, nil
 	case InsertTeam:
 		team, ok := r.Data.(model.Team)
 		if !ok {
-			return nil, ReturnNone, fmt.Errorf("Request.Data for InsertTeam operation needs to be a team")
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Request.Data for InsertTeam operation needs to be a team")
 		}
 		err := db.InsertTeam(team.Name, team.DisplayName, team.PasswordHash, team.AdminHash)
 		if err != nil {
-			return nil, ReturnNone, fmt.Errorf("Error while executing InsertTeam for '%v': %v", r.Data, err)
+			return nil, ReturnNone, err
 		}
 		return true, ReturnBoolean, nil
 	case UpdateTeam:
 		team, ok := r.Data.(model.Team)
 		if !ok {
-			return nil, ReturnNone, fmt.Errorf("Request.Data for UpdateTeam operation needs to be a team")
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Request.Data for UpdateTeam operation needs to be a team")
 		}
 		err := db.UpdateTeam(team)
 		if err != nil {
-			return nil, ReturnNone, fmt.Errorf("Error while executing UpdateTeam for '%v': %v", r.Data, err)
+			return nil, ReturnNone, err
 		}
 		return true, ReturnBoolean, nil
 	case DeleteTeam:
 		teamId, ok := r.Data.(string)
 		if !ok {
-			return nil, ReturnNone, fmt.Errorf("Request.Data for DeleteTeam operation needs to be a string")
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Request.Data for DeleteTeam operation needs to be a string")
 		}
 		err := db.DeleteTeam(teamId)
 		if err != nil {
-			return nil, ReturnNone, fmt.Errorf("Error while executing DeleteTeam for '%v': %v", r.Data, err)
+			return nil, ReturnNone, err
 		}
 		return nil, ReturnNone, nil
 	case Check:
 		return true, ReturnBoolean, nil
+	case Identify:
+		return Identity{Role: r.role, Key: r.key}, ReturnIdentity, nil
+	case Login:
+		session, err := db.CreateSession(r.teamID, r.role)
+		if err != nil {
+			return nil, ReturnNone, err
+		}
+		return session, ReturnSession, nil
+	case Refresh:
+		if r.session == "" {
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Refresh operation needs a session")
+		}
+		session, err := db.CreateSession(r.teamID, r.role)
+		if err != nil {
+			return nil, ReturnNone, err
+		}
+		err = db.RevokeSession(r.teamID, r.session)
+		if err != nil {
+			return nil, ReturnNone, err
+		}
+		return session, ReturnSession, nil
+	case Logout:
+		if r.session == "" {
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Logout operation needs a session")
+		}
+		err := db.RevokeSession(r.teamID, r.session)
+		if err != nil {
+			return nil, ReturnNone, err
+		}
+		return true, ReturnBoolean, nil
+	case CreateAPIKey:
+		key, ok := r.Data.(model.APIKey)
+		if !ok {
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Request.Data for CreateAPIKey operation needs to be an API key")
+		}
+		key.TeamID = r.teamID
+		err := db.InsertAPIKey(key)
+		if err != nil {
+			return nil, ReturnNone, err
+		}
+		return true, ReturnBoolean, nil
+	case ListAPIKeys:
+		keys, err := db.GetAPIKeys(r.teamID)
+		if err != nil {
+			return nil, ReturnNone, err
+		}
+		return keys, ReturnAPIKeys, nil
+	case RevokeAPIKey:
+		id, ok := r.Data.(string)
+		if !ok {
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Request.Data for RevokeAPIKey operation needs to be a string")
+		}
+		err := db.DeleteAPIKey(r.teamID, id)
+		if err != nil {
+			return nil, ReturnNone, err
+		}
+		return true, ReturnBoolean, nil
+	case ListLockouts:
+		teamID, ok := r.Data.(string)
+		if !ok {
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Request.Data for ListLockouts operation needs to be a string")
+		}
+		lockouts, err := db.GetLockouts(teamID)
+		if err != nil {
+			return nil, ReturnNone, err
+		}
+		return lockouts, ReturnLockouts, nil
+	case ClearLockouts:
+		lockout, ok := r.Data.(model.Lockout)
+		if !ok {
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Request.Data for ClearLockouts operation needs to be a lockout")
+		}
+		err := db.ClearLockouts(lockout.TeamID, lockout.Client)
+		if err != nil {
+			return nil, ReturnNone, err
+		}
+		return true, ReturnBoolean, nil
+	case RotatePassword:
+		passwords, ok := r.Data.(model.TeamPasswords)
+		if !ok {
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Request.Data for RotatePassword operation needs to be passwords")
+		}
+		err := db.SetTeamPasswords(r.teamID, passwords)
+		if err != nil {
+			return nil, ReturnNone, err
+		}
+		return true, ReturnBoolean, nil
+	case GetTeam:
+		team, err := db.GetTeamByID(r.teamID)
+		if err != nil {
+			return nil, ReturnNone, err
+		}
+		team.PasswordHash, team.AdminHash, team.ReadOnlyHash = "", "", ""
+		return team, ReturnTeam, nil
+	case InsertBatch:
+		snippets, ok := r.Data.([]model.Snippet)
+		if !ok {
+			return nil, ReturnNone, errs.Errorf(errs.ErrInvalid, "Request.Data for InsertBatch operation needs to be a list of snippets")
+		}
+		inserted, err := db.InsertSnippets(snippets)
+		if err != nil {
+			return nil, ReturnNone, err
+		}
+		return inserted, ReturnSnippetList, nil
 	}
 
 	return nil, ReturnNone, nil
 }
 
+// TypeCheck checks that data returned by Execute matches retType.
 func TypeCheck(data any, retType RequestReturn) error {
 	switch retType {
 	case ReturnSingleSnippet:
@@ -250,6 +576,31 @@ func TypeCheck(data any, retType RequestReturn) error {
 		if !ok {
 			return fmt.Errorf("Expected data to be a boolean")
 		}
+	case ReturnSession:
+		_, ok := data.(model.Session)
+		if !ok {
+			return fmt.Errorf("Expected data to be a session")
+		}
+	case ReturnAPIKeys:
+		_, ok := data.([]model.APIKey)
+		if !ok {
+			return fmt.Errorf("Expected data to be a list of API keys")
+		}
+	case ReturnLockouts:
+		_, ok := data.([]model.Lockout)
+		if !ok {
+			return fmt.Errorf("Expected data to be a list of lockouts")
+		}
+	case ReturnEffect:
+		_, ok := data.(Effect)
+		if !ok {
+			return fmt.Errorf("Expected data to be an effect")
+		}
+	case ReturnIdentity:
+		_, ok := data.(Identity)
+		if !ok {
+			return fmt.Errorf("Expected data to be an identity")
+		}
 	}
 	return nil
 }
@@ -3,7 +3,9 @@ package request
 import (
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
 	"github.com/snippetaccumulator/snac/internal/backend/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -18,6 +20,66 @@ func (m *MockDatabase) CheckTeamPassword(teamID string, password string, admin b
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockDatabase) CheckTeamRole(teamID string, password string) (model.Role, error) {
+	args := m.Called(teamID, password)
+	return args.Get(0).(model.Role), args.Error(1)
+}
+
+func (m *MockDatabase) CreateSession(teamID string, role model.Role) (model.Session, error) {
+	args := m.Called(teamID, role)
+	return args.Get(0).(model.Session), args.Error(1)
+}
+
+func (m *MockDatabase) CheckSession(teamID string, token string) (model.Role, error) {
+	args := m.Called(teamID, token)
+	return args.Get(0).(model.Role), args.Error(1)
+}
+
+func (m *MockDatabase) RevokeSession(teamID string, token string) error {
+	args := m.Called(teamID, token)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) InsertAPIKey(key model.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) GetAPIKeys(teamID string) ([]model.APIKey, error) {
+	args := m.Called(teamID)
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *MockDatabase) DeleteAPIKey(teamID string, id string) error {
+	args := m.Called(teamID, id)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) CheckAPIKey(teamID string, token string) (model.APIKey, error) {
+	args := m.Called(teamID, token)
+	return args.Get(0).(model.APIKey), args.Error(1)
+}
+
+func (m *MockDatabase) SetTeamPasswords(teamID string, passwords model.TeamPasswords) error {
+	args := m.Called(teamID, passwords)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) GetLockouts(teamID string) ([]model.Lockout, error) {
+	args := m.Called(teamID)
+	return args.Get(0).([]model.Lockout), args.Error(1)
+}
+
+func (m *MockDatabase) SaveLockout(lockout model.Lockout) error {
+	args := m.Called(lockout)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) ClearLockouts(teamID string, client string) error {
+	args := m.Called(teamID, client)
+	return args.Error(0)
+}
+
 func (m *MockDatabase) GetTeamByID(teamID string) (model.Team, error) {
 	args := m.Called(teamID)
 	return args.Get(0).(model.Team), args.Error(1)
@@ -33,11 +95,21 @@ func (m *MockDatabase) GetByTeamID(teamID string) ([]model.PartialSnippet, error
 	return args.Get(0).([]model.PartialSnippet), args.Error(1)
 }
 
+func (m *MockDatabase) GetSnippetsByTeamID(teamID string) ([]model.Snippet, error) {
+	args := m.Called(teamID)
+	return args.Get(0).([]model.Snippet), args.Error(1)
+}
+
 func (m *MockDatabase) InsertSnippet(snippet model.Snippet) (model.Snippet, error) {
 	args := m.Called(snippet)
 	return args.Get(0).(model.Snippet), args.Error(1)
 }
 
+func (m *MockDatabase) InsertSnippets(snippets []model.Snippet) ([]model.Snippet, error) {
+	args := m.Called(snippets)
+	return args.Get(0).([]model.Snippet), args.Error(1)
+}
+
 func (m *MockDatabase) UpdateSnippet(snippet model.Snippet) error {
 	args := m.Called(snippet)
 	return args.Error(0)
@@ -69,27 +141,25 @@ func (m *MockDatabase) Close() {
 
 func TestRequestExecute_Get(t *testing.T) {
 	db := new(MockDatabase)
-	db.On("CheckTeamPassword", "team1", "password", false).Return(true, nil)
-	snippet := model.Snippet{ID: "1", Content: "Sample"}
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	snippet := model.Snippet{ID: "1", TeamID: "team1", Content: "Sample"}
 	snippetID := model.ID("1")
 
 	// Setup mock for successful snippet retrieval
 	db.On("GetByID", snippetID).Return(snippet, nil)
 
 	// Test case for successful Get
-	req := NewRequestBuilder().ForTeamByID("team1", "password", false).Get(snippetID).Build()
-	result, retType, err := req.Execute(db)
+	req := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildGet(snippetID)
+	result, err := req.Execute(db)
 	assert.Nil(t, err)
-	assert.NotNil(t, result)
 	assert.Equal(t, snippet, result)
-	assert.Equal(t, ReturnSingleSnippet, retType)
 
 	db.AssertExpectations(t)
 }
 
 func TestRequestExecute_GetAllPartials(t *testing.T) {
 	db := new(MockDatabase)
-	db.On("CheckTeamPassword", "team1", "password", false).Return(true, nil)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
 	teamID := "team1"
 	partials := []model.PartialSnippet{{ID: "1", Title: "Sample Partial"}}
 
@@ -97,62 +167,105 @@ func TestRequestExecute_GetAllPartials(t *testing.T) {
 	db.On("GetByTeamID", teamID).Return(partials, nil) // Successful case
 
 	// Create the request
-	req := NewRequestBuilder().ForTeamByID(teamID, "password", false).GetAllPartials().Build()
+	req := NewRequestBuilder().ForTeamByID(teamID, "password", false).BuildGetAllPartials()
 
 	// Execute the request
-	result, retType, err := req.Execute(db)
+	result, err := req.Execute(db)
 	assert.Nil(t, err)
-	assert.Equal(t, ReturnPartials, retType)
 	assert.Equal(t, partials, result)
 
 	db.AssertExpectations(t)
 }
 
+func TestRequestExecute_GetAll(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleReadOnly, nil)
+	snippets := []model.Snippet{{ID: "1", TeamID: "team1", Content: "echo 1"}, {ID: "2", TeamID: "team1", Content: "echo 2"}}
+	db.On("GetSnippetsByTeamID", "team1").Return(snippets, nil).Once()
+
+	result, err := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildGetAll().Execute(db)
+	assert.Nil(t, err)
+	assert.Equal(t, snippets, result)
+
+	// API keys only get the snippets of their tags
+	db.On("CheckAPIKey", "team1", "key").Return(model.APIKey{ID: "1", Scope: model.RoleReadOnly, Tags: []string{"ci"}}, nil)
+	db.On("GetSnippetsByTeamID", "team1").Return([]model.Snippet{
+		{ID: "1", TeamID: "team1", Tags: []string{"ci"}},
+		{ID: "2", TeamID: "team1", Tags: []string{"go"}},
+	}, nil).Once()
+	result, err = NewRequestBuilder().ForTeamWithAPIKey("team1", "key").BuildGetAll().Execute(db)
+	assert.Nil(t, err)
+	assert.Equal(t, []model.Snippet{{ID: "1", TeamID: "team1", Tags: []string{"ci"}}}, result)
+
+	db.AssertExpectations(t)
+}
+
 func TestRequestExecute_Insert(t *testing.T) {
 	db := new(MockDatabase)
-	db.On("CheckTeamPassword", "team1", "password", false).Return(true, nil)
-	snippet := model.Snippet{ID: "1", Content: "Sample"}
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	snippet := model.Snippet{ID: "1", TeamID: "team1", Content: "Sample"}
 	db.On("InsertSnippet", snippet).Return(snippet, nil) // Mock successful insert
 
-	req := NewRequestBuilder().ForTeamByID("team1", "password", false).Insert(snippet).Build()
+	req := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildInsert(snippet)
 
 	// Test successful Insert
-	result, retType, err := req.Execute(db)
+	result, err := req.Execute(db)
 	assert.Nil(t, err)
 	assert.Equal(t, snippet, result)
-	assert.Equal(t, ReturnSingleSnippet, retType)
+
+	db.AssertExpectations(t)
+}
+
+func TestRequestExecute_InsertBatch(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	db.On("CheckTeamRole", "team1", "readonly").Return(model.RoleReadOnly, nil)
+	snippets := []model.Snippet{{ID: "1", TeamID: "team1", Content: "One"}, {ID: "2", TeamID: "team1", Content: "Two"}}
+	db.On("InsertSnippets", snippets).Return(snippets, nil)
+
+	result, err := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildInsertBatch(snippets).Execute(db)
+	assert.Nil(t, err)
+	assert.Equal(t, snippets, result)
+
+	_, err = NewRequestBuilder().ForTeamByID("team1", "password", false).BuildInsertBatch(nil).Execute(db)
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+
+	_, err = NewRequestBuilder().ForTeamByID("team1", "readonly", false).BuildInsertBatch(snippets).Execute(db)
+	assert.ErrorIs(t, err, errs.ErrForbidden)
 
 	db.AssertExpectations(t)
 }
 
 func TestRequestExecute_Update(t *testing.T) {
 	db := new(MockDatabase)
-	db.On("CheckTeamPassword", "team1", "password", false).Return(true, nil)
-	snippet := model.Snippet{ID: "1", Content: "Updated Sample"}
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	snippet := model.Snippet{ID: "1", TeamID: "team1", Content: "Updated Sample"}
+	db.On("GetByID", model.ID("1")).Return(model.Snippet{ID: "1", TeamID: "team1", Content: "Sample"}, nil)
 	db.On("UpdateSnippet", snippet).Return(nil) // Mock successful update
 
-	req := NewRequestBuilder().ForTeamByID("team1", "password", false).Update(snippet).Build()
+	req := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildUpdate(snippet)
 
 	// Test successful Update
-	_, retType, err := req.Execute(db)
+	result, err := req.Execute(db)
 	assert.Nil(t, err)
-	assert.Equal(t, ReturnBoolean, retType)
+	assert.True(t, result)
 
 	db.AssertExpectations(t)
 }
 
 func TestRequestExecute_Delete(t *testing.T) {
 	db := new(MockDatabase)
-	db.On("CheckTeamPassword", "team1", "password", false).Return(true, nil)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
 	snippetID := model.ID("1")
+	db.On("GetByID", snippetID).Return(model.Snippet{ID: "1", TeamID: "team1"}, nil)
 	db.On("DeleteSnippet", snippetID).Return(nil) // Mock successful delete
 
-	req := NewRequestBuilder().ForTeamByID("team1", "password", false).Delete(snippetID).Build()
+	req := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildDelete(snippetID)
 
 	// Test successful Delete
-	_, retType, err := req.Execute(db)
+	result, err := req.Execute(db)
 	assert.Nil(t, err)
-	assert.Equal(t, ReturnBoolean, retType)
+	assert.True(t, result)
 
 	db.AssertExpectations(t)
 }
@@ -162,51 +275,63 @@ func TestRequestExecute_InsertTeam(t *testing.T) {
 	team := model.Team{Name: "newTeam", DisplayName: "New Team", PasswordHash: "passhash", AdminHash: "adminhash"}
 	db.On("InsertTeam", team.Name, team.DisplayName, team.PasswordHash, team.AdminHash).Return(nil) // Mock successful insert
 
-	req := NewRequestBuilder().NewTeam(team).Build()
+	req := NewRequestBuilder().WithInstanceToken("secret").BuildNewTeam(team)
 
 	// Test successful InsertTeam
-	_, retType, err := req.Execute(db)
+	result, err := req.ExecuteWith(NewPipeline(InstanceAdmin("secret")).Use(Defaults()...), db)
 	assert.Nil(t, err)
-	assert.Equal(t, ReturnBoolean, retType)
+	assert.True(t, result)
 
 	db.AssertExpectations(t)
 }
 
 func TestRequestExecute_UpdateTeam(t *testing.T) {
 	db := new(MockDatabase)
-	db.On("CheckTeamPassword", "team1", "password", false).Return(true, nil)
-	team := model.Team{Name: "existingTeam", DisplayName: "Updated Team", PasswordHash: "passhash", AdminHash: "adminhash"}
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleAdmin, nil)
+	team := model.Team{Name: "team1", DisplayName: "Updated Team", PasswordHash: "passhash", AdminHash: "adminhash"}
 	db.On("UpdateTeam", team).Return(nil) // Mock successful update
 
-	req := NewRequestBuilder().ForTeamByID("team1", "password", false).UpdateTeam(team).Build()
+	req := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildUpdateTeam(team)
 
 	// Test successful UpdateTeam
-	_, retType, err := req.Execute(db)
+	result, err := req.Execute(db)
 	assert.Nil(t, err)
-	assert.Equal(t, ReturnBoolean, retType)
+	assert.True(t, result)
 
 	db.AssertExpectations(t)
 }
 
 func TestRequestExecute_DeleteTeam(t *testing.T) {
 	db := new(MockDatabase)
-	db.On("CheckTeamPassword", "team1", "password", false).Return(true, nil)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleAdmin, nil)
 	teamID := "team1"
 	db.On("DeleteTeam", teamID).Return(nil) // Mock successful delete
 
-	req := NewRequestBuilder().ForTeamByID("team1", "password", false).DeleteTeam(teamID).Build()
+	req := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildDeleteTeam(teamID)
 
 	// Test successful DeleteTeam
-	_, retType, err := req.Execute(db)
+	result, err := req.Execute(db)
 	assert.Nil(t, err)
-	assert.Equal(t, ReturnNone, retType)
+	assert.Equal(t, None{}, result)
+
+	db.AssertExpectations(t)
+}
+
+func TestRequestExecute_GetTeam(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "readonly").Return(model.RoleReadOnly, nil)
+	db.On("GetTeamByID", "team1").Return(model.Team{Name: "team1", DisplayName: "Team 1", PasswordHash: "passhash", AdminHash: "adminhash"}, nil)
+
+	team, err := NewRequestBuilder().ForTeamByID("team1", "readonly", false).BuildGetTeam().Execute(db)
+	assert.Nil(t, err)
+	assert.Equal(t, model.Team{Name: "team1", DisplayName: "Team 1"}, team)
 
 	db.AssertExpectations(t)
 }
 
 func TestRequestExecute_Get_Negative(t *testing.T) {
 	db := new(MockDatabase)
-	db.On("CheckTeamPassword", "team1", "password", false).Return(true, nil)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
 	invalidID := model.ID("2")
 
 	// Test case for failed Get due to non-existent ID
@@ -226,8 +351,8 @@ func TestRequestExecute_Get_Negative(t *testing.T) {
 
 func TestRequestExecute_Insert_Negative(t *testing.T) {
 	db := new(MockDatabase)
-	db.On("CheckTeamPassword", "team1", "password", false).Return(true, nil)
-	snippet := model.Snippet{ID: "1", Content: "Sample"}
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	snippet := model.Snippet{ID: "1", TeamID: "team1", Content: "Sample"}
 	db.On("InsertSnippet", snippet).Return(model.Snippet{}, errors.New("insert error"))
 	req := NewRequestBuilder().ForTeamByID("team1", "password", false).Insert(snippet).Build()
 	_, _, err := req.Execute(db)
@@ -238,8 +363,9 @@ func TestRequestExecute_Insert_Negative(t *testing.T) {
 
 func TestRequestExecute_Update_Negative(t *testing.T) {
 	db := new(MockDatabase)
-	db.On("CheckTeamPassword", "team1", "password", false).Return(true, nil)
-	snippet := model.Snippet{ID: "1", Content: "Updated Sample"}
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	snippet := model.Snippet{ID: "1", TeamID: "team1", Content: "Updated Sample"}
+	db.On("GetByID", model.ID("1")).Return(model.Snippet{ID: "1", TeamID: "team1"}, nil)
 	db.On("UpdateSnippet", snippet).Return(errors.New("update error"))
 	req := NewRequestBuilder().ForTeamByID("team1", "password", false).Update(snippet).Build()
 	_, _, err := req.Execute(db)
@@ -250,8 +376,9 @@ func TestRequestExecute_Update_Negative(t *testing.T) {
 
 func TestRequestExecute_Delete_Negative(t *testing.T) {
 	db := new(MockDatabase)
-	db.On("CheckTeamPassword", "team1", "password", false).Return(true, nil)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
 	snippetID := model.ID("1")
+	db.On("GetByID", snippetID).Return(model.Snippet{ID: "1", TeamID: "team1"}, nil)
 	db.On("DeleteSnippet", snippetID).Return(errors.New("delete error"))
 	req := Request{Operation: Delete, teamID: "team1", password: "password", Data: snippetID}
 	_, _, err := req.Execute(db)
@@ -264,8 +391,8 @@ func TestRequestExecute_InsertTeam_Negative(t *testing.T) {
 	db := new(MockDatabase)
 	team := model.Team{Name: "newTeam", DisplayName: "New Team", PasswordHash: "passhash", AdminHash: "adminhash"}
 	db.On("InsertTeam", team.Name, team.DisplayName, team.PasswordHash, team.AdminHash).Return(errors.New("insert team error"))
-	req := NewRequestBuilder().NewTeam(team).Build()
-	_, _, err := req.Execute(db)
+	req := NewRequestBuilder().WithInstanceToken("secret").NewTeam(team).Build()
+	_, _, err := NewPipeline(InstanceAdmin("secret")).Use(Defaults()...).Execute(req, db)
 	assert.NotNil(t, err)
 
 	db.AssertExpectations(t)
@@ -273,8 +400,8 @@ func TestRequestExecute_InsertTeam_Negative(t *testing.T) {
 
 func TestRequestExecute_UpdateTeam_Negative(t *testing.T) {
 	db := new(MockDatabase)
-	db.On("CheckTeamPassword", "team1", "password", false).Return(true, nil)
-	team := model.Team{Name: "existingTeam", DisplayName: "Updated Team", PasswordHash: "passhash", AdminHash: "adminhash"}
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleAdmin, nil)
+	team := model.Team{Name: "team1", DisplayName: "Updated Team", PasswordHash: "passhash", AdminHash: "adminhash"}
 	db.On("UpdateTeam", team).Return(errors.New("update team error"))
 	req := Request{Operation: UpdateTeam, teamID: "team1", password: "password", Data: team}
 	_, _, err := req.Execute(db)
@@ -285,7 +412,7 @@ func TestRequestExecute_UpdateTeam_Negative(t *testing.T) {
 
 func TestRequestExecute_DeleteTeam_Negative(t *testing.T) {
 	db := new(MockDatabase)
-	db.On("CheckTeamPassword", "team1", "password", false).Return(true, nil)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleAdmin, nil)
 	teamID := "team1"
 	db.On("DeleteTeam", teamID).Return(errors.New("delete team error"))
 	req := Request{Operation: DeleteTeam, teamID: "team1", password: "password", Data: teamID}
@@ -297,10 +424,351 @@ func TestRequestExecute_DeleteTeam_Negative(t *testing.T) {
 
 func TestWrongPassword(t *testing.T) {
 	db := new(MockDatabase)
-	db.On("CheckTeamPassword", "team1", "password", false).Return(false, nil)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleNone, nil)
 	req := NewRequestBuilder().ForTeamByID("team1", "password", false).Get(model.ID("1")).Build()
 	_, _, err := req.Execute(db)
 	assert.NotNil(t, err)
 
 	db.AssertExpectations(t)
 }
+
+func TestRequestExecute_Dynamic(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	snippet := model.Snippet{ID: "1", TeamID: "team1", Content: "Sample"}
+	db.On("GetByID", model.ID("1")).Return(snippet, nil)
+
+	req := NewRequestBuilder().ForTeamByID("team1", "password", false).Get(model.ID("1")).Build()
+	result, retType, err := req.Execute(db)
+	assert.Nil(t, err)
+	assert.Equal(t, ReturnSingleSnippet, retType)
+	assert.Nil(t, TypeCheck(result, retType))
+	assert.NotNil(t, TypeCheck(result, ReturnPartials))
+
+	db.AssertExpectations(t)
+}
+
+func TestExecute_TypeMismatch(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	db.On("GetByID", model.ID("1")).Return(model.Snippet{ID: "1", TeamID: "team1"}, nil)
+
+	req := NewRequestBuilder().ForTeamByID("team1", "password", false).Get(model.ID("1")).Build()
+	_, err := Execute[[]model.PartialSnippet](req, db)
+	assert.EqualError(t, err, "Expected data to be []model.PartialSnippet, got model.Snippet")
+
+	db.AssertExpectations(t)
+}
+
+func TestExecute_ErrorHasNoPayload(t *testing.T) {
+	db := new(MockDatabase)
+
+	req := NewRequestBuilder().ForTeamByID("team1", "password", false).Update(model.Snippet{}).Build()
+	req.Data = "not a snippet"
+	result, retType, err := req.Execute(db)
+	assert.NotNil(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, ReturnNone, retType)
+
+	db.AssertExpectations(t)
+}
+
+func TestPermissions_InsertTeamNeedsInstanceAdmin(t *testing.T) {
+	db := new(MockDatabase)
+	team := model.Team{Name: "newTeam"}
+
+	_, err := NewRequestBuilder().BuildNewTeam(team).Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	p := NewPipeline(InstanceAdmin("secret")).Use(Defaults()...)
+	_, err = NewRequestBuilder().WithInstanceToken("wrong").BuildNewTeam(team).ExecuteWith(p, db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// an empty token must never grant anything
+	p = NewPipeline(InstanceAdmin("")).Use(Defaults()...)
+	_, err = NewRequestBuilder().BuildNewTeam(team).ExecuteWith(p, db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	db.AssertExpectations(t)
+}
+
+func TestPermissions_ReadOnly(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "readonly").Return(model.RoleReadOnly, nil)
+	db.On("GetByTeamID", "team1").Return([]model.PartialSnippet{}, nil)
+
+	b := NewRequestBuilder()
+	_, err := b.ForTeamByID("team1", "readonly", false).BuildGetAllPartials().Execute(db)
+	assert.Nil(t, err)
+
+	_, err = b.ForTeamByID("team1", "readonly", false).BuildInsert(model.Snippet{ID: "1"}).Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = b.ForTeamByID("team1", "readonly", false).BuildDelete("1").Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	db.AssertExpectations(t)
+}
+
+func TestPermissions_MemberCannotChangeTeam(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+
+	b := NewRequestBuilder()
+	_, err := b.ForTeamByID("team1", "password", false).BuildUpdateTeam(model.Team{Name: "team1"}).Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = b.ForTeamByID("team1", "password", false).BuildDeleteTeam("team1").Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	db.AssertExpectations(t)
+}
+
+func TestScope_OtherTeamsAreDenied(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "admin").Return(model.RoleAdmin, nil)
+	db.On("GetByID", model.ID("2")).Return(model.Snippet{ID: "2", TeamID: "team2", Content: "Secret"}, nil)
+
+	b := NewRequestBuilder()
+	_, err := b.ForTeamByID("team1", "admin", false).BuildGet("2").Execute(db)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	_, err = b.ForTeamByID("team1", "admin", false).BuildUpdate(model.Snippet{ID: "2", TeamID: "team1"}).Execute(db)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	_, err = b.ForTeamByID("team1", "admin", false).BuildDelete("2").Execute(db)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	_, err = b.ForTeamByID("team1", "admin", false).BuildInsert(model.Snippet{TeamID: "team2"}).Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = b.ForTeamByID("team1", "admin", false).BuildInsertBatch([]model.Snippet{{TeamID: "team1"}, {TeamID: "team2"}}).Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = b.ForTeamByID("team1", "admin", false).BuildUpdateTeam(model.Team{Name: "team2"}).Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = b.ForTeamByID("team1", "admin", false).BuildDeleteTeam("team2").Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	db.AssertNotCalled(t, "UpdateSnippet", mock.Anything)
+	db.AssertNotCalled(t, "DeleteSnippet", mock.Anything)
+	db.AssertNotCalled(t, "InsertSnippet", mock.Anything)
+	db.AssertNotCalled(t, "InsertSnippets", mock.Anything)
+	db.AssertNotCalled(t, "UpdateTeam", mock.Anything)
+	db.AssertNotCalled(t, "DeleteTeam", mock.Anything)
+	db.AssertExpectations(t)
+}
+
+func TestAuthenticate_AdminFlagNeedsAdminPassword(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+
+	_, err := NewRequestBuilder().ForTeamByID("team1", "password", true).BuildCheck().Execute(db)
+	assert.EqualError(t, err, "Incorrect password for team 'team1'")
+
+	db.AssertExpectations(t)
+}
+
+func TestRequestExecute_Login(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	session := model.Session{Token: "token", TeamID: "team1", Role: model.RoleMember}
+	db.On("CreateSession", "team1", model.RoleMember).Return(session, nil)
+
+	result, err := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildLogin().Execute(db)
+	assert.Nil(t, err)
+	assert.Equal(t, session, result)
+
+	db.AssertExpectations(t)
+}
+
+func TestRequestExecute_Session(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckSession", "team1", "token").Return(model.RoleReadOnly, nil)
+	db.On("GetByTeamID", "team1").Return([]model.PartialSnippet{}, nil)
+
+	b := NewRequestBuilder()
+	_, err := b.ForTeamWithSession("team1", "token").BuildGetAllPartials().Execute(db)
+	assert.Nil(t, err)
+
+	// the session keeps the role it was issued for
+	_, err = b.ForTeamWithSession("team1", "token").BuildDelete("1").Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	db.AssertExpectations(t)
+}
+
+func TestRequestExecute_InvalidSession(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckSession", "team1", "expired").Return(model.RoleNone, nil)
+
+	_, err := NewRequestBuilder().ForTeamWithSession("team1", "expired").BuildCheck().Execute(db)
+	assert.EqualError(t, err, "Invalid or expired session for team 'team1', log in again")
+
+	db.AssertExpectations(t)
+}
+
+func TestRequestExecute_Refresh(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckSession", "team1", "old").Return(model.RoleAdmin, nil)
+	session := model.Session{Token: "new", TeamID: "team1", Role: model.RoleAdmin}
+	db.On("CreateSession", "team1", model.RoleAdmin).Return(session, nil)
+	db.On("RevokeSession", "team1", "old").Return(nil)
+
+	result, err := NewRequestBuilder().ForTeamWithSession("team1", "old").BuildRefresh().Execute(db)
+	assert.Nil(t, err)
+	assert.Equal(t, session, result)
+
+	db.AssertExpectations(t)
+}
+
+func TestRequestExecute_Logout(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckSession", "team1", "token").Return(model.RoleMember, nil)
+	db.On("RevokeSession", "team1", "token").Return(nil)
+
+	result, err := NewRequestBuilder().ForTeamWithSession("team1", "token").BuildLogout().Execute(db)
+	assert.Nil(t, err)
+	assert.True(t, result)
+
+	// without a session there is nothing to log out of
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	_, err = NewRequestBuilder().ForTeamByID("team1", "password", false).BuildLogout().Execute(db)
+	assert.ErrorContains(t, err, "Logout operation needs a session")
+
+	db.AssertExpectations(t)
+}
+
+func TestRequestExecute_APIKey(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckAPIKey", "team1", "key").Return(model.APIKey{ID: "1", Scope: model.RoleReadOnly}, nil)
+	db.On("GetByTeamID", "team1").Return([]model.PartialSnippet{{ID: "1"}}, nil)
+
+	partials, err := NewRequestBuilder().ForTeamWithAPIKey("team1", "key").BuildGetAllPartials().Execute(db)
+	assert.Nil(t, err)
+	assert.Len(t, partials, 1)
+
+	_, err = NewRequestBuilder().ForTeamWithAPIKey("team1", "key").BuildDelete("1").Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// API keys are for automation, they cannot be exchanged for a session
+	_, err = NewRequestBuilder().ForTeamWithAPIKey("team1", "key").BuildLogin().Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	db.On("CheckAPIKey", "team1", "revoked").Return(model.APIKey{}, nil)
+	_, err = NewRequestBuilder().ForTeamWithAPIKey("team1", "revoked").BuildCheck().Execute(db)
+	assert.ErrorContains(t, err, "Invalid or expired API key for team 'team1'")
+
+	db.AssertExpectations(t)
+}
+
+func TestRequestExecute_APIKeyTags(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckAPIKey", "team1", "key").Return(model.APIKey{ID: "1", Scope: model.RoleMember, Tags: []string{"ci"}}, nil)
+	db.On("GetByTeamID", "team1").Return([]model.PartialSnippet{
+		{ID: "1", Tags: []string{"ci", "go"}},
+		{ID: "2", Tags: []string{"go"}},
+	}, nil)
+	db.On("GetByID", model.ID("2")).Return(model.Snippet{ID: "2", TeamID: "team1", Tags: []string{"go"}}, nil)
+	db.On("InsertSnippet", mock.Anything).Return(model.Snippet{ID: "3", TeamID: "team1", Tags: []string{"ci"}}, nil)
+
+	partials, err := NewRequestBuilder().ForTeamWithAPIKey("team1", "key").BuildGetAllPartials().Execute(db)
+	assert.Nil(t, err)
+	assert.Equal(t, []model.PartialSnippet{{ID: "1", Tags: []string{"ci", "go"}}}, partials)
+
+	_, err = NewRequestBuilder().ForTeamWithAPIKey("team1", "key").BuildGet("2").Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = NewRequestBuilder().ForTeamWithAPIKey("team1", "key").BuildInsert(model.Snippet{TeamID: "team1", Tags: []string{"go"}}).Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = NewRequestBuilder().ForTeamWithAPIKey("team1", "key").BuildInsert(model.Snippet{TeamID: "team1", Tags: []string{"ci"}}).Execute(db)
+	assert.Nil(t, err)
+
+	// retagging a snippet does not move it into the scope of the key
+	_, err = NewRequestBuilder().ForTeamWithAPIKey("team1", "key").BuildUpdate(model.Snippet{ID: "2", TeamID: "team1", Tags: []string{"ci"}}).Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	db.AssertNotCalled(t, "UpdateSnippet", mock.Anything)
+	db.AssertExpectations(t)
+}
+
+func TestRequestExecute_CreateAPIKey(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "admin").Return(model.RoleAdmin, nil)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	db.On("InsertAPIKey", mock.MatchedBy(func(key model.APIKey) bool {
+		return key.TeamID == "team1" && key.Name == "ci"
+	})).Return(nil)
+
+	key, _, err := model.NewAPIKey("other", "ci", model.RoleMember, nil, time.Time{})
+	assert.Nil(t, err)
+
+	_, err = NewRequestBuilder().ForTeamByID("team1", "password", false).BuildCreateAPIKey(key).Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// the key always belongs to the team of the request
+	result, err := NewRequestBuilder().ForTeamByID("team1", "admin", true).BuildCreateAPIKey(key).Execute(db)
+	assert.Nil(t, err)
+	assert.True(t, result)
+
+	key.Scope = model.RoleAdmin
+	_, err = NewRequestBuilder().ForTeamByID("team1", "admin", true).BuildCreateAPIKey(key).Execute(db)
+	assert.ErrorContains(t, err, "Request.Data for CreateAPIKey operation needs to be an API key with the read-only or member scope")
+
+	db.AssertExpectations(t)
+}
+
+func TestRequestExecute_ErrorKinds(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	db.On("CheckTeamRole", "team1", "wrong").Return(model.RoleNone, nil)
+	db.On("CheckTeamRole", "team2", "password").Return(model.RoleNone, errs.Errorf(errs.ErrUnavailable, "Database error: timeout"))
+	db.On("GetByID", model.ID("1")).Return(model.Snippet{}, errs.Errorf(errs.ErrNotFound, "Snippet '1' was not found"))
+
+	_, err := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildGet("1").Execute(db)
+	assert.EqualError(t, err, "Error while executing Get for '1': Snippet '1' was not found")
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	_, err = NewRequestBuilder().ForTeamByID("team1", "wrong", false).BuildGet("1").Execute(db)
+	assert.ErrorIs(t, err, ErrIncorrectPassword)
+	assert.ErrorIs(t, err, errs.ErrUnauthorized)
+
+	_, err = NewRequestBuilder().ForTeamByID("team1", "password", false).BuildDeleteTeam("team1").Execute(db)
+	assert.ErrorIs(t, err, errs.ErrForbidden)
+
+	_, err = Execute[bool](Request{Operation: Delete, Data: 1}, db)
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+
+	_, err = NewRequestBuilder().ForTeamByID("team2", "password", false).BuildCheck().Execute(db)
+	assert.ErrorIs(t, err, errs.ErrUnavailable)
+
+	db.AssertExpectations(t)
+}
+
+func TestRequestExecute_RotatePassword(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "admin").Return(model.RoleAdmin, nil)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	passwords := model.TeamPasswords{Password: "new-password"}
+	db.On("SetTeamPasswords", "team1", passwords).Return(nil)
+
+	_, err := NewRequestBuilder().ForTeamByID("team1", "password", false).BuildRotatePassword(passwords).Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	result, err := NewRequestBuilder().ForTeamByID("team1", "admin", true).BuildRotatePassword(passwords).Execute(db)
+	assert.Nil(t, err)
+	assert.True(t, result)
+
+	_, err = NewRequestBuilder().ForTeamByID("team1", "admin", true).BuildRotatePassword(model.TeamPasswords{}).Execute(db)
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+
+	// an admin session or API key is not enough, the admin password is needed
+	_, err = NewRequestBuilder().ForTeamWithSession("team1", "admin-session").BuildRotatePassword(passwords).Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = NewRequestBuilder().ForTeamWithAPIKey("team1", "admin-key").BuildRotatePassword(passwords).Execute(db)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	db.AssertExpectations(t)
+}
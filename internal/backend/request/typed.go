@@ -0,0 +1,156 @@
+package request
+
+import (
+	"fmt"
+
+	"github.com/snippetaccumulator/snac/internal/backend/database"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+)
+
+// None is the result type of operations that return no data.
+type None struct{}
+
+// Typed is a Request whose result type is known at compile time.
+// It is created by the Build* methods of the RequestBuilder.
+type Typed[T any] struct {
+	Request
+}
+
+func (t Typed[T]) Execute(db database.Database) (T, error) {
+	return Execute[T](t.Request, db)
+}
+
+// ExecuteWith runs the request through the pipeline p instead of the default one.
+func (t Typed[T]) ExecuteWith(p *Pipeline, db database.Database) (T, error) {
+	return ExecuteWith[T](p, t.Request, db)
+}
+
+// Explain runs the request as a dry run through the default pipeline, see RequestBuilder.DryRun.
+func (t Typed[T]) Explain(db database.Database) (Effect, error) {
+	return t.ExplainWith(NewPipeline(Defaults()...), db)
+}
+
+func (t Typed[T]) ExplainWith(p *Pipeline, db database.Database) (Effect, error) {
+	r := t.Request
+	r.dryRun = true
+	return ExecuteWith[Effect](p, r, db)
+}
+
+// Execute runs a dynamically built Request and checks that its result is of type T.
+func Execute[T any](r Request, db database.Database) (T, error) {
+	return ExecuteWith[T](NewPipeline(Defaults()...), r, db)
+}
+
+// ExecuteWith runs a dynamically built Request through the pipeline p and checks that its result is of type T.
+func ExecuteWith[T any](p *Pipeline, r Request, db database.Database) (T, error) {
+	var result T
+	data, _, err := p.Execute(r, db)
+	if err != nil {
+		return result, err
+	}
+
+	if data == nil {
+		if _, ok := any(result).(None); ok {
+			return result, nil
+		}
+		return result, fmt.Errorf("Expected data to be %T, got nothing", result)
+	}
+
+	result, ok := data.(T)
+	if !ok {
+		return result, fmt.Errorf("Expected data to be %T, got %T", result, data)
+	}
+	return result, nil
+}
+
+func typed[T any](b *RequestBuilder) Typed[T] {
+	return Typed[T]{Request: b.Build()}
+}
+
+func (b *RequestBuilder) BuildGet(snippetID model.ID) Typed[model.Snippet] {
+	return typed[model.Snippet](b.Get(snippetID))
+}
+
+func (b *RequestBuilder) BuildGetAllPartials() Typed[[]model.PartialSnippet] {
+	return typed[[]model.PartialSnippet](b.GetAllPartials())
+}
+
+func (b *RequestBuilder) BuildGetAll() Typed[[]model.Snippet] {
+	return typed[[]model.Snippet](b.GetAll())
+}
+
+func (b *RequestBuilder) BuildInsert(snippet model.Snippet) Typed[model.Snippet] {
+	return typed[model.Snippet](b.Insert(snippet))
+}
+
+func (b *RequestBuilder) BuildInsertBatch(snippets []model.Snippet) Typed[[]model.Snippet] {
+	return typed[[]model.Snippet](b.InsertBatch(snippets))
+}
+
+func (b *RequestBuilder) BuildUpdate(snippet model.Snippet) Typed[bool] {
+	return typed[bool](b.Update(snippet))
+}
+
+func (b *RequestBuilder) BuildDelete(snippetID model.ID) Typed[bool] {
+	return typed[bool](b.Delete(snippetID))
+}
+
+func (b *RequestBuilder) BuildNewTeam(team model.Team) Typed[bool] {
+	return typed[bool](b.NewTeam(team))
+}
+
+func (b *RequestBuilder) BuildUpdateTeam(team model.Team) Typed[bool] {
+	return typed[bool](b.UpdateTeam(team))
+}
+
+func (b *RequestBuilder) BuildDeleteTeam(teamID string) Typed[None] {
+	return typed[None](b.DeleteTeam(teamID))
+}
+
+func (b *RequestBuilder) BuildCheck() Typed[bool] {
+	return typed[bool](b.Check())
+}
+
+func (b *RequestBuilder) BuildIdentify() Typed[Identity] {
+	return typed[Identity](b.Identify())
+}
+
+func (b *RequestBuilder) BuildLogin() Typed[model.Session] {
+	return typed[model.Session](b.Login())
+}
+
+func (b *RequestBuilder) BuildRefresh() Typed[model.Session] {
+	return typed[model.Session](b.Refresh())
+}
+
+func (b *RequestBuilder) BuildLogout() Typed[bool] {
+	return typed[bool](b.Logout())
+}
+
+func (b *RequestBuilder) BuildCreateAPIKey(key model.APIKey) Typed[bool] {
+	return typed[bool](b.CreateAPIKey(key))
+}
+
+func (b *RequestBuilder) BuildListAPIKeys() Typed[[]model.APIKey] {
+	return typed[[]model.APIKey](b.ListAPIKeys())
+}
+
+func (b *RequestBuilder) BuildRevokeAPIKey(id string) Typed[bool] {
+	return typed[bool](b.RevokeAPIKey(id))
+}
+
+func (b *RequestBuilder) BuildListLockouts(teamID string) Typed[[]model.Lockout] {
+	return typed[[]model.Lockout](b.ListLockouts(teamID))
+}
+
+func (b *RequestBuilder) BuildClearLockouts(teamID string, client string) Typed[bool] {
+	return typed[bool](b.ClearLockouts(teamID, client))
+}
+
+func (b *RequestBuilder) BuildGetTeam() Typed[model.Team] {
+	return typed[model.Team](b.GetTeam())
+}
+
+func (b *RequestBuilder) BuildRotatePassword(passwords model.TeamPasswords) Typed[bool] {
+	return typed[bool](b.RotatePassword(passwords))
+}
@@ -0,0 +1,393 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/database"
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/backend/request"
+	"github.com/snippetaccumulator/snac/internal/backend/wire"
+	"github.com/snippetaccumulator/snac/internal/log"
+)
+
+// Server serves the calls of a database.Remote from a local database.
+// Every call except InsertTeam and the credential checks needs the team password as basic auth, a session as bearer token
+// or an API key, and is executed as a request restricted to the snippets and team of the authenticated team.
+type Server struct {
+	db       database.Database
+	pipeline *request.Pipeline
+	mux      *http.ServeMux
+}
+
+// caller holds the credentials sent with a call, they are checked by the pipeline.
+type caller struct {
+	teamID        string
+	password      string
+	session       string
+	apiKey        string
+	instanceToken string
+	// client is the address the call comes from, see request.Guard.
+	client string
+}
+
+// NewHandler creates a Server that runs the forwarded calls through middlewares,
+// followed by request.Validate, request.Authenticate, request.Permissions, request.Scope and request.TagScope.
+// Pass request.InstanceAdmin in middlewares to allow creating teams, and a request.Guard to limit password guessing.
+func NewHandler(db database.Database, middlewares ...request.Middleware) *Server {
+	s := &Server{
+		db:  db,
+		mux: http.NewServeMux(),
+	}
+	s.pipeline = request.NewPipeline(middlewares...).Use(
+		request.Validate(),
+		request.Authenticate(),
+		request.Permissions(),
+		request.Scope(),
+		request.TagScope(),
+	)
+
+	route(s, wire.GetByID, (*Server).getByID)
+	route(s, wire.GetByTeamID, (*Server).getByTeamID)
+	route(s, wire.GetSnippetsByTeamID, (*Server).getSnippetsByTeamID)
+	route(s, wire.InsertSnippet, (*Server).insertSnippet)
+	route(s, wire.InsertSnippets, (*Server).insertSnippets)
+	route(s, wire.UpdateSnippet, (*Server).updateSnippet)
+	route(s, wire.DeleteSnippet, (*Server).deleteSnippet)
+	route(s, wire.GetTeamByID, (*Server).getTeamByID)
+	route(s, wire.InsertTeam, (*Server).insertTeam)
+	route(s, wire.UpdateTeam, (*Server).updateTeam)
+	route(s, wire.DeleteTeam, (*Server).deleteTeam)
+	route(s, wire.CheckTeamPassword, (*Server).checkTeamPassword)
+	route(s, wire.CheckTeamRole, (*Server).checkTeamRole)
+	route(s, wire.CreateSession, (*Server).createSession)
+	route(s, wire.CheckSession, (*Server).checkSession)
+	route(s, wire.RevokeSession, (*Server).revokeSession)
+	route(s, wire.InsertAPIKey, (*Server).insertAPIKey)
+	route(s, wire.GetAPIKeys, (*Server).getAPIKeys)
+	route(s, wire.DeleteAPIKey, (*Server).deleteAPIKey)
+	route(s, wire.CheckAPIKey, (*Server).checkAPIKey)
+	route(s, wire.GetLockouts, (*Server).getLockouts)
+	route(s, wire.ClearLockouts, (*Server).clearLockouts)
+	route(s, wire.SetTeamPasswords, (*Server).setTeamPasswords)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// route serves method with fn. Calls with the wire.DryRunHeader are served by a copy of s whose database
+// is a transaction that is rolled back afterwards.
+func route[A any](s *Server, method string, fn func(s *Server, c caller, args A) (any, error)) {
+	s.mux.HandleFunc("POST "+wire.Prefix+method, func(w http.ResponseWriter, r *http.Request) {
+		c := caller{instanceToken: r.Header.Get(wire.InstanceTokenHeader), client: r.RemoteAddr}
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			c.client = host
+		}
+		auth := r.Header.Get("Authorization")
+		if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			c.teamID = r.Header.Get(wire.TeamHeader)
+			c.session = token
+		} else if key, ok := strings.CutPrefix(auth, wire.APIKeyScheme); ok {
+			c.teamID = r.Header.Get(wire.TeamHeader)
+			c.apiKey = key
+		} else {
+			c.teamID, c.password, _ = r.BasicAuth()
+		}
+
+		var args A
+		if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+			writeError(w, method, errs.Errorf(errs.ErrInvalid, "Invalid body for %s: %v", method, err))
+			return
+		}
+
+		var result any
+		var err error
+		if r.Header.Get(wire.DryRunHeader) == "true" {
+			result, err = s.dryRun(func(s *Server) (any, error) {
+				return fn(s, c, args)
+			})
+		} else {
+			result, err = fn(s, c, args)
+		}
+		if err != nil {
+			writeError(w, method, err)
+			return
+		}
+
+		if result == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	})
+}
+
+func (s *Server) dryRun(fn func(s *Server) (any, error)) (any, error) {
+	tx, ok := s.db.(database.Transactional)
+	if !ok {
+		return nil, errs.Errorf(errs.ErrInvalid, "Dry runs are not supported by this server")
+	}
+	var result any
+	err := tx.DryRun(func(db database.Database) error {
+		dryRun := *s
+		dryRun.db = keepLockouts{Database: db, db: s.db}
+		var err error
+		result, err = fn(&dryRun)
+		return err
+	})
+	return result, err
+}
+
+// keepLockouts saves failed password checks of dry runs outside of their transaction,
+// otherwise dry runs could be used to guess passwords without being locked out.
+type keepLockouts struct {
+	database.Database
+	db database.Database
+}
+
+func (k keepLockouts) SaveLockout(lockout model.Lockout) error {
+	return k.db.SaveLockout(lockout)
+}
+
+func writeError(w http.ResponseWriter, method string, err error) {
+	status := errs.HTTPStatus(err)
+	log.Debug("%s failed with %d: %v", method, status, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(wire.Error{Error: err.Error()})
+}
+
+func (s *Server) request(c caller) *request.RequestBuilder {
+	b := request.NewRequestBuilder().WithInstanceToken(c.instanceToken).FromClient(c.client)
+	if c.apiKey != "" {
+		return b.ForTeamWithAPIKey(c.teamID, c.apiKey)
+	}
+	if c.session != "" {
+		return b.ForTeamWithSession(c.teamID, c.session)
+	}
+	return b.ForTeamByID(c.teamID, c.password, false)
+}
+
+func (s *Server) getByID(c caller, args wire.IDArgs) (any, error) {
+	return s.request(c).BuildGet(args.ID).ExecuteWith(s.pipeline, s.db)
+}
+
+func (s *Server) getByTeamID(c caller, args wire.TeamIDArgs) (any, error) {
+	if args.TeamID != c.teamID {
+		return nil, errs.Errorf(errs.ErrForbidden, "Not allowed to list snippets of team '%s'", args.TeamID)
+	}
+	partials, err := s.request(c).BuildGetAllPartials().ExecuteWith(s.pipeline, s.db)
+	if err != nil {
+		return nil, err
+	}
+	if partials == nil {
+		partials = []model.PartialSnippet{}
+	}
+	return partials, nil
+}
+
+func (s *Server) getSnippetsByTeamID(c caller, args wire.TeamIDArgs) (any, error) {
+	if args.TeamID != c.teamID {
+		return nil, errs.Errorf(errs.ErrForbidden, "Not allowed to list snippets of team '%s'", args.TeamID)
+	}
+	snippets, err := s.request(c).BuildGetAll().ExecuteWith(s.pipeline, s.db)
+	if err != nil {
+		return nil, err
+	}
+	if snippets == nil {
+		snippets = []model.Snippet{}
+	}
+	return snippets, nil
+}
+
+func (s *Server) insertSnippet(c caller, args wire.SnippetArgs) (any, error) {
+	return s.request(c).BuildInsert(args.Snippet).ExecuteWith(s.pipeline, s.db)
+}
+
+func (s *Server) insertSnippets(c caller, args wire.SnippetsArgs) (any, error) {
+	return s.request(c).BuildInsertBatch(args.Snippets).ExecuteWith(s.pipeline, s.db)
+}
+
+func (s *Server) updateSnippet(c caller, args wire.SnippetArgs) (any, error) {
+	_, err := s.request(c).BuildUpdate(args.Snippet).ExecuteWith(s.pipeline, s.db)
+	return nil, err
+}
+
+func (s *Server) deleteSnippet(c caller, args wire.IDArgs) (any, error) {
+	_, err := s.request(c).BuildDelete(args.ID).ExecuteWith(s.pipeline, s.db)
+	return nil, err
+}
+
+// getTeamByID returns the team without its password hashes, they never leave the server.
+func (s *Server) getTeamByID(c caller, args wire.TeamIDArgs) (any, error) {
+	_, err := s.request(c).BuildCheck().ExecuteWith(s.pipeline, s.db)
+	if err != nil {
+		return nil, err
+	}
+	if args.TeamID != c.teamID {
+		return nil, errs.Errorf(errs.ErrForbidden, "Not allowed to read team '%s'", args.TeamID)
+	}
+	team, err := s.db.GetTeamByID(args.TeamID)
+	if err != nil {
+		return nil, err
+	}
+	team.PasswordHash = ""
+	team.AdminHash = ""
+	team.ReadOnlyHash = ""
+	return team, nil
+}
+
+func (s *Server) insertTeam(c caller, args wire.InsertTeamArgs) (any, error) {
+	team := model.Team{
+		Name:         args.TeamID,
+		DisplayName:  args.DisplayName,
+		PasswordHash: args.Password,
+		AdminHash:    args.AdminPassword,
+	}
+	_, err := s.request(c).BuildNewTeam(team).ExecuteWith(s.pipeline, s.db)
+	return nil, err
+}
+
+// updateTeam keeps the stored password hashes when the client sends none, see getTeamByID.
+// A read-only password can therefore not be removed through a server.
+func (s *Server) updateTeam(c caller, args wire.TeamArgs) (any, error) {
+	if args.Team.Name == c.teamID {
+		stored, err := s.db.GetTeamByID(args.Team.Name)
+		if err != nil {
+			return nil, err
+		}
+		if args.Team.PasswordHash == "" {
+			args.Team.PasswordHash = stored.PasswordHash
+		}
+		if args.Team.AdminHash == "" {
+			args.Team.AdminHash = stored.AdminHash
+		}
+		if args.Team.ReadOnlyHash == "" {
+			args.Team.ReadOnlyHash = stored.ReadOnlyHash
+		}
+	}
+	_, err := s.request(c).BuildUpdateTeam(args.Team).ExecuteWith(s.pipeline, s.db)
+	return nil, err
+}
+
+func (s *Server) deleteTeam(c caller, args wire.TeamIDArgs) (any, error) {
+	_, err := s.request(c).BuildDeleteTeam(args.TeamID).ExecuteWith(s.pipeline, s.db)
+	return nil, err
+}
+
+// checkPassword runs a password check through the pipeline, so it is guarded like every other call.
+// An incorrect password is not an error of the call, it is reported as its result.
+func (s *Server) checkPassword(c caller, teamID string, password string, admin bool) (bool, error) {
+	_, err := request.NewRequestBuilder().ForTeamByID(teamID, password, admin).FromClient(c.client).BuildCheck().ExecuteWith(s.pipeline, s.db)
+	if errors.Is(err, request.ErrIncorrectPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *Server) checkTeamPassword(c caller, args wire.CheckTeamPasswordArgs) (any, error) {
+	correct, err := s.checkPassword(c, args.TeamID, args.Password, args.Admin)
+	if err != nil {
+		return nil, err
+	}
+	return wire.CheckTeamPasswordResult{Correct: correct}, nil
+}
+
+// checkTeamRole returns the role of the password, it is checked once through the pipeline like checkPassword.
+func (s *Server) checkTeamRole(c caller, args wire.CheckTeamRoleArgs) (any, error) {
+	identity, err := request.NewRequestBuilder().ForTeamByID(args.TeamID, args.Password, false).FromClient(c.client).BuildIdentify().ExecuteWith(s.pipeline, s.db)
+	if errors.Is(err, request.ErrIncorrectPassword) {
+		return wire.CheckTeamRoleResult{Role: model.RoleNone}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return wire.CheckTeamRoleResult{Role: identity.Role}, nil
+}
+
+// createSession logs the caller in, the role of the session is the one of its credentials, not the requested one.
+func (s *Server) createSession(c caller, args wire.CreateSessionArgs) (any, error) {
+	if args.TeamID != c.teamID {
+		return nil, errs.Errorf(errs.ErrForbidden, "Not allowed to create sessions for team '%s'", args.TeamID)
+	}
+	return s.request(c).BuildLogin().ExecuteWith(s.pipeline, s.db)
+}
+
+// checkSession returns the role of the session in args, it is guarded like a password check.
+func (s *Server) checkSession(c caller, args wire.SessionArgs) (any, error) {
+	identity, err := request.NewRequestBuilder().ForTeamWithSession(args.TeamID, args.Token).FromClient(c.client).BuildIdentify().ExecuteWith(s.pipeline, s.db)
+	if errors.Is(err, request.ErrInvalidSession) {
+		return wire.CheckTeamRoleResult{Role: model.RoleNone}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return wire.CheckTeamRoleResult{Role: identity.Role}, nil
+}
+
+// revokeSession logs out the session in args, knowing the token is enough to revoke it.
+func (s *Server) revokeSession(c caller, args wire.SessionArgs) (any, error) {
+	_, err := s.request(c).ForTeamWithSession(args.TeamID, args.Token).BuildLogout().ExecuteWith(s.pipeline, s.db)
+	return nil, err
+}
+
+func (s *Server) insertAPIKey(c caller, args wire.APIKeyArgs) (any, error) {
+	if args.Key.TeamID != c.teamID {
+		return nil, errs.Errorf(errs.ErrForbidden, "Not allowed to create API keys for team '%s'", args.Key.TeamID)
+	}
+	_, err := s.request(c).BuildCreateAPIKey(args.Key).ExecuteWith(s.pipeline, s.db)
+	return nil, err
+}
+
+func (s *Server) getAPIKeys(c caller, args wire.TeamIDArgs) (any, error) {
+	if args.TeamID != c.teamID {
+		return nil, errs.Errorf(errs.ErrForbidden, "Not allowed to list API keys of team '%s'", args.TeamID)
+	}
+	return s.request(c).BuildListAPIKeys().ExecuteWith(s.pipeline, s.db)
+}
+
+func (s *Server) deleteAPIKey(c caller, args wire.APIKeyIDArgs) (any, error) {
+	if args.TeamID != c.teamID {
+		return nil, errs.Errorf(errs.ErrForbidden, "Not allowed to revoke API keys of team '%s'", args.TeamID)
+	}
+	_, err := s.request(c).BuildRevokeAPIKey(args.ID).ExecuteWith(s.pipeline, s.db)
+	return nil, err
+}
+
+// checkAPIKey returns the key a token belongs to, without its hash. It is guarded like a password check.
+func (s *Server) checkAPIKey(c caller, args wire.CheckAPIKeyArgs) (any, error) {
+	identity, err := request.NewRequestBuilder().ForTeamWithAPIKey(args.TeamID, args.Token).FromClient(c.client).BuildIdentify().ExecuteWith(s.pipeline, s.db)
+	if errors.Is(err, request.ErrInvalidAPIKey) {
+		return model.APIKey{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return identity.Key, nil
+}
+
+func (s *Server) getLockouts(c caller, args wire.TeamIDArgs) (any, error) {
+	return s.request(c).BuildListLockouts(args.TeamID).ExecuteWith(s.pipeline, s.db)
+}
+
+func (s *Server) clearLockouts(c caller, args wire.LockoutArgs) (any, error) {
+	_, err := s.request(c).BuildClearLockouts(args.TeamID, args.Client).ExecuteWith(s.pipeline, s.db)
+	return nil, err
+}
+
+func (s *Server) setTeamPasswords(c caller, args wire.SetTeamPasswordsArgs) (any, error) {
+	if args.TeamID != c.teamID {
+		return nil, errs.Errorf(errs.ErrForbidden, "Not allowed to change the passwords of team '%s'", args.TeamID)
+	}
+	_, err := s.request(c).BuildRotatePassword(args.Passwords).ExecuteWith(s.pipeline, s.db)
+	return nil, err
+}
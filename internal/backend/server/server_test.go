@@ -0,0 +1,535 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/database"
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/backend/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockDatabase struct {
+	mock.Mock
+}
+
+func (m *MockDatabase) CheckTeamPassword(teamID string, password string, admin bool) (bool, error) {
+	args := m.Called(teamID, password, admin)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDatabase) CheckTeamRole(teamID string, password string) (model.Role, error) {
+	args := m.Called(teamID, password)
+	return args.Get(0).(model.Role), args.Error(1)
+}
+
+func (m *MockDatabase) CreateSession(teamID string, role model.Role) (model.Session, error) {
+	args := m.Called(teamID, role)
+	return args.Get(0).(model.Session), args.Error(1)
+}
+
+func (m *MockDatabase) CheckSession(teamID string, token string) (model.Role, error) {
+	args := m.Called(teamID, token)
+	return args.Get(0).(model.Role), args.Error(1)
+}
+
+func (m *MockDatabase) RevokeSession(teamID string, token string) error {
+	args := m.Called(teamID, token)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) InsertAPIKey(key model.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) GetAPIKeys(teamID string) ([]model.APIKey, error) {
+	args := m.Called(teamID)
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *MockDatabase) DeleteAPIKey(teamID string, id string) error {
+	args := m.Called(teamID, id)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) CheckAPIKey(teamID string, token string) (model.APIKey, error) {
+	args := m.Called(teamID, token)
+	return args.Get(0).(model.APIKey), args.Error(1)
+}
+
+func (m *MockDatabase) SetTeamPasswords(teamID string, passwords model.TeamPasswords) error {
+	args := m.Called(teamID, passwords)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) GetLockouts(teamID string) ([]model.Lockout, error) {
+	args := m.Called(teamID)
+	return args.Get(0).([]model.Lockout), args.Error(1)
+}
+
+func (m *MockDatabase) SaveLockout(lockout model.Lockout) error {
+	args := m.Called(lockout)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) ClearLockouts(teamID string, client string) error {
+	args := m.Called(teamID, client)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) GetTeamByID(teamID string) (model.Team, error) {
+	args := m.Called(teamID)
+	return args.Get(0).(model.Team), args.Error(1)
+}
+
+func (m *MockDatabase) GetByID(id model.ID) (model.Snippet, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.Snippet), args.Error(1)
+}
+
+func (m *MockDatabase) GetByTeamID(teamID string) ([]model.PartialSnippet, error) {
+	args := m.Called(teamID)
+	return args.Get(0).([]model.PartialSnippet), args.Error(1)
+}
+
+func (m *MockDatabase) GetSnippetsByTeamID(teamID string) ([]model.Snippet, error) {
+	args := m.Called(teamID)
+	return args.Get(0).([]model.Snippet), args.Error(1)
+}
+
+func (m *MockDatabase) InsertSnippet(snippet model.Snippet) (model.Snippet, error) {
+	args := m.Called(snippet)
+	return args.Get(0).(model.Snippet), args.Error(1)
+}
+
+func (m *MockDatabase) InsertSnippets(snippets []model.Snippet) ([]model.Snippet, error) {
+	args := m.Called(snippets)
+	return args.Get(0).([]model.Snippet), args.Error(1)
+}
+
+func (m *MockDatabase) UpdateSnippet(snippet model.Snippet) error {
+	args := m.Called(snippet)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) DeleteSnippet(id model.ID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) InsertTeam(name, displayName, passwordHash, adminHash string) error {
+	args := m.Called(name, displayName, passwordHash, adminHash)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) UpdateTeam(team model.Team) error {
+	args := m.Called(team)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) DeleteTeam(teamID string) error {
+	args := m.Called(teamID)
+	return args.Error(0)
+}
+
+func (m *MockDatabase) Close() {
+	m.Called()
+}
+
+func remote(t *testing.T, db *MockDatabase, teamID, password string) *database.Remote {
+	ts := httptest.NewServer(NewHandler(db, request.InstanceAdmin("secret")))
+	t.Cleanup(ts.Close)
+	return database.NewRemote(ts.URL, teamID, password)
+}
+
+func TestRemote_GetByID(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	snippet := model.Snippet{ID: "1", TeamID: "team1", Title: "Sample", Tags: []string{"a"}}
+	db.On("GetByID", model.ID("1")).Return(snippet, nil)
+
+	got, err := remote(t, db, "team1", "password").GetByID("1")
+	assert.Nil(t, err)
+	assert.Equal(t, snippet, got)
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_GetSnippetsByTeamID(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	snippets := []model.Snippet{{ID: "1", TeamID: "team1", Title: "One", Content: "echo 1"}}
+	db.On("GetSnippetsByTeamID", "team1").Return(snippets, nil)
+
+	r := remote(t, db, "team1", "password")
+	got, err := r.GetSnippetsByTeamID("team1")
+	assert.Nil(t, err)
+	assert.Equal(t, "echo 1", got[0].Content)
+
+	_, err = r.GetSnippetsByTeamID("team2")
+	assert.ErrorIs(t, err, errs.ErrForbidden)
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_GetByID_OtherTeam(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	db.On("GetByID", model.ID("1")).Return(model.Snippet{ID: "1", TeamID: "team2"}, nil)
+
+	_, err := remote(t, db, "team1", "password").GetByID("1")
+	assert.EqualError(t, err, "Snippet '1' was not found")
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_WrongPassword(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "wrong").Return(model.RoleNone, nil)
+
+	_, err := remote(t, db, "team1", "wrong").GetByTeamID("team1")
+	assert.EqualError(t, err, "Incorrect password for team 'team1'")
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_CheckTeamPassword(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "admin").Return(model.RoleAdmin, nil)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+
+	correct, err := remote(t, db, "", "").CheckTeamPassword("team1", "admin", true)
+	assert.Nil(t, err)
+	assert.True(t, correct)
+
+	correct, err = remote(t, db, "", "").CheckTeamPassword("team1", "password", true)
+	assert.Nil(t, err)
+	assert.False(t, correct)
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_GetTeamByID_HidesHashes(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	db.On("GetTeamByID", "team1").Return(model.Team{Name: "team1", DisplayName: "Team", PasswordHash: "hash", AdminHash: "hash"}, nil)
+
+	team, err := remote(t, db, "team1", "password").GetTeamByID("team1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Team", team.DisplayName)
+	assert.Empty(t, team.PasswordHash)
+	assert.Empty(t, team.AdminHash)
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_UpdateTeam_NeedsAdmin(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+
+	db.On("GetTeamByID", "team1").Return(model.Team{Name: "team1"}, nil)
+
+	err := remote(t, db, "team1", "password").UpdateTeam(model.Team{Name: "team1", DisplayName: "New"})
+	assert.EqualError(t, err, "Forbidden: UpdateTeam needs the admin role, but the request has the member role")
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_UpdateTeam_KeepsHashes(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "admin").Return(model.RoleAdmin, nil)
+	db.On("GetTeamByID", "team1").Return(model.Team{Name: "team1", PasswordHash: "hash", AdminHash: "adminhash"}, nil)
+	db.On("UpdateTeam", mock.MatchedBy(func(team model.Team) bool {
+		return team.DisplayName == "New" && team.PasswordHash == "hash" && team.AdminHash == "adminhash"
+	})).Return(nil)
+
+	err := remote(t, db, "team1", "admin").UpdateTeam(model.Team{Name: "team1", DisplayName: "New"})
+	assert.Nil(t, err)
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_InsertTeam_NeedsInstanceToken(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("InsertTeam", "team1", "Team", "password", "admin").Return(nil)
+
+	err := remote(t, db, "", "").InsertTeam("team1", "Team", "password", "admin")
+	assert.ErrorContains(t, err, "Forbidden")
+
+	err = remote(t, db, "", "").WithInstanceToken("secret").InsertTeam("team1", "Team", "password", "admin")
+	assert.Nil(t, err)
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_ClearLockouts(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("ClearLockouts", "team1", "10.0.0.1").Return(nil)
+	db.On("ClearLockouts", "team1", "").Return(nil)
+
+	// the client reaches the database, an empty one clears the lockouts of all clients
+	assert.Nil(t, remote(t, db, "", "").WithInstanceToken("secret").ClearLockouts("team1", "10.0.0.1"))
+	assert.Nil(t, remote(t, db, "", "").WithInstanceToken("secret").ClearLockouts("team1", ""))
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_ReadOnly(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "readonly").Return(model.RoleReadOnly, nil)
+	db.On("GetByTeamID", "team1").Return([]model.PartialSnippet{{ID: "1", TeamID: "team1"}}, nil)
+
+	r := remote(t, db, "team1", "readonly")
+	partials, err := r.GetByTeamID("team1")
+	assert.Nil(t, err)
+	assert.Len(t, partials, 1)
+
+	_, err = r.InsertSnippet(model.Snippet{ID: "2", TeamID: "team1"})
+	assert.ErrorContains(t, err, "Forbidden")
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_Session(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	session := model.Session{Token: "token", TeamID: "team1", Role: model.RoleMember}
+	db.On("CreateSession", "team1", model.RoleMember).Return(session, nil)
+	db.On("CheckSession", "team1", "token").Return(model.RoleMember, nil)
+	db.On("GetByTeamID", "team1").Return([]model.PartialSnippet{}, nil)
+	db.On("RevokeSession", "team1", "token").Return(nil)
+
+	// the server decides about the role, not the client
+	got, err := remote(t, db, "team1", "password").CreateSession("team1", model.RoleAdmin)
+	assert.Nil(t, err)
+	assert.Equal(t, session.Token, got.Token)
+
+	r := remote(t, db, "team1", "").WithSession("token")
+	_, err = r.GetByTeamID("team1")
+	assert.Nil(t, err)
+
+	err = r.RevokeSession("team1", "token")
+	assert.Nil(t, err)
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_APIKey(t *testing.T) {
+	db := new(MockDatabase)
+	key := model.APIKey{ID: "1", TeamID: "team1", Scope: model.RoleReadOnly, Tags: []string{"ci"}, Hash: "hash"}
+	db.On("CheckAPIKey", "team1", "token").Return(key, nil)
+	db.On("GetByTeamID", "team1").Return([]model.PartialSnippet{
+		{ID: "1", TeamID: "team1", Tags: []string{"ci"}},
+		{ID: "2", TeamID: "team1", Tags: []string{"go"}},
+	}, nil)
+
+	r := remote(t, db, "team1", "").WithAPIKey("token")
+	partials, err := r.GetByTeamID("team1")
+	assert.Nil(t, err)
+	assert.Len(t, partials, 1)
+
+	_, err = r.InsertSnippet(model.Snippet{ID: "3", TeamID: "team1", Tags: []string{"ci"}})
+	assert.ErrorContains(t, err, "Forbidden")
+
+	got, err := r.CheckAPIKey("team1", "token")
+	assert.Nil(t, err)
+	assert.Empty(t, got.Hash)
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_CheckTeamRole(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleAdmin, nil).Once()
+	db.On("CheckTeamRole", "team1", "wrong").Return(model.RoleNone, nil).Once()
+
+	role, err := remote(t, db, "", "").CheckTeamRole("team1", "password")
+	assert.Nil(t, err)
+	assert.Equal(t, model.RoleAdmin, role)
+
+	role, err = remote(t, db, "", "").CheckTeamRole("team1", "wrong")
+	assert.Nil(t, err)
+	assert.Equal(t, model.RoleNone, role)
+
+	// the password is checked once per call
+	db.AssertExpectations(t)
+}
+
+func TestRemote_CheckSession_Guarded(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("GetLockouts", "team1").Return([]model.Lockout{
+		{TeamID: "team1", Client: "127.0.0.1", Failures: 5, LockedUntil: time.Now().Add(time.Hour)},
+	}, nil)
+
+	ts := httptest.NewServer(NewHandler(db, request.NewGuard(request.DefaultLockoutPolicy(), nil).Middleware()))
+	t.Cleanup(ts.Close)
+
+	_, err := database.NewRemote(ts.URL, "", "").CheckSession("team1", "token")
+	assert.ErrorContains(t, err, "Locked out")
+	_, err = database.NewRemote(ts.URL, "", "").CheckAPIKey("team1", "token")
+	assert.ErrorContains(t, err, "Locked out")
+
+	db.AssertNotCalled(t, "CheckSession", "team1", "token")
+	db.AssertNotCalled(t, "CheckAPIKey", "team1", "token")
+	db.AssertExpectations(t)
+}
+
+func TestRemote_RevokeSession_Guarded(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("GetLockouts", "team1").Return([]model.Lockout{
+		{TeamID: "team1", Client: "127.0.0.1", Failures: 5, LockedUntil: time.Now().Add(time.Hour)},
+	}, nil)
+
+	ts := httptest.NewServer(NewHandler(db, request.NewGuard(request.DefaultLockoutPolicy(), nil).Middleware()))
+	t.Cleanup(ts.Close)
+
+	// the logout is guarded for the client of the caller too
+	err := database.NewRemote(ts.URL, "", "").RevokeSession("team1", "token")
+	assert.ErrorContains(t, err, "Locked out")
+
+	db.AssertNotCalled(t, "RevokeSession", "team1", "token")
+	db.AssertExpectations(t)
+}
+
+func TestRemote_InsertSnippets(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	snippets := []model.Snippet{{ID: "1", TeamID: "team1", Title: "One"}, {ID: "2", TeamID: "team1", Title: "Two"}}
+	db.On("InsertSnippets", snippets).Return(snippets, nil)
+
+	r := remote(t, db, "team1", "password")
+	inserted, err := r.InsertSnippets(snippets)
+	assert.Nil(t, err)
+	assert.Equal(t, snippets, inserted)
+
+	// a single snippet of another team forbids the whole batch
+	_, err = r.InsertSnippets([]model.Snippet{snippets[0], {ID: "3", TeamID: "team2"}})
+	assert.ErrorIs(t, err, errs.ErrForbidden)
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_Lockout(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("GetLockouts", "team1").Return([]model.Lockout{
+		{TeamID: "team1", Client: "127.0.0.1", Failures: 5, LockedUntil: time.Now().Add(time.Hour)},
+	}, nil)
+
+	ts := httptest.NewServer(NewHandler(db, request.NewGuard(request.DefaultLockoutPolicy(), nil).Middleware()))
+	t.Cleanup(ts.Close)
+
+	// the password is not even checked while the client is locked out
+	_, err := database.NewRemote(ts.URL, "", "").CheckTeamRole("team1", "password")
+	assert.ErrorContains(t, err, "Locked out: too many failed attempts for team 'team1'")
+
+	db.AssertNotCalled(t, "CheckTeamRole", "team1", "password")
+	db.AssertExpectations(t)
+}
+
+func TestRemote_ErrorKinds(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	db.On("CheckTeamRole", "team1", "wrong").Return(model.RoleNone, nil)
+	db.On("GetByID", model.ID("1")).Return(model.Snippet{}, errs.Errorf(errs.ErrNotFound, "Snippet '1' was not found"))
+
+	// the kinds survive the round trip through the server
+	_, err := remote(t, db, "team1", "password").GetByID("1")
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	_, err = remote(t, db, "team1", "wrong").GetByID("1")
+	assert.ErrorIs(t, err, errs.ErrUnauthorized)
+	assert.ErrorContains(t, err, "Incorrect password for team 'team1'")
+
+	_, err = database.NewRemote("http://127.0.0.1:1", "team1", "password").GetByID("1")
+	assert.ErrorIs(t, err, errs.ErrUnavailable)
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_SetTeamPasswords(t *testing.T) {
+	db := new(MockDatabase)
+	db.On("CheckTeamRole", "team1", "admin").Return(model.RoleAdmin, nil)
+	passwords := model.TeamPasswords{AdminPassword: "new-admin-password"}
+	db.On("SetTeamPasswords", "team1", passwords).Return(nil)
+
+	err := remote(t, db, "team1", "admin").SetTeamPasswords("team1", passwords)
+	assert.Nil(t, err)
+
+	err = remote(t, db, "team1", "admin").SetTeamPasswords("team2", passwords)
+	assert.ErrorIs(t, err, errs.ErrForbidden)
+
+	db.AssertExpectations(t)
+}
+
+// transactionalDatabase runs dry runs on tx, or directly on the mock if there is none, and counts them.
+type transactionalDatabase struct {
+	*MockDatabase
+	tx      *MockDatabase
+	dryRuns int
+}
+
+func (t *transactionalDatabase) DryRun(fn func(db database.Database) error) error {
+	t.dryRuns++
+	if t.tx != nil {
+		return fn(t.tx)
+	}
+	return fn(t.MockDatabase)
+}
+
+func TestRemote_DryRun(t *testing.T) {
+	db := &transactionalDatabase{MockDatabase: new(MockDatabase)}
+	db.On("CheckTeamRole", "team1", "password").Return(model.RoleMember, nil)
+	db.On("GetByID", model.ID("1")).Return(model.Snippet{ID: "1", TeamID: "team1"}, nil)
+	db.On("DeleteSnippet", model.ID("1")).Return(nil)
+	ts := httptest.NewServer(NewHandler(db))
+	t.Cleanup(ts.Close)
+
+	r := database.NewRemote(ts.URL, "team1", "password")
+	err := r.DeleteSnippet("1")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, db.dryRuns)
+
+	err = r.DryRun(func(db database.Database) error {
+		return db.DeleteSnippet("1")
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, db.dryRuns)
+
+	db.AssertExpectations(t)
+}
+
+func TestRemote_DryRun_NotSupported(t *testing.T) {
+	db := new(MockDatabase)
+
+	err := remote(t, db, "team1", "password").DryRun(func(db database.Database) error {
+		return db.DeleteSnippet("1")
+	})
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+
+	db.AssertNotCalled(t, "DeleteSnippet", model.ID("1"))
+}
+
+func TestRemote_DryRun_KeepsLockouts(t *testing.T) {
+	db := &transactionalDatabase{MockDatabase: new(MockDatabase), tx: new(MockDatabase)}
+	db.tx.On("GetLockouts", "team1").Return([]model.Lockout{}, nil)
+	db.tx.On("CheckTeamRole", "team1", "wrong").Return(model.RoleNone, nil)
+	db.On("SaveLockout", mock.Anything).Return(nil)
+	ts := httptest.NewServer(NewHandler(db, request.NewGuard(request.DefaultLockoutPolicy(), nil).Middleware()))
+	t.Cleanup(ts.Close)
+
+	// failed password checks count although the rest of the dry run is rolled back
+	err := database.NewRemote(ts.URL, "team1", "wrong").DryRun(func(db database.Database) error {
+		return db.DeleteSnippet("1")
+	})
+	assert.ErrorIs(t, err, errs.ErrUnauthorized)
+
+	db.AssertNumberOfCalls(t, "SaveLockout", 2)
+	db.tx.AssertNotCalled(t, "SaveLockout", mock.Anything)
+	db.AssertExpectations(t)
+	db.tx.AssertExpectations(t)
+}
@@ -0,0 +1,117 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+)
+
+// DefaultTTL is how long a session token is valid if the config does not say otherwise.
+const DefaultTTL = 7 * 24 * time.Hour
+
+var (
+	ErrMalformed = errors.New("Session token is malformed")
+	ErrSignature = errors.New("Session token has an invalid signature")
+	ErrExpired   = errors.New("Session token has expired")
+)
+
+// Claims is the signed content of a session token.
+type Claims struct {
+	ID      string     `json:"id"`
+	TeamID  string     `json:"team"`
+	Role    model.Role `json:"role"`
+	Expires int64      `json:"exp"`
+	// Fingerprint of the team passwords when the token was issued, see Fingerprint.
+	Fingerprint string `json:"fp"`
+}
+
+// Signer issues and verifies session tokens with an HMAC key.
+type Signer struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+func NewSigner(key []byte, ttl time.Duration) *Signer {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Signer{key: key, ttl: ttl, now: time.Now}
+}
+
+// Fingerprint identifies the current passwords of a team by their generation, not by their hashes, so a rehash
+// keeps it. Tokens with another fingerprint were issued before a rotation, or for a team of the same name
+// that was deleted, and are not valid anymore.
+func Fingerprint(team model.Team) string {
+	sum := sha256.Sum256([]byte(team.Name + "\n" + team.Created.UTC().Format(time.RFC3339) + "\n" + strconv.Itoa(team.Generation)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Issue creates a signed token for the team and role.
+func (s *Signer) Issue(team model.Team, role model.Role) (model.Session, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return model.Session{}, err
+	}
+
+	expires := s.now().Add(s.ttl)
+	claims := Claims{
+		ID:          hex.EncodeToString(id),
+		TeamID:      team.Name,
+		Role:        role,
+		Expires:     expires.Unix(),
+		Fingerprint: Fingerprint(team),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return model.Session{}, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return model.Session{
+		Token:   encoded + "." + s.sign(encoded),
+		TeamID:  team.Name,
+		Role:    role,
+		Expires: time.Unix(claims.Expires, 0),
+	}, nil
+}
+
+// Parse verifies the signature and expiry of token and returns its claims.
+func (s *Signer) Parse(token string) (Claims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return Claims{}, ErrMalformed
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(encoded))) {
+		return Claims{}, ErrSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, ErrMalformed
+	}
+
+	if !s.now().Before(time.Unix(claims.Expires, 0)) {
+		return Claims{}, ErrExpired
+	}
+	return claims, nil
+}
+
+func (s *Signer) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueAndParse(t *testing.T) {
+	signer := NewSigner([]byte("key"), time.Hour)
+	team := model.Team{Name: "team1", PasswordHash: "hash", AdminHash: "adminhash"}
+
+	session, err := signer.Issue(team, model.RoleMember)
+	assert.Nil(t, err)
+	assert.Equal(t, "team1", session.TeamID)
+
+	claims, err := signer.Parse(session.Token)
+	assert.Nil(t, err)
+	assert.Equal(t, "team1", claims.TeamID)
+	assert.Equal(t, model.RoleMember, claims.Role)
+	assert.Equal(t, Fingerprint(team), claims.Fingerprint)
+}
+
+func TestParse_Expired(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	signer := NewSigner([]byte("key"), time.Hour)
+	signer.now = func() time.Time { return now }
+
+	session, err := signer.Issue(model.Team{Name: "team1"}, model.RoleMember)
+	assert.Nil(t, err)
+
+	now = now.Add(time.Hour)
+	_, err = signer.Parse(session.Token)
+	assert.ErrorIs(t, err, ErrExpired)
+}
+
+func TestParse_Tampered(t *testing.T) {
+	signer := NewSigner([]byte("key"), time.Hour)
+	session, err := signer.Issue(model.Team{Name: "team1"}, model.RoleReadOnly)
+	assert.Nil(t, err)
+
+	_, err = NewSigner([]byte("other key"), time.Hour).Parse(session.Token)
+	assert.ErrorIs(t, err, ErrSignature)
+
+	_, err = signer.Parse("x" + session.Token)
+	assert.ErrorIs(t, err, ErrSignature)
+
+	_, err = signer.Parse("no-dot")
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestFingerprint_ChangesWithRotation(t *testing.T) {
+	team := model.Team{Name: "team1", PasswordHash: "hash", AdminHash: "adminhash", Created: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	rehashed := team
+	rehashed.PasswordHash = "rehashed"
+	assert.Equal(t, Fingerprint(team), Fingerprint(rehashed))
+
+	rotated := team
+	rotated.Generation++
+	assert.NotEqual(t, Fingerprint(team), Fingerprint(rotated))
+
+	recreated := team
+	recreated.Created = team.Created.Add(time.Minute)
+	assert.NotEqual(t, Fingerprint(team), Fingerprint(recreated))
+}
@@ -0,0 +1,130 @@
+package wire
+
+import "github.com/snippetaccumulator/snac/internal/backend/model"
+
+// Prefix is the path prefix of all calls forwarded from a Remote database to a snac server.
+const Prefix = "/api/v1/"
+
+// Names of the forwarded database calls, appended to Prefix to build the request path.
+const (
+	GetByID             = "get-by-id"
+	GetByTeamID         = "get-by-team-id"
+	GetSnippetsByTeamID = "get-snippets-by-team-id"
+	InsertSnippet       = "insert-snippet"
+	InsertSnippets      = "insert-snippets"
+	UpdateSnippet       = "update-snippet"
+	DeleteSnippet       = "delete-snippet"
+	GetTeamByID         = "get-team-by-id"
+	InsertTeam          = "insert-team"
+	UpdateTeam          = "update-team"
+	DeleteTeam          = "delete-team"
+	CheckTeamPassword   = "check-team-password"
+	CheckTeamRole       = "check-team-role"
+	CreateSession       = "create-session"
+	CheckSession        = "check-session"
+	RevokeSession       = "revoke-session"
+	InsertAPIKey        = "insert-api-key"
+	GetAPIKeys          = "get-api-keys"
+	DeleteAPIKey        = "delete-api-key"
+	CheckAPIKey         = "check-api-key"
+	GetLockouts         = "get-lockouts"
+	ClearLockouts       = "clear-lockouts"
+	SetTeamPasswords    = "set-team-passwords"
+)
+
+// TeamHeader names the team of a call authenticated with a session token as bearer token,
+// or with an API key in the Authorization header with the APIKeyScheme.
+const TeamHeader = "X-Snac-Team"
+
+// InstanceTokenHeader carries the token that grants the instance-admin role, e.g. to create teams.
+const InstanceTokenHeader = "X-Snac-Instance-Token"
+
+type IDArgs struct {
+	ID model.ID `json:"id"`
+}
+
+type TeamIDArgs struct {
+	TeamID string `json:"team_id"`
+}
+
+type SnippetArgs struct {
+	Snippet model.Snippet `json:"snippet"`
+}
+
+type SnippetsArgs struct {
+	Snippets []model.Snippet `json:"snippets"`
+}
+
+type TeamArgs struct {
+	Team model.Team `json:"team"`
+}
+
+type InsertTeamArgs struct {
+	TeamID        string `json:"team_id"`
+	DisplayName   string `json:"display_name"`
+	Password      string `json:"password"`
+	AdminPassword string `json:"admin_password"`
+}
+
+type CheckTeamPasswordArgs struct {
+	TeamID   string `json:"team_id"`
+	Password string `json:"password"`
+	Admin    bool   `json:"admin"`
+}
+
+type CheckTeamPasswordResult struct {
+	Correct bool `json:"correct"`
+}
+
+type CheckTeamRoleArgs struct {
+	TeamID   string `json:"team_id"`
+	Password string `json:"password"`
+}
+
+type CheckTeamRoleResult struct {
+	Role model.Role `json:"role"`
+}
+
+type CreateSessionArgs struct {
+	TeamID string     `json:"team_id"`
+	Role   model.Role `json:"role"`
+}
+
+type SessionArgs struct {
+	TeamID string `json:"team_id"`
+	Token  string `json:"token"`
+}
+
+type APIKeyArgs struct {
+	Key model.APIKey `json:"key"`
+}
+
+type APIKeyIDArgs struct {
+	TeamID string `json:"team_id"`
+	ID     string `json:"id"`
+}
+
+type CheckAPIKeyArgs struct {
+	TeamID string `json:"team_id"`
+	Token  string `json:"token"`
+}
+
+type LockoutArgs struct {
+	TeamID string `json:"team_id"`
+	Client string `json:"client"`
+}
+
+type SetTeamPasswordsArgs struct {
+	TeamID    string              `json:"team_id"`
+	Passwords model.TeamPasswords `json:"passwords"`
+}
+
+// Error is the body of every non 2xx response.
+type Error struct {
+	Error string `json:"error"`
+}
+
+const APIKeyScheme = "ApiKey "
+
+// DryRunHeader makes the server discard the changes of a call, see database.Transactional.
+const DryRunHeader = "X-Snac-Dry-Run"
@@ -0,0 +1,266 @@
+// Package backup writes the snippets of a team into an archive and reads them back from it.
+//
+// An archive is a gzipped tar file with a manifest.json describing the team and every snippet,
+// and one snippets/<ID>.json file per snippet. The manifest has the SHA-256 checksum of every snippet file,
+// so an archive that was changed or damaged is not restored.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+)
+
+const (
+	// Format names archives of snac in their manifest.
+	Format = "snac-backup"
+	// Version is the version of the archive layout that is written, archives of newer versions cannot be read.
+	Version = 1
+
+	manifestFile = "manifest.json"
+	snippetsDir  = "snippets"
+)
+
+// maxSize is the most an archive may hold once decompressed, 512 MiB. Read refuses larger ones
+// before they fill the memory, e.g. a small file that decompresses to gigabytes.
+var maxSize int64 = 512 << 20
+
+// Manifest describes an archive. Its field names are kept, so older versions of snac can tell an archive they cannot read.
+type Manifest struct {
+	Format   string    `json:"format"`
+	Version  int       `json:"version"`
+	Created  time.Time `json:"created"`
+	Team     Team      `json:"team"`
+	Snippets []Entry   `json:"snippets"`
+}
+
+// Team is the metadata of the backed up team, its passwords are not part of an archive.
+type Team struct {
+	Name         string    `json:"name"`
+	DisplayName  string    `json:"display_name"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// Entry is a snippet in the manifest, File is its path in the archive.
+type Entry struct {
+	ID     model.ID `json:"id"`
+	Title  string   `json:"title"`
+	File   string   `json:"file"`
+	SHA256 string   `json:"sha256"`
+}
+
+// snippetFile is a snippet as it is stored in an archive, with the field names of the output formats.
+type snippetFile struct {
+	ID           model.ID  `json:"id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	Language     string    `json:"language"`
+	Tags         []string  `json:"tags"`
+	Content      string    `json:"content"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// Archive is what an archive holds, its snippets keep the team they were backed up from.
+type Archive struct {
+	Manifest Manifest
+	Snippets []model.Snippet
+}
+
+// Write writes an archive of the team and its snippets to w.
+func Write(w io.Writer, team model.Team, snippets []model.Snippet, created time.Time) error {
+	manifest := Manifest{
+		Format:  Format,
+		Version: Version,
+		Created: created.UTC(),
+		Team: Team{
+			Name:         team.Name,
+			DisplayName:  team.DisplayName,
+			Created:      team.Created.UTC(),
+			LastModified: team.LastModified.UTC(),
+		},
+		Snippets: []Entry{},
+	}
+	files := make([][]byte, len(snippets))
+	for i, snippet := range snippets {
+		data, err := json.MarshalIndent(snippetFile{
+			ID:           snippet.ID,
+			Title:        snippet.Title,
+			Description:  snippet.Description,
+			Language:     snippet.Language,
+			Tags:         snippet.Tags,
+			Content:      snippet.Content,
+			LastModified: snippet.LastModified.UTC(),
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		files[i] = data
+		manifest.Snippets = append(manifest.Snippets, Entry{
+			ID:     snippet.ID,
+			Title:  snippet.Title,
+			File:   path.Join(snippetsDir, string(snippet.ID)+".json"),
+			SHA256: checksum(data),
+		})
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := writeFile(tw, manifestFile, manifestData, manifest.Created); err != nil {
+		return err
+	}
+	for i, entry := range manifest.Snippets {
+		if err := writeFile(tw, entry.File, files[i], manifest.Created); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeFile(tw *tar.Writer, name string, data []byte, modified time.Time) error {
+	err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     int64(len(data)),
+		Mode:     0644,
+		ModTime:  modified,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	return err
+}
+
+// Read reads an archive from r and verifies it. It returns an ErrInvalid error if it is not an archive of snac,
+// of a newer version, or if a snippet file is missing or does not match its checksum.
+func Read(r io.Reader) (Archive, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return Archive{}, errs.Errorf(errs.ErrInvalid, "Not a snac backup: %w", err)
+	}
+	tr := tar.NewReader(&capped{r: gz, left: maxSize})
+	files := map[string][]byte{}
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, errTooLarge) {
+			return Archive{}, tooLarge()
+		}
+		if err != nil {
+			return Archive{}, errs.Errorf(errs.ErrInvalid, "Not a snac backup: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Size > maxSize {
+			return Archive{}, tooLarge()
+		}
+		var data bytes.Buffer
+		if _, err := io.Copy(&data, tr); err != nil {
+			if errors.Is(err, errTooLarge) {
+				return Archive{}, tooLarge()
+			}
+			return Archive{}, errs.Errorf(errs.ErrInvalid, "Could not read %s of the backup: %w", header.Name, err)
+		}
+		files[path.Clean(header.Name)] = data.Bytes()
+	}
+
+	var archive Archive
+	manifestData, ok := files[manifestFile]
+	if !ok {
+		return Archive{}, errs.New(errs.ErrInvalid, "Not a snac backup: it has no "+manifestFile)
+	}
+	if err := json.Unmarshal(manifestData, &archive.Manifest); err != nil {
+		return Archive{}, errs.Errorf(errs.ErrInvalid, "Not a snac backup: %s is invalid: %w", manifestFile, err)
+	}
+	manifest := archive.Manifest
+	if manifest.Format != Format {
+		return Archive{}, errs.Errorf(errs.ErrInvalid, "Not a snac backup: the format is '%s'", manifest.Format)
+	}
+	if manifest.Version < 1 || manifest.Version > Version {
+		return Archive{}, errs.Errorf(errs.ErrInvalid, "The backup has version %d, this snac reads versions up to %d", manifest.Version, Version)
+	}
+
+	seen := map[model.ID]bool{}
+	for _, entry := range manifest.Snippets {
+		data, ok := files[path.Clean(entry.File)]
+		if !ok {
+			return Archive{}, errs.Errorf(errs.ErrInvalid, "The backup is missing %s of snippet %s", entry.File, entry.ID)
+		}
+		if checksum(data) != entry.SHA256 {
+			return Archive{}, errs.Errorf(errs.ErrInvalid, "The checksum of %s does not match, the backup is damaged", entry.File)
+		}
+		var file snippetFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return Archive{}, errs.Errorf(errs.ErrInvalid, "%s of the backup is invalid: %w", entry.File, err)
+		}
+		if file.ID != entry.ID || seen[file.ID] {
+			return Archive{}, errs.Errorf(errs.ErrInvalid, "%s of the backup has the ID %s, the manifest expects %s once", entry.File, file.ID, entry.ID)
+		}
+		seen[file.ID] = true
+		archive.Snippets = append(archive.Snippets, model.Snippet{
+			ID:           file.ID,
+			TeamID:       manifest.Team.Name,
+			Title:        file.Title,
+			Description:  file.Description,
+			Language:     file.Language,
+			Tags:         file.Tags,
+			Content:      file.Content,
+			LastModified: file.LastModified,
+		})
+	}
+	return archive, nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+var errTooLarge = errors.New("too large")
+
+func tooLarge() error {
+	return errs.Errorf(errs.ErrInvalid, "The backup holds more than %d MiB once decompressed, which is not a backup of snac", maxSize>>20)
+}
+
+// capped reads up to left bytes from r and fails with errTooLarge if r has more.
+type capped struct {
+	r    io.Reader
+	left int64
+}
+
+func (c *capped) Read(p []byte) (int, error) {
+	if c.left <= 0 {
+		// only the end of r is fine at the cap
+		if n, err := c.r.Read(make([]byte, 1)); n == 0 && errors.Is(err, io.EOF) {
+			return 0, io.EOF
+		}
+		return 0, errTooLarge
+	}
+	if int64(len(p)) > c.left {
+		p = p[:c.left]
+	}
+	n, err := c.r.Read(p)
+	c.left -= int64(n)
+	return n, err
+}
@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	created = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	team    = model.Team{Name: "ops", DisplayName: "Operations", Created: created.Add(-time.Hour), LastModified: created, PasswordHash: "secret"}
+)
+
+func testSnippets() []model.Snippet {
+	return []model.Snippet{
+		{ID: "AB3CD", TeamID: "ops", Title: "Restart", Language: "bash", Tags: []string{"k8s"}, Content: "kubectl rollout restart ${name}\n", LastModified: created},
+		{ID: "EF4GH", TeamID: "ops", Title: "Users", Description: "Active users", Language: "sql", Content: "SELECT * FROM users", LastModified: created},
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.Nil(t, Write(&buffer, team, testSnippets(), created))
+	assert.NotContains(t, buffer.String(), "secret")
+
+	archive, err := Read(&buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, Format, archive.Manifest.Format)
+	assert.Equal(t, Version, archive.Manifest.Version)
+	assert.Equal(t, created, archive.Manifest.Created)
+	assert.Equal(t, Team{Name: "ops", DisplayName: "Operations", Created: created.Add(-time.Hour), LastModified: created}, archive.Manifest.Team)
+	assert.Equal(t, "snippets/AB3CD.json", archive.Manifest.Snippets[0].File)
+	assert.Len(t, archive.Manifest.Snippets[0].SHA256, 64)
+	assert.Equal(t, testSnippets(), archive.Snippets)
+
+	// a team without snippets
+	buffer.Reset()
+	assert.Nil(t, Write(&buffer, team, nil, created))
+	archive, err = Read(&buffer)
+	assert.Nil(t, err)
+	assert.Empty(t, archive.Snippets)
+}
+
+// rewrite copies the files of an archive, passing them through change, which returns nil to drop a file.
+func rewrite(t *testing.T, archive []byte, change func(name string, data []byte) []byte) io.Reader {
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	assert.Nil(t, err)
+	tr := tar.NewReader(gr)
+
+	var result bytes.Buffer
+	gw := gzip.NewWriter(&result)
+	tw := tar.NewWriter(gw)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		data, err := io.ReadAll(tr)
+		assert.Nil(t, err)
+		if data = change(header.Name, data); data == nil {
+			continue
+		}
+		header.Size = int64(len(data))
+		assert.Nil(t, tw.WriteHeader(header))
+		_, err = tw.Write(data)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gw.Close())
+	return &result
+}
+
+func TestReadInvalid(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.Nil(t, Write(&buffer, team, testSnippets(), created))
+	archive := buffer.Bytes()
+
+	for name, test := range map[string]struct {
+		change func(name string, data []byte) []byte
+		err    string
+	}{
+		"changed snippet": {func(name string, data []byte) []byte {
+			return bytes.Replace(data, []byte("users"), []byte("admins"), 1)
+		}, "checksum of snippets/EF4GH.json does not match"},
+		"missing snippet": {func(name string, data []byte) []byte {
+			if name == "snippets/AB3CD.json" {
+				return nil
+			}
+			return data
+		}, "missing snippets/AB3CD.json"},
+		"newer version": {func(name string, data []byte) []byte {
+			return bytes.Replace(data, []byte(`"version": 1`), []byte(`"version": 2`), 1)
+		}, "version 2"},
+		"other format": {func(name string, data []byte) []byte {
+			return bytes.Replace(data, []byte(`"snac-backup"`), []byte(`"other"`), 1)
+		}, "the format is 'other'"},
+		"no manifest": {func(name string, data []byte) []byte {
+			if name == "manifest.json" {
+				return nil
+			}
+			return data
+		}, "no manifest.json"},
+	} {
+		_, err := Read(rewrite(t, archive, test.change))
+		assert.ErrorIs(t, err, errs.ErrInvalid, name)
+		assert.ErrorContains(t, err, test.err, name)
+	}
+
+	_, err := Read(strings.NewReader("not gzip"))
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+}
+
+func TestReadTooLarge(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.Nil(t, Write(&buffer, team, testSnippets(), created))
+	archive := buffer.Bytes()
+
+	defer func(size int64) { maxSize = size }(maxSize)
+	maxSize = 1 << 20
+	_, err := Read(bytes.NewReader(archive))
+	assert.Nil(t, err)
+
+	// archives that decompress to more than the cap are refused without reading all of them,
+	// whether a single file is too large or all of them together
+	for name, grow := range map[string]func(name string, data []byte) []byte{
+		"single file": func(name string, data []byte) []byte {
+			if name == "manifest.json" {
+				return append(data, bytes.Repeat([]byte(" "), 2<<20)...)
+			}
+			return data
+		},
+		"all files": func(name string, data []byte) []byte {
+			return append(data, bytes.Repeat([]byte(" "), 600<<10)...)
+		},
+	} {
+		_, err = Read(rewrite(t, archive, grow))
+		assert.ErrorIs(t, err, errs.ErrInvalid, name)
+		assert.ErrorContains(t, err, "more than 1 MiB once decompressed", name)
+	}
+}
@@ -0,0 +1,61 @@
+package backup
+
+import (
+	"slices"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+)
+
+// Plan is how the snippets of an archive are restored into a team.
+type Plan struct {
+	// Insert are the snippets to insert, already moved to the team.
+	Insert []model.Snippet
+	// Remapped has the new IDs of snippets whose ID is taken by another snippet.
+	Remapped map[model.ID]model.ID
+	// Unchanged are the snippets the team has already, as they are in the archive.
+	Unchanged []model.ID
+}
+
+// Merge plans to restore the snippets into team, which has the existing snippets.
+// Snippets that are there already are left out. Those whose ID is taken, by another snippet of the team
+// or as reported by taken, e.g. by a snippet of another team, get a new ID.
+func Merge(snippets []model.Snippet, team string, existing []model.Snippet, taken func(id model.ID) bool) Plan {
+	plan := Plan{Remapped: map[model.ID]model.ID{}}
+	byID := map[model.ID]model.Snippet{}
+	used := map[model.ID]bool{}
+	for _, snippet := range existing {
+		byID[snippet.ID] = snippet
+		used[snippet.ID] = true
+	}
+	for _, snippet := range snippets {
+		used[snippet.ID] = true
+	}
+
+	for _, snippet := range snippets {
+		snippet.TeamID = team
+		if current, ok := byID[snippet.ID]; ok && same(current, snippet) {
+			plan.Unchanged = append(plan.Unchanged, snippet.ID)
+			continue
+		}
+		if _, ok := byID[snippet.ID]; ok || taken(snippet.ID) {
+			id := model.NewID()
+			for used[id] || taken(id) {
+				id = model.NewID()
+			}
+			used[id] = true
+			plan.Remapped[snippet.ID] = id
+			snippet.ID = id
+		}
+		plan.Insert = append(plan.Insert, snippet)
+	}
+	return plan
+}
+
+// same reports if two snippets have the same fields, apart from when they were last modified.
+func same(a, b model.Snippet) bool {
+	return a.Title == b.Title &&
+		a.Description == b.Description &&
+		a.Language == b.Language &&
+		a.Content == b.Content &&
+		slices.Equal(a.Tags, b.Tags)
+}
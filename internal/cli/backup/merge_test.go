@@ -0,0 +1,46 @@
+package backup
+
+import (
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	archived := []model.Snippet{
+		{ID: "AB3CD", TeamID: "ops", Title: "Same", Content: "echo same"},
+		{ID: "EF4GH", TeamID: "ops", Title: "Changed", Content: "echo new"},
+		{ID: "JK5LM", TeamID: "ops", Title: "Elsewhere", Content: "echo elsewhere"},
+		{ID: "NP6QR", TeamID: "ops", Title: "New", Content: "echo new"},
+	}
+	existing := []model.Snippet{
+		{ID: "AB3CD", TeamID: "dev", Title: "Same", Tags: []string{}, Content: "echo same"},
+		{ID: "EF4GH", TeamID: "dev", Title: "Changed", Content: "echo old"},
+	}
+	taken := func(id model.ID) bool {
+		return id == "JK5LM"
+	}
+
+	plan := Merge(archived, "dev", existing, taken)
+	assert.Equal(t, []model.ID{"AB3CD"}, plan.Unchanged)
+	assert.Len(t, plan.Remapped, 2)
+	assert.Len(t, plan.Insert, 3)
+
+	for i, old := range []model.ID{"EF4GH", "JK5LM"} {
+		id := plan.Remapped[old]
+		assert.Equal(t, id, plan.Insert[i].ID)
+		assert.NotContains(t, []model.ID{"AB3CD", "EF4GH", "JK5LM", "NP6QR"}, id)
+	}
+	assert.Equal(t, "Changed", plan.Insert[0].Title)
+	assert.Equal(t, model.ID("NP6QR"), plan.Insert[2].ID)
+	for _, snippet := range plan.Insert {
+		assert.Equal(t, "dev", snippet.TeamID)
+	}
+
+	// a team without the snippets gets all of them as they are
+	plan = Merge(archived, "ops", nil, func(model.ID) bool { return false })
+	assert.Empty(t, plan.Unchanged)
+	assert.Empty(t, plan.Remapped)
+	assert.Equal(t, archived, plan.Insert)
+}
@@ -0,0 +1,436 @@
+// Package browse is the full-screen snippet browser of the CLI.
+package browse
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/snippetaccumulator/snac/internal/backend/database"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/backend/request"
+	"github.com/snippetaccumulator/snac/internal/cli/render"
+)
+
+// Config is what the browser needs from the CLI. Everything it does goes through requests built by Requests.
+type Config struct {
+	Team     string
+	Requests func() *request.RequestBuilder
+	Pipeline *request.Pipeline
+	DB       database.Database
+	// Copy puts content on the clipboard.
+	Copy func(content string) error
+	// Editor is the command snippets are edited with, it may come with arguments.
+	Editor string
+	// Style is the chroma style of the preview.
+	Style string
+}
+
+// Run shows the browser until the user quits it.
+func Run(config Config) error {
+	_, err := tea.NewProgram(New(config), tea.WithAltScreen()).Run()
+	return err
+}
+
+type mode int
+
+const (
+	modeBrowse mode = iota
+	modeRetag
+	modeTitle
+	modeTags
+	modeConfirmDelete
+)
+
+// item is a snippet in the list, it is filtered by title and tags.
+type item struct {
+	partial model.PartialSnippet
+}
+
+func (i item) Title() string { return i.partial.Title }
+
+func (i item) Description() string {
+	details := []string{i.partial.ID.String()}
+	if i.partial.Language != "" {
+		details = append(details, i.partial.Language)
+	}
+	if len(i.partial.Tags) > 0 {
+		details = append(details, strings.Join(i.partial.Tags, ", "))
+	}
+	return strings.Join(details, " · ")
+}
+
+func (i item) FilterValue() string {
+	return i.partial.Title + " " + strings.Join(i.partial.Tags, " ")
+}
+
+var (
+	listStyle    = lipgloss.NewStyle().Border(lipgloss.NormalBorder(), false, true, false, false).PaddingRight(1)
+	previewStyle = lipgloss.NewStyle().PaddingLeft(1)
+	titleStyle   = lipgloss.NewStyle().Bold(true)
+	dimStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	statusStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("230")).Background(lipgloss.Color("62")).Padding(0, 1)
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+)
+
+type Model struct {
+	config  Config
+	list    list.Model
+	preview viewport.Model
+	input   textinput.Model
+	mode    mode
+
+	// snippets caches the full snippets shown in the preview, it is cleared on every change.
+	snippets map[model.ID]model.Snippet
+	loading  model.ID
+	// draft is the snippet being created.
+	draft model.Snippet
+
+	syncing bool
+	synced  time.Time
+	status  string
+	err     error
+
+	width, height int
+}
+
+func New(config Config) Model {
+	l := list.New(nil, list.NewDefaultDelegate(), 0, 0)
+	l.Title = "Snippets of " + config.Team
+	l.SetShowHelp(false)
+	l.SetShowStatusBar(false)
+	l.SetStatusBarItemName("snippet", "snippets")
+	l.DisableQuitKeybindings()
+
+	return Model{
+		config:   config,
+		list:     l,
+		preview:  viewport.New(0, 0),
+		input:    textinput.New(),
+		snippets: map[model.ID]model.Snippet{},
+		syncing:  true,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return m.listSnippets()
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+		m.showPreview()
+		return m, nil
+	case listMsg:
+		m.syncing = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.synced = time.Now()
+		sort.Slice(msg.partials, func(i, j int) bool {
+			return strings.ToLower(msg.partials[i].Title) < strings.ToLower(msg.partials[j].Title)
+		})
+		items := make([]list.Item, len(msg.partials))
+		for i, partial := range msg.partials {
+			items[i] = item{partial: partial}
+		}
+		cmd := m.list.SetItems(items)
+		load := m.loadSelected()
+		return m, tea.Batch(cmd, load)
+	case snippetMsg:
+		m.loading = ""
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.snippets[msg.snippet.ID] = msg.snippet
+		m.showPreview()
+		return m, nil
+	case doneMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.err, m.status = nil, msg.status
+		m.snippets = map[model.ID]model.Snippet{}
+		m.syncing = true
+		return m, m.listSnippets()
+	case editedMsg:
+		return m.edited(msg)
+	case tea.KeyMsg:
+		if m.mode != modeBrowse {
+			return m.updateInput(msg)
+		}
+		if !m.list.SettingFilter() {
+			if cmd, handled := m.handleKey(msg); handled {
+				return m, cmd
+			}
+		}
+	}
+
+	selected := m.selectedID()
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	if m.selectedID() != selected {
+		m.preview.GotoTop()
+		load := m.loadSelected()
+		return m, tea.Batch(cmd, load)
+	}
+	return m, cmd
+}
+
+// handleKey runs the actions on the selected snippet, it returns false for keys of the list.
+func (m *Model) handleKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, keys.Quit):
+		return tea.Quit, true
+	case key.Matches(msg, keys.Reload):
+		m.syncing = true
+		m.snippets = map[model.ID]model.Snippet{}
+		return m.listSnippets(), true
+	case key.Matches(msg, keys.Create):
+		m.draft = model.Snippet{}
+		m.startInput(modeTitle, "Title: ", "")
+		return textinput.Blink, true
+	case key.Matches(msg, keys.Scroll):
+		if msg.String() == "ctrl+d" {
+			m.preview.HalfViewDown()
+		} else {
+			m.preview.HalfViewUp()
+		}
+		return nil, true
+	}
+
+	var action func(snippet model.Snippet) tea.Cmd
+	switch {
+	case key.Matches(msg, keys.Copy):
+		action = m.copy
+	case key.Matches(msg, keys.Edit):
+		action = func(snippet model.Snippet) tea.Cmd {
+			m.status = "Editing " + snippet.ID.String()
+			return m.edit(snippet)
+		}
+	case key.Matches(msg, keys.Retag):
+		action = func(snippet model.Snippet) tea.Cmd {
+			m.startInput(modeRetag, "Tags: ", strings.Join(snippet.Tags, ", "))
+			return textinput.Blink
+		}
+	case key.Matches(msg, keys.Delete):
+		action = func(snippet model.Snippet) tea.Cmd {
+			m.startInput(modeConfirmDelete, fmt.Sprintf("Delete '%s'? (y/n) ", snippet.Title), "")
+			return textinput.Blink
+		}
+	default:
+		return nil, false
+	}
+
+	snippet, ok := m.snippets[m.selectedID()]
+	if !ok {
+		m.status = "No snippet selected"
+		if m.loading != "" {
+			m.status = "Still loading the snippet"
+		}
+		return nil, true
+	}
+	return action(snippet), true
+}
+
+func (m *Model) startInput(mode mode, prompt, value string) {
+	m.mode = mode
+	m.input.Prompt = prompt
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	m.input.Focus()
+	m.status, m.err = "", nil
+}
+
+func (m Model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.mode = modeBrowse
+		m.input.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		return m.submitInput()
+	}
+	if m.mode == modeConfirmDelete {
+		switch msg.String() {
+		case "y", "Y":
+			m.mode = modeBrowse
+			return m, m.delete(m.snippets[m.selectedID()])
+		case "n", "N":
+			m.mode = modeBrowse
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m Model) submitInput() (tea.Model, tea.Cmd) {
+	value := strings.TrimSpace(m.input.Value())
+	mode := m.mode
+	m.mode = modeBrowse
+	m.input.Blur()
+
+	switch mode {
+	case modeRetag:
+		snippet := m.snippets[m.selectedID()]
+		snippet.Tags = splitTags(value)
+		if len(snippet.Tags) == 0 {
+			m.err = fmt.Errorf("A snippet needs at least one tag")
+			return m, nil
+		}
+		return m, m.update(snippet, "Updated tags of "+snippet.ID.String())
+	case modeTitle:
+		if value == "" {
+			m.status = "Cancelled, a snippet needs a title"
+			return m, nil
+		}
+		m.draft.Title = value
+		m.startInput(modeTags, "Tags: ", "")
+		return m, textinput.Blink
+	case modeTags:
+		m.draft.Tags = splitTags(value)
+		if len(m.draft.Tags) == 0 {
+			m.status = "Cancelled, a snippet needs at least one tag"
+			return m, nil
+		}
+		return m, m.edit(m.draft)
+	}
+	return m, nil
+}
+
+// edited creates or updates the snippet with the content saved in the editor.
+func (m Model) edited(msg editedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		return m, nil
+	}
+	if msg.snippet.ID == "" {
+		snippet := model.NewSnippetBuilder(msg.snippet.Title, m.config.Team).
+			WithTags(msg.snippet.Tags).
+			WithContent(msg.content).
+			Build()
+		return m, m.insert(snippet)
+	}
+	if msg.content == msg.snippet.Content {
+		m.status = "No changes to " + msg.snippet.ID.String()
+		return m, nil
+	}
+	snippet := msg.snippet
+	snippet.Content = msg.content
+	return m, m.update(snippet, "Updated snippet "+snippet.ID.String())
+}
+
+func (m Model) selectedID() model.ID {
+	selected, ok := m.list.SelectedItem().(item)
+	if !ok {
+		return ""
+	}
+	return selected.partial.ID
+}
+
+// loadSelected loads the selected snippet for the preview unless it is loaded already.
+func (m *Model) loadSelected() tea.Cmd {
+	id := m.selectedID()
+	if _, ok := m.snippets[id]; ok || id == "" || id == m.loading {
+		m.showPreview()
+		return nil
+	}
+	m.loading = id
+	m.showPreview()
+	return m.get(id)
+}
+
+func (m *Model) resize() {
+	listWidth := m.width * 2 / 5
+	height := m.height - 1
+	m.list.SetSize(listWidth, height)
+	m.preview.Width = m.width - listWidth - listStyle.GetHorizontalFrameSize() - previewStyle.GetHorizontalFrameSize()
+	m.preview.Height = height
+}
+
+func (m *Model) showPreview() {
+	id := m.selectedID()
+	snippet, ok := m.snippets[id]
+	switch {
+	case id == "":
+		m.preview.SetContent(dimStyle.Render("No snippets, press n to create one"))
+	case !ok:
+		m.preview.SetContent(dimStyle.Render("Loading..."))
+	default:
+		m.preview.SetContent(m.renderSnippet(snippet))
+	}
+}
+
+func (m Model) renderSnippet(snippet model.Snippet) string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(snippet.Title) + "\n")
+	if snippet.Description != "" {
+		b.WriteString(lipgloss.NewStyle().Width(m.preview.Width).Render(snippet.Description) + "\n")
+	}
+	b.WriteString(dimStyle.Render(item{partial: model.PartialSnippet{ID: snippet.ID, Language: snippet.Language, Tags: snippet.Tags}}.Description()) + "\n\n")
+
+	content, err := render.Highlight(snippet.Content, snippet.Language, m.config.Style)
+	if err != nil {
+		content = snippet.Content
+	}
+	b.WriteString(content)
+	return b.String()
+}
+
+func (m Model) View() string {
+	main := lipgloss.JoinHorizontal(lipgloss.Top,
+		listStyle.Render(m.list.View()),
+		previewStyle.Render(m.preview.View()),
+	)
+	return lipgloss.JoinVertical(lipgloss.Left, main, m.statusBar())
+}
+
+func (m Model) statusBar() string {
+	if m.mode != modeBrowse {
+		return m.input.View()
+	}
+
+	sync := "synced " + m.synced.Format("15:04:05")
+	if m.syncing {
+		sync = "syncing..."
+	}
+	left := statusStyle.Render(fmt.Sprintf("%s · %d snippets · %s", m.config.Team, len(m.list.Items()), sync))
+
+	message := dimStyle.Render(m.status)
+	if m.err != nil {
+		message = errorStyle.Render(m.err.Error())
+	}
+	if m.status == "" && m.err == nil {
+		var help []string
+		for _, binding := range keys.help() {
+			help = append(help, binding.Help().Key+" "+binding.Help().Desc)
+		}
+		message = dimStyle.Render("/ filter · " + strings.Join(help, " · "))
+	}
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(left + " " + message)
+}
+
+func splitTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,61 @@
+package browse
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func update(t *testing.T, m Model, msg tea.Msg) Model {
+	t.Helper()
+	updated, _ := m.Update(msg)
+	return updated.(Model)
+}
+
+func TestBrowse_ListAndPreview(t *testing.T) {
+	m := New(Config{Team: "team1"})
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = update(t, m, listMsg{partials: []model.PartialSnippet{
+		{ID: "B", Title: "second", Tags: []string{"ops"}},
+		{ID: "A", Title: "first", Tags: []string{"go"}, Language: "go"},
+	}})
+
+	assert.False(t, m.syncing)
+	assert.Equal(t, model.ID("A"), m.selectedID())
+	assert.Equal(t, model.ID("A"), m.loading)
+	assert.Contains(t, m.View(), "Loading...")
+
+	m = update(t, m, snippetMsg{snippet: model.Snippet{ID: "A", Title: "first", Tags: []string{"go"}, Language: "go", Content: "package main"}})
+	assert.Equal(t, model.ID(""), m.loading)
+	assert.Contains(t, m.View(), "first")
+	assert.Contains(t, m.View(), "team1 · 2 snippets · synced")
+}
+
+func TestBrowse_Retag(t *testing.T) {
+	m := New(Config{Team: "team1"})
+	m = update(t, m, tea.WindowSizeMsg{Width: 100, Height: 30})
+	m = update(t, m, listMsg{partials: []model.PartialSnippet{{ID: "A", Title: "first", Tags: []string{"go"}}}})
+	m = update(t, m, snippetMsg{snippet: model.Snippet{ID: "A", Title: "first", Tags: []string{"go"}}})
+
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	assert.Equal(t, modeRetag, m.mode)
+	assert.Equal(t, "go", m.input.Value())
+
+	// a snippet keeps at least one tag
+	m.input.SetValue(" , ")
+	m = update(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, modeBrowse, m.mode)
+	assert.EqualError(t, m.err, "A snippet needs at least one tag")
+}
+
+func TestBrowse_DoneReloads(t *testing.T) {
+	m := New(Config{Team: "team1"})
+	m.snippets["A"] = model.Snippet{ID: "A"}
+
+	m = update(t, m, doneMsg{status: "Deleted snippet A"})
+	assert.True(t, m.syncing)
+	assert.Empty(t, m.snippets)
+	assert.Equal(t, "Deleted snippet A", m.status)
+}
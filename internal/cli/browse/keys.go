@@ -0,0 +1,29 @@
+package browse
+
+import "github.com/charmbracelet/bubbles/key"
+
+type keyMap struct {
+	Copy   key.Binding
+	Edit   key.Binding
+	Delete key.Binding
+	Retag  key.Binding
+	Create key.Binding
+	Reload key.Binding
+	Scroll key.Binding
+	Quit   key.Binding
+}
+
+var keys = keyMap{
+	Copy:   key.NewBinding(key.WithKeys("c", "y"), key.WithHelp("c", "copy")),
+	Edit:   key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit")),
+	Delete: key.NewBinding(key.WithKeys("d", "delete"), key.WithHelp("d", "delete")),
+	Retag:  key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "tags")),
+	Create: key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "new")),
+	Reload: key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "reload")),
+	Scroll: key.NewBinding(key.WithKeys("ctrl+d", "ctrl+u"), key.WithHelp("ctrl+d/u", "scroll")),
+	Quit:   key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+}
+
+func (k keyMap) help() []key.Binding {
+	return []key.Binding{k.Copy, k.Edit, k.Retag, k.Delete, k.Create, k.Reload, k.Quit}
+}
@@ -0,0 +1,103 @@
+package browse
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/lang"
+)
+
+// listMsg carries the snippets of the team.
+type listMsg struct {
+	partials []model.PartialSnippet
+	err      error
+}
+
+// snippetMsg carries a snippet that was loaded for the preview.
+type snippetMsg struct {
+	snippet model.Snippet
+	err     error
+}
+
+// doneMsg reports a finished change, the list is reloaded afterwards if it succeeded.
+type doneMsg struct {
+	status string
+	err    error
+}
+
+// editedMsg carries the content saved in the editor for a snippet.
+type editedMsg struct {
+	snippet model.Snippet
+	content string
+	err     error
+}
+
+func (m Model) listSnippets() tea.Cmd {
+	return func() tea.Msg {
+		partials, err := m.config.Requests().BuildGetAllPartials().ExecuteWith(m.config.Pipeline, m.config.DB)
+		return listMsg{partials: partials, err: err}
+	}
+}
+
+func (m Model) get(id model.ID) tea.Cmd {
+	return func() tea.Msg {
+		snippet, err := m.config.Requests().BuildGet(id).ExecuteWith(m.config.Pipeline, m.config.DB)
+		return snippetMsg{snippet: snippet, err: err}
+	}
+}
+
+func (m Model) insert(snippet model.Snippet) tea.Cmd {
+	return func() tea.Msg {
+		created, err := m.config.Requests().BuildInsert(snippet).ExecuteWith(m.config.Pipeline, m.config.DB)
+		return doneMsg{status: "Created snippet " + created.ID.String(), err: err}
+	}
+}
+
+func (m Model) update(snippet model.Snippet, status string) tea.Cmd {
+	return func() tea.Msg {
+		_, err := m.config.Requests().BuildUpdate(snippet).ExecuteWith(m.config.Pipeline, m.config.DB)
+		return doneMsg{status: status, err: err}
+	}
+}
+
+func (m Model) delete(snippet model.Snippet) tea.Cmd {
+	return func() tea.Msg {
+		_, err := m.config.Requests().BuildDelete(snippet.ID).ExecuteWith(m.config.Pipeline, m.config.DB)
+		return doneMsg{status: "Deleted snippet " + snippet.ID.String(), err: err}
+	}
+}
+
+func (m Model) copy(snippet model.Snippet) tea.Cmd {
+	return func() tea.Msg {
+		err := m.config.Copy(snippet.Content)
+		return doneMsg{status: "Copied " + snippet.ID.String() + " to the clipboard", err: err}
+	}
+}
+
+// edit suspends the browser while the content of snippet is open in the editor.
+func (m Model) edit(snippet model.Snippet) tea.Cmd {
+	file, err := os.CreateTemp("", "snac-*"+lang.Extension(snippet.Language))
+	if err != nil {
+		return func() tea.Msg { return editedMsg{err: err} }
+	}
+	_, err = file.WriteString(snippet.Content)
+	file.Close()
+	if err != nil {
+		os.Remove(file.Name())
+		return func() tea.Msg { return editedMsg{err: err} }
+	}
+
+	editor := strings.Fields(m.config.Editor)
+	cmd := exec.Command(editor[0], append(editor[1:], file.Name())...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(file.Name())
+		if err != nil {
+			return editedMsg{err: err}
+		}
+		content, err := os.ReadFile(file.Name())
+		return editedMsg{snippet: snippet, content: string(content), err: err}
+	})
+}
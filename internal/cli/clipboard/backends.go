@@ -0,0 +1,152 @@
+package clipboard
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	"golang.org/x/term"
+)
+
+// system is what the backends need from the environment, replaced in tests.
+type system struct {
+	goos     string
+	getenv   func(string) string
+	lookPath func(string) (string, error)
+	input    func(content string, name string, args ...string) error
+	output   func(name string, args ...string) (string, error)
+	terminal io.Writer
+	isTTY    func() bool
+}
+
+func defaultSystem() system {
+	return system{
+		goos:     runtime.GOOS,
+		getenv:   os.Getenv,
+		lookPath: exec.LookPath,
+		input:    input,
+		output:   output,
+		terminal: os.Stderr,
+		isTTY:    func() bool { return term.IsTerminal(int(os.Stderr.Fd())) },
+	}
+}
+
+// Backends returns the native backends of the platforms, then OSC 52.
+func Backends() []Backend {
+	return backends(defaultSystem())
+}
+
+func backends(s system) []Backend {
+	return []Backend{
+		&command{system: s, name: "wl-copy", goos: "linux", display: "WAYLAND_DISPLAY",
+			copy: []string{"wl-copy"}, paste: []string{"wl-paste", "--no-newline"}},
+		&command{system: s, name: "xclip", goos: "linux", display: "DISPLAY",
+			copy: []string{"xclip", "-in", "-selection", "clipboard"}, paste: []string{"xclip", "-out", "-selection", "clipboard"}},
+		&command{system: s, name: "xsel", goos: "linux", display: "DISPLAY",
+			copy: []string{"xsel", "--input", "--clipboard"}, paste: []string{"xsel", "--output", "--clipboard"}},
+		&command{system: s, name: "pbcopy", goos: "darwin",
+			copy: []string{"pbcopy"}, paste: []string{"pbpaste"}},
+		&command{system: s, name: "clip", goos: "windows",
+			copy: []string{"clip.exe"}, paste: []string{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard -Raw"}},
+		&osc52Backend{system: s},
+	}
+}
+
+// command is a backend that pipes the content into a program and reads it back from another.
+type command struct {
+	system
+	name string
+	goos string
+	// display is the environment variable that needs to be set for the program to reach a display server.
+	display string
+	copy    []string
+	paste   []string
+}
+
+func (c *command) Name() string {
+	return c.name
+}
+
+func (c *command) Available() bool {
+	if c.system.goos != c.goos && !(c.goos == "linux" && c.system.goos == "freebsd") {
+		return false
+	}
+	if c.display != "" && c.getenv(c.display) == "" {
+		return false
+	}
+	_, err := c.lookPath(c.copy[0])
+	return err == nil
+}
+
+func (c *command) Copy(content string) error {
+	return c.input(content, c.copy[0], c.copy[1:]...)
+}
+
+func (c *command) Paste() (string, error) {
+	content, err := c.output(c.paste[0], c.paste[1:]...)
+	if err != nil {
+		return "", err
+	}
+	// clip.exe has no way to paste, and powershell adds a line break
+	if c.goos == "windows" {
+		content = strings.TrimSuffix(content, "\r\n")
+	}
+	return content, nil
+}
+
+// osc52Backend asks the terminal to copy with an escape sequence, which also works over SSH.
+// It cannot paste, as few terminals answer queries for the clipboard.
+type osc52Backend struct {
+	system
+}
+
+func (o *osc52Backend) Name() string {
+	return "osc52"
+}
+
+func (o *osc52Backend) Available() bool {
+	return o.isTTY()
+}
+
+func (o *osc52Backend) Copy(content string) error {
+	sequence := osc52.New(content)
+	// multiplexers swallow the sequence unless it is wrapped for them
+	if o.getenv("TMUX") != "" {
+		sequence = sequence.Tmux()
+	} else if strings.HasPrefix(o.getenv("TERM"), "screen") {
+		sequence = sequence.Screen()
+	}
+	_, err := sequence.WriteTo(o.terminal)
+	return err
+}
+
+func (o *osc52Backend) Paste() (string, error) {
+	return "", ErrPasteUnsupported
+}
+
+// input pipes content into the program. Its output is not read, as xclip and wl-copy keep running
+// in the background to serve the clipboard, and waiting for them to close it would never end.
+func input(content string, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(content)
+	return cmd.Run()
+}
+
+func output(name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	content, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, message)
+		}
+		return "", err
+	}
+	return string(content), nil
+}
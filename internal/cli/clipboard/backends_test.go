@@ -0,0 +1,101 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type call struct {
+	content string
+	args    []string
+}
+
+func fakeSystem(goos string, env map[string]string, programs ...string) (system, *[]call, *bytes.Buffer) {
+	calls := &[]call{}
+	terminal := &bytes.Buffer{}
+	return system{
+		goos:   goos,
+		getenv: func(key string) string { return env[key] },
+		lookPath: func(name string) (string, error) {
+			for _, program := range programs {
+				if program == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", errors.New("not found")
+		},
+		input: func(content string, name string, args ...string) error {
+			*calls = append(*calls, call{content: content, args: append([]string{name}, args...)})
+			return nil
+		},
+		output: func(name string, args ...string) (string, error) {
+			*calls = append(*calls, call{args: append([]string{name}, args...)})
+			return "pasted", nil
+		},
+		terminal: terminal,
+		isTTY:    func() bool { return env["TERM"] != "" },
+	}, calls, terminal
+}
+
+func TestBackends_Native(t *testing.T) {
+	s, calls, _ := fakeSystem("linux", map[string]string{"DISPLAY": ":0", "TERM": "xterm"}, "xsel", "wl-copy")
+	clipboard, err := NewWith(backends(s), Auto)
+	assert.Nil(t, err)
+
+	// wl-copy is installed, but there is no wayland display
+	name, err := clipboard.Copy("content")
+	assert.Nil(t, err)
+	assert.Equal(t, "xsel", name)
+
+	content, err := clipboard.Paste()
+	assert.Nil(t, err)
+	assert.Equal(t, "pasted", content)
+	assert.Equal(t, []call{
+		{content: "content", args: []string{"xsel", "--input", "--clipboard"}},
+		{args: []string{"xsel", "--output", "--clipboard"}},
+	}, *calls)
+}
+
+func TestBackends_OSC52(t *testing.T) {
+	// over SSH there is neither a display nor a clipboard program
+	s, calls, terminal := fakeSystem("linux", map[string]string{"TERM": "xterm"}, "xclip")
+	clipboard, err := NewWith(backends(s), Auto)
+	assert.Nil(t, err)
+
+	name, err := clipboard.Copy("hello")
+	assert.Nil(t, err)
+	assert.Equal(t, "osc52", name)
+	assert.Equal(t, "\x1b]52;c;aGVsbG8=\x07", terminal.String())
+	assert.Empty(t, *calls)
+
+	_, err = clipboard.Paste()
+	assert.ErrorContains(t, err, "No clipboard")
+
+	// tmux needs the sequence wrapped
+	s, _, terminal = fakeSystem("linux", map[string]string{"TERM": "screen-256color", "TMUX": "/tmp/tmux"})
+	clipboard, err = NewWith(backends(s), Auto)
+	assert.Nil(t, err)
+	_, err = clipboard.Copy("hello")
+	assert.Nil(t, err)
+	assert.Equal(t, "\x1bPtmux;\x1b\x1b]52;c;aGVsbG8=\x07\x1b\\", terminal.String())
+}
+
+func TestBackends_Platforms(t *testing.T) {
+	s, calls, _ := fakeSystem("darwin", map[string]string{}, "pbcopy", "xclip")
+	clipboard, err := NewWith(backends(s), Auto)
+	assert.Nil(t, err)
+	name, err := clipboard.Copy("content")
+	assert.Nil(t, err)
+	assert.Equal(t, "pbcopy", name)
+
+	s, calls, _ = fakeSystem("windows", map[string]string{}, "clip.exe")
+	clipboard, err = NewWith(backends(s), Auto)
+	assert.Nil(t, err)
+	name, err = clipboard.Copy("content")
+	assert.Nil(t, err)
+	assert.Equal(t, "clip", name)
+	assert.Equal(t, []string{"clip.exe"}, (*calls)[0].args)
+}
@@ -0,0 +1,110 @@
+// Package clipboard copies to and pastes from the clipboard of the system snac runs on,
+// or of the terminal it is used from if that is a remote or headless session.
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+)
+
+// Auto tries the backends in order and uses the first one that is available.
+const Auto = "auto"
+
+// Backend is a way to reach a clipboard.
+type Backend interface {
+	Name() string
+	// Available reports if the backend can be used in the current environment.
+	Available() bool
+	Copy(content string) error
+	Paste() (string, error)
+}
+
+// ErrPasteUnsupported is returned by backends that can only copy.
+var ErrPasteUnsupported = errs.New(errs.ErrUnavailable, "Backend cannot read the clipboard")
+
+// Clipboard copies and pastes with the first available of its backends.
+type Clipboard struct {
+	backends []Backend
+}
+
+// New returns a clipboard with the native backends, falling back to OSC 52 for copying.
+// A backend other than Auto forces that one, even if it does not seem to be available.
+func New(backend string) (*Clipboard, error) {
+	return NewWith(Backends(), backend)
+}
+
+// NewWith returns a clipboard with the backends, in the order they are tried.
+func NewWith(backends []Backend, backend string) (*Clipboard, error) {
+	if backend == "" || backend == Auto {
+		return &Clipboard{backends: backends}, nil
+	}
+	for _, b := range backends {
+		if b.Name() == backend {
+			return &Clipboard{backends: []Backend{forced{b}}}, nil
+		}
+	}
+	return nil, errs.Errorf(errs.ErrInvalid, "Unknown clipboard backend '%s', use one of: %s", backend, strings.Join(Names(backends), ", "))
+}
+
+// Names returns the names of the backends, led by Auto.
+func Names(backends []Backend) []string {
+	names := []string{Auto}
+	for _, b := range backends {
+		names = append(names, b.Name())
+	}
+	return names
+}
+
+// Copy puts content on the clipboard and returns the name of the backend that did.
+// If a backend fails, the next available one is tried.
+func (c *Clipboard) Copy(content string) (string, error) {
+	var failures []error
+	for _, b := range c.backends {
+		if !b.Available() {
+			continue
+		}
+		err := b.Copy(content)
+		if err == nil {
+			return b.Name(), nil
+		}
+		failures = append(failures, fmt.Errorf("%s: %w", b.Name(), err))
+	}
+	return "", c.unavailable("copy to", failures)
+}
+
+// Paste returns the content of the clipboard, skipping backends that cannot read it.
+func (c *Clipboard) Paste() (string, error) {
+	var failures []error
+	for _, b := range c.backends {
+		if !b.Available() {
+			continue
+		}
+		content, err := b.Paste()
+		if err == nil {
+			return content, nil
+		}
+		if !errors.Is(err, ErrPasteUnsupported) {
+			failures = append(failures, fmt.Errorf("%s: %w", b.Name(), err))
+		}
+	}
+	return "", c.unavailable("paste from", failures)
+}
+
+func (c *Clipboard) unavailable(action string, failures []error) error {
+	if len(failures) == 0 {
+		return errs.Errorf(errs.ErrUnavailable, "No clipboard to %s, install xclip, xsel or wl-clipboard, or use a terminal that supports OSC 52", action)
+	}
+	return errs.Errorf(errs.ErrUnavailable, "Could not %s the clipboard: %w", action, errors.Join(failures...))
+}
+
+// forced is a backend that is always available, as the user chose it.
+type forced struct {
+	Backend
+}
+
+func (f forced) Available() bool {
+	return true
+}
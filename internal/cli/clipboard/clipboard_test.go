@@ -0,0 +1,103 @@
+package clipboard
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBackend struct {
+	name      string
+	available bool
+	err       error
+	canPaste  bool
+	content   string
+}
+
+func (f *fakeBackend) Name() string {
+	return f.name
+}
+
+func (f *fakeBackend) Available() bool {
+	return f.available
+}
+
+func (f *fakeBackend) Copy(content string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.content = content
+	return nil
+}
+
+func (f *fakeBackend) Paste() (string, error) {
+	if !f.canPaste {
+		return "", ErrPasteUnsupported
+	}
+	return f.content, f.err
+}
+
+func TestCopy(t *testing.T) {
+	missing := &fakeBackend{name: "missing"}
+	broken := &fakeBackend{name: "broken", available: true, canPaste: true, err: errors.New("no display")}
+	native := &fakeBackend{name: "native", available: true, canPaste: true}
+	terminal := &fakeBackend{name: "terminal", available: true}
+
+	clipboard, err := NewWith([]Backend{missing, broken, native, terminal}, Auto)
+	assert.Nil(t, err)
+
+	// unavailable and failing backends are skipped
+	name, err := clipboard.Copy("content")
+	assert.Nil(t, err)
+	assert.Equal(t, "native", name)
+	assert.Equal(t, "content", native.content)
+	assert.Equal(t, "", terminal.content)
+
+	content, err := clipboard.Paste()
+	assert.Nil(t, err)
+	assert.Equal(t, "content", content)
+
+	// the fallback copies, but cannot paste
+	native.available = false
+	name, err = clipboard.Copy("other")
+	assert.Nil(t, err)
+	assert.Equal(t, "terminal", name)
+
+	_, err = clipboard.Paste()
+	assert.ErrorIs(t, err, errs.ErrUnavailable)
+	assert.ErrorContains(t, err, "no display")
+}
+
+func TestCopy_Unavailable(t *testing.T) {
+	clipboard, err := NewWith([]Backend{&fakeBackend{name: "missing"}}, "")
+	assert.Nil(t, err)
+
+	_, err = clipboard.Copy("content")
+	assert.ErrorIs(t, err, errs.ErrUnavailable)
+	assert.ErrorContains(t, err, "No clipboard")
+}
+
+func TestNewWith_Forced(t *testing.T) {
+	native := &fakeBackend{name: "native", available: true}
+	terminal := &fakeBackend{name: "terminal"}
+
+	// a forced backend is used even if it does not seem to be available
+	clipboard, err := NewWith([]Backend{native, terminal}, "terminal")
+	assert.Nil(t, err)
+	name, err := clipboard.Copy("content")
+	assert.Nil(t, err)
+	assert.Equal(t, "terminal", name)
+	assert.Equal(t, "", native.content)
+
+	// and it is the only one tried
+	terminal.err = errors.New("failed")
+	_, err = clipboard.Copy("content")
+	assert.ErrorContains(t, err, "terminal: failed")
+	assert.Equal(t, "", native.content)
+
+	_, err = NewWith([]Backend{native, terminal}, "unknown")
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+	assert.ErrorContains(t, err, "auto, native, terminal")
+}
@@ -1,8 +1,124 @@
 package cli
 
-import "github.com/snippetaccumulator/snac/internal/common"
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/common"
+	"gopkg.in/yaml.v3"
+)
 
 type Config struct {
 	common.CommonConfig
-	LogLevel string `yaml:"log_level" json:"log_level"`
+	// Server is the URL of a snac server. If set, all requests are sent there instead of to the database directly.
+	Server string `yaml:"server" json:"server"`
+	// InstanceToken grants the instance-admin role on the server, which is needed to create teams.
+	InstanceToken string `yaml:"instance_token" json:"instance_token"`
+	LogLevel      string `yaml:"log_level" json:"log_level"`
+	// Theme is the chroma style used to highlight snippets, see https://xyproto.github.io/splash/docs/.
+	Theme string `yaml:"theme" json:"theme"`
+	// Clipboard forces a clipboard backend: wl-copy, xclip, xsel, pbcopy, clip or osc52. Empty or auto tries them in order.
+	Clipboard string `yaml:"clipboard" json:"clipboard"`
+	// Vault is the encrypted store the credentials are kept in instead of this file, see snac vault.
+	Vault Vault `yaml:"vault" json:"vault"`
+	// Profiles are named teams with their credentials and backend, see UseProfile.
+	Profiles map[string]*Profile `yaml:"profiles,omitempty" json:"profiles,omitempty"`
+	// Profile is the name of the profile in use, without one the team and backend above are used.
+	Profile string `yaml:"profile,omitempty" json:"profile,omitempty"`
+}
+
+// Vault locates the credential store and the key file that unlocks it.
+type Vault struct {
+	// Path is the store file, credentials.vault next to the config file by default.
+	Path string `yaml:"path" json:"path"`
+	// KeyFile unlocks the store instead of a passphrase.
+	KeyFile string `yaml:"key_file" json:"key_file"`
+}
+
+// Profile is a team with its credentials and the backend it is on.
+type Profile struct {
+	common.CommonConfig `yaml:",inline"`
+	Server              string `yaml:"server,omitempty" json:"server,omitempty"`
+	InstanceToken       string `yaml:"instance_token,omitempty" json:"instance_token,omitempty"`
+}
+
+// UseProfile replaces team, credentials and backend with the ones of the profile with the name,
+// or of the current profile for "". Without a current profile the config is left as it is.
+// It returns an ErrInvalid error for an unknown profile.
+func (c *Config) UseProfile(name string) error {
+	if name == "" {
+		name = c.Profile
+	}
+	if name == "" {
+		return nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok || profile == nil {
+		return c.unknownProfile(name)
+	}
+	c.CommonConfig = profile.CommonConfig
+	c.Server = profile.Server
+	c.InstanceToken = profile.InstanceToken
+	c.Profile = name
+	return nil
+}
+
+// CurrentProfile returns team, credentials and backend in use as a profile.
+func (c *Config) CurrentProfile() Profile {
+	return Profile{CommonConfig: c.CommonConfig, Server: c.Server, InstanceToken: c.InstanceToken}
+}
+
+// Credentials returns team, credentials and database of the profile with the name to be changed,
+// the ones at the top of the config for "". It returns an ErrInvalid error for an unknown profile.
+func (c *Config) Credentials(profile string) (*common.CommonConfig, error) {
+	if profile == "" {
+		return &c.CommonConfig, nil
+	}
+	if existing, ok := c.Profiles[profile]; ok && existing != nil {
+		return &existing.CommonConfig, nil
+	}
+	return nil, c.unknownProfile(profile)
+}
+
+// ProfileNames returns the names of the profiles, sorted.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Config) unknownProfile(name string) error {
+	if len(c.Profiles) == 0 {
+		return errs.Errorf(errs.ErrInvalid, "Unknown profile '%s', there are no profiles yet", name)
+	}
+	return errs.Errorf(errs.ErrInvalid, "Unknown profile '%s', use one of: %s", name, strings.Join(c.ProfileNames(), ", "))
+}
+
+// LoadFile reads the config file at path as it is, without any overrides.
+func LoadFile(path string) (Config, error) {
+	var config Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = yaml.Unmarshal(data, &config)
+	return config, err
+}
+
+// Save writes the config to path, only readable by the user as it contains credentials.
+func (c Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the permissions of an existing file
+	return os.Chmod(path, 0600)
 }
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig() Config {
+	config := Config{
+		Server: "https://snac.example.com",
+		Profiles: map[string]*Profile{
+			"work": {CommonConfig: common.CommonConfig{TeamName: "ops", Session: "token"}, Server: "https://work.example.com"},
+			"home": {CommonConfig: common.CommonConfig{TeamName: "me", Database: common.Database{Url: "libsql://home"}}},
+		},
+		Profile: "work",
+	}
+	config.TeamName = "default"
+	config.Password = "secret"
+	return config
+}
+
+func TestUseProfile(t *testing.T) {
+	config := testConfig()
+	assert.Nil(t, config.UseProfile(""))
+	assert.Equal(t, "ops", config.TeamName)
+	assert.Equal(t, "", config.Password)
+	assert.Equal(t, "token", config.Session)
+	assert.Equal(t, "https://work.example.com", config.Server)
+
+	config = testConfig()
+	assert.Nil(t, config.UseProfile("home"))
+	assert.Equal(t, "home", config.Profile)
+	assert.Equal(t, "me", config.TeamName)
+	assert.Equal(t, "libsql://home", config.Database.Url)
+	assert.Equal(t, "", config.Server)
+
+	config = testConfig()
+	err := config.UseProfile("school")
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+	assert.ErrorContains(t, err, "home, work")
+
+	// without a current profile the config is used as it is
+	config = testConfig()
+	config.Profile = ""
+	assert.Nil(t, config.UseProfile(""))
+	assert.Equal(t, "default", config.TeamName)
+	assert.Equal(t, "secret", config.Password)
+}
+
+func TestCredentials(t *testing.T) {
+	config := testConfig()
+	credentials, err := config.Credentials("home")
+	assert.Nil(t, err)
+	credentials.Password = "changed"
+	assert.Equal(t, "changed", config.Profiles["home"].Password)
+
+	credentials, err = config.Credentials("")
+	assert.Nil(t, err)
+	assert.Equal(t, "default", credentials.TeamName)
+
+	_, err = config.Credentials("school")
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+}
+
+func TestSaveProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := testConfig()
+	assert.Nil(t, config.Save(path))
+
+	loaded, err := LoadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, testConfig(), loaded)
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"path/filepath"
+
+	"github.com/snippetaccumulator/snac/internal/cli/vault"
+)
+
+const (
+	// StoreFile is the name of the credential store next to the config file.
+	StoreFile = "credentials.vault"
+	// PassphraseEnv is the environment variable with the passphrase of the credential store, for when there is no terminal.
+	PassphraseEnv = EnvPrefix + "PASSPHRASE"
+)
+
+// StorePath returns the path of the credential store, vault.path or the StoreFile next to the config file at configPath.
+func (c *Config) StorePath(configPath string) string {
+	if c.Vault.Path != "" {
+		return c.Vault.Path
+	}
+	return filepath.Join(filepath.Dir(configPath), StoreFile)
+}
+
+// Credentials a command can use besides the token of the database, see MissingCredentials.
+const (
+	// UsesTeam is a password, session or API key of the team.
+	UsesTeam = "team"
+	// UsesInstance is the instance token, the database auth token without a server.
+	UsesInstance = "instance"
+	// UsesSessions is the key sessions are signed with, for commands that issue sessions.
+	UsesSessions = "sessions"
+)
+
+// MissingCredentials reports if a credential in use is not set, which leaves it to the credential store.
+// The token of the database is in use without a server, the others if uses names them. The session key
+// is also in use if the team credential is a session.
+func (c *Config) MissingCredentials(uses ...string) bool {
+	direct := c.Server == ""
+	if direct && c.Database.AuthToken == "" {
+		return true
+	}
+	for _, use := range uses {
+		switch use {
+		case UsesTeam:
+			if c.Password == "" && c.Session == "" && c.APIKey == "" {
+				return true
+			}
+			if direct && c.Password == "" && c.APIKey == "" && c.Database.SessionKey == "" {
+				return true
+			}
+		case UsesInstance:
+			if !direct && c.InstanceToken == "" {
+				return true
+			}
+		case UsesSessions:
+			if direct && c.Database.SessionKey == "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PlaintextSecrets returns the keys of the credentials that are set in the config and its profiles,
+// e.g. password or profiles.work.session.
+func (c *Config) PlaintextSecrets() []string {
+	var keys []string
+	for _, f := range secretFields(c.Fields()) {
+		if f.Value() != "" {
+			keys = append(keys, f.Key)
+		}
+	}
+	for _, name := range c.ProfileNames() {
+		if profile := c.Profiles[name]; profile != nil {
+			for _, f := range secretFields(profile.Fields()) {
+				if f.Value() != "" {
+					keys = append(keys, "profiles."+name+"."+f.Key)
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// FillSecrets sets the credentials in use that are not set from the store, the ones of the current profile if there is one.
+// Credentials of the environment and flags are kept.
+func (c *Config) FillSecrets(store *vault.Store) {
+	fill(c.Fields(), store.Scope(c.Profile))
+}
+
+// RestoreSecrets sets the credentials of the config file and its profiles that are not set from the store.
+func (c *Config) RestoreSecrets(store *vault.Store) {
+	fill(c.Fields(), store.Scope(""))
+	for name, profile := range c.Profiles {
+		if profile != nil {
+			fill(profile.Fields(), store.Scope(name))
+		}
+	}
+}
+
+// MoveSecrets replaces the credentials of the store with the ones of the config file and its profiles, and removes them from the config.
+// Restore the credentials first, or the ones only the store has are lost.
+func (c *Config) MoveSecrets(store *vault.Store) {
+	store.SetScope("", take(c.Fields()))
+	for _, scope := range store.Scopes() {
+		if _, ok := c.Profiles[scope]; scope != "" && !ok {
+			store.SetScope(scope, nil)
+		}
+	}
+	for name, profile := range c.Profiles {
+		if profile != nil {
+			store.SetScope(name, take(profile.Fields()))
+		}
+	}
+}
+
+func secretFields(fields []Field) []Field {
+	var secrets []Field
+	for _, f := range fields {
+		if f.Secret() {
+			secrets = append(secrets, f)
+		}
+	}
+	return secrets
+}
+
+func fill(fields []Field, secrets map[string]string) {
+	for _, f := range secretFields(fields) {
+		if value := secrets[f.Key]; value != "" && f.Value() == "" {
+			_ = f.set(value)
+		}
+	}
+}
+
+// take returns the credentials of the fields by key and clears them.
+func take(fields []Field) map[string]string {
+	secrets := map[string]string{}
+	for _, f := range secretFields(fields) {
+		secrets[f.Key] = f.Value()
+		_ = f.set("")
+	}
+	return secrets
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/cli/vault"
+	"github.com/stretchr/testify/assert"
+)
+
+func testStore(t *testing.T) *vault.Store {
+	store, err := vault.Create(filepath.Join(t.TempDir(), StoreFile), []byte("passphrase"))
+	assert.Nil(t, err)
+	return store
+}
+
+func TestStorePath(t *testing.T) {
+	var config Config
+	assert.Equal(t, filepath.Join("/home/me/.config/snac", StoreFile), config.StorePath("/home/me/.config/snac/config.yaml"))
+	config.Vault.Path = "/secrets/snac.vault"
+	assert.Equal(t, "/secrets/snac.vault", config.StorePath("/home/me/.config/snac/config.yaml"))
+}
+
+func TestMoveSecrets(t *testing.T) {
+	config := testConfig()
+	config.Database.AuthToken = "token"
+	assert.Equal(t, []string{"password", "database.auth_token", "profiles.work.session"}, config.PlaintextSecrets())
+
+	store := testStore(t)
+	store.SetScope("gone", map[string]string{"password": "old"})
+	config.MoveSecrets(store)
+	assert.Empty(t, config.PlaintextSecrets())
+	assert.Equal(t, "default", config.TeamName)
+	assert.Equal(t, map[string]string{"password": "secret", "database.auth_token": "token"}, store.Scope(""))
+	assert.Equal(t, map[string]string{"session": "token"}, store.Scope("work"))
+	assert.Equal(t, []string{"", "work"}, store.Scopes())
+
+	config.RestoreSecrets(store)
+	assert.Equal(t, "secret", config.Password)
+	assert.Equal(t, "token", config.Profiles["work"].Session)
+}
+
+func TestFillSecrets(t *testing.T) {
+	store := testStore(t)
+	store.SetScope("", map[string]string{"password": "secret"})
+	store.SetScope("work", map[string]string{"session": "token", "api_key": "key"})
+
+	config := Config{}
+	config.FillSecrets(store)
+	assert.Equal(t, "secret", config.Password)
+
+	config = testConfig()
+	config.Profiles["work"].Session = ""
+	assert.Nil(t, config.UseProfile(""))
+	config.APIKey = "from the environment"
+	config.FillSecrets(store)
+	assert.Equal(t, "token", config.Session)
+	assert.Equal(t, "from the environment", config.APIKey)
+	assert.Equal(t, "", config.Password)
+}
+
+func TestMissingCredentials(t *testing.T) {
+	config := Config{Server: "https://snac.example.com"}
+	assert.False(t, config.MissingCredentials())
+	assert.True(t, config.MissingCredentials(UsesTeam))
+	config.APIKey = "key"
+	assert.False(t, config.MissingCredentials(UsesTeam))
+	assert.True(t, config.MissingCredentials(UsesTeam, UsesInstance))
+	config.InstanceToken = "token"
+	assert.False(t, config.MissingCredentials(UsesTeam, UsesInstance, UsesSessions))
+
+	config = Config{}
+	config.Session = "session"
+	assert.True(t, config.MissingCredentials(UsesTeam))
+	config.Database.AuthToken = "token"
+	assert.True(t, config.MissingCredentials(UsesTeam))
+	config.Database.SessionKey = "key"
+	assert.False(t, config.MissingCredentials(UsesTeam, UsesInstance))
+
+	config = Config{}
+	config.Password = "secret"
+	config.Database.AuthToken = "token"
+	assert.False(t, config.MissingCredentials(UsesTeam))
+	assert.True(t, config.MissingCredentials(UsesTeam, UsesSessions))
+}
@@ -0,0 +1,90 @@
+package exporter
+
+import (
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"gopkg.in/yaml.v3"
+)
+
+// Espanso exports a match file of the espanso text expander, to be put in its match folder.
+// Snippets are expanded by :trigger, those with placeholders open a form asking for their values.
+type Espanso struct{}
+
+type espansoFile struct {
+	Matches []espansoMatch `yaml:"matches"`
+}
+
+type espansoMatch struct {
+	Trigger    string                  `yaml:"trigger"`
+	Label      string                  `yaml:"label,omitempty"`
+	Replace    string                  `yaml:"replace,omitempty"`
+	Form       string                  `yaml:"form,omitempty"`
+	FormFields map[string]espansoField `yaml:"form_fields,omitempty"`
+}
+
+type espansoField struct {
+	Type    string   `yaml:"type,omitempty"`
+	Values  []string `yaml:"values,omitempty,flow"`
+	Default string   `yaml:"default,omitempty"`
+}
+
+func (Espanso) Name() string {
+	return "espanso"
+}
+
+func (Espanso) Export(snippets []model.Snippet) ([]File, error) {
+	names := triggers(snippets)
+	var file espansoFile
+	for i, snippet := range snippets {
+		match := espansoMatch{Trigger: ":" + names[i], Label: snippet.Title}
+		if len(model.Placeholders(snippet.Content)) == 0 {
+			match.Replace = strings.ReplaceAll(snippet.Content, "$${", "${")
+		} else {
+			match.Form, match.FormFields = toEspanso(snippet.Content)
+		}
+		file.Matches = append(file.Matches, match)
+	}
+
+	data, err := yaml.Marshal(file)
+	if err != nil {
+		return nil, err
+	}
+	return []File{{Name: "snac.yml", Content: append([]byte("# Exported from snac\n"), data...)}}, nil
+}
+
+// toEspanso converts the placeholders of content to the [[name]] fields of a form.
+// Fields with choices become a choice, defaults are kept.
+func toEspanso(content string) (string, map[string]espansoField) {
+	vars := variables(content)
+	names := map[string]string{}
+	taken := map[string]bool{}
+	fields := map[string]espansoField{}
+
+	var result strings.Builder
+	for _, part := range model.Parts(content) {
+		if part.Placeholder == nil {
+			result.WriteString(part.Text)
+			continue
+		}
+		name, ok := names[part.Placeholder.Name]
+		if !ok {
+			name = identifier(part.Placeholder.Name)
+			for taken[name] {
+				name += "_"
+			}
+			names[part.Placeholder.Name], taken[name] = name, true
+
+			// fields without a default or choices need no entry
+			variable := vars[part.Placeholder.Name]
+			if len(variable.Choices) > 0 {
+				fields[name] = espansoField{Type: "choice", Values: variable.Choices, Default: variable.Default}
+			} else if variable.HasDefault {
+				fields[name] = espansoField{Default: variable.Default}
+			}
+		}
+		result.WriteString("[[" + name + "]]")
+	}
+
+	return result.String(), fields
+}
@@ -0,0 +1,38 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEspanso(t *testing.T) {
+	snippets := []model.Snippet{
+		{ID: "AB3CD", Title: "Signature", Content: "Best regards,\n$${name}\n"},
+		{ID: "EF4GH", Title: "Get resources", Content: "kubectl -n ${k8s.ns:default} get ${kind|pods,nodes} ${name:} ${k8s.ns:}"},
+	}
+
+	files, err := Espanso{}.Export(snippets)
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "snac.yml", files[0].Name)
+	assert.Equal(t, `# Exported from snac
+matches:
+    - trigger: :signature
+      label: Signature
+      replace: |
+        Best regards,
+        ${name}
+    - trigger: :get-resources
+      label: Get resources
+      form: kubectl -n [[k8s_ns]] get [[kind]] [[name]] [[k8s_ns]]
+      form_fields:
+        k8s_ns:
+            default: default
+        kind:
+            type: choice
+            values: [pods, nodes]
+            default: pods
+`, string(files[0].Content))
+}
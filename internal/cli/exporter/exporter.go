@@ -0,0 +1,130 @@
+// Package exporter writes snippets in the formats of editors and other tools, so they can load them directly.
+// Exporters are registered by name, so the export command can pick one.
+package exporter
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+)
+
+// Exporter renders snippets into the files of its format.
+type Exporter interface {
+	Name() string
+	Export(snippets []model.Snippet) ([]File, error)
+}
+
+// File is a file written by an exporter, its name is relative to the output directory.
+type File struct {
+	Name    string
+	Content []byte
+}
+
+var exporters []Exporter
+
+// Register adds an exporter, replacing one with the same name.
+func Register(exporter Exporter) {
+	for i, existing := range exporters {
+		if existing.Name() == exporter.Name() {
+			exporters[i] = exporter
+			return
+		}
+	}
+	exporters = append(exporters, exporter)
+}
+
+// Get returns the exporter with the name, or an ErrInvalid error naming the known ones.
+func Get(name string) (Exporter, error) {
+	for _, exporter := range exporters {
+		if exporter.Name() == name {
+			return exporter, nil
+		}
+	}
+	return nil, errs.Errorf(errs.ErrInvalid, "Unknown export format '%s', use one of: %s", name, strings.Join(Names(), ", "))
+}
+
+// Names returns the names of the registered exporters, sorted.
+func Names() []string {
+	names := make([]string, 0, len(exporters))
+	for _, exporter := range exporters {
+		names = append(names, exporter.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(VSCode{})
+	Register(JetBrains{})
+	Register(UltiSnips{})
+	Register(Espanso{})
+}
+
+// triggers returns a unique trigger for every snippet, made of the words of its title, e.g. k8s-get-pods.
+// Titles without letters or digits use the ID, and triggers that are taken get a number.
+func triggers(snippets []model.Snippet) []string {
+	result := make([]string, len(snippets))
+	taken := map[string]bool{}
+	for i, snippet := range snippets {
+		trigger := slug(snippet.Title)
+		if trigger == "" {
+			trigger = strings.ToLower(string(snippet.ID))
+		}
+		unique := trigger
+		for n := 2; taken[unique]; n++ {
+			unique = trigger + "-" + strconv.Itoa(n)
+		}
+		taken[unique] = true
+		result[i] = unique
+	}
+	return result
+}
+
+// slug returns the letters and digits of title in lower case, with a - between words.
+func slug(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// identifier returns name with every character but letters, digits and _ replaced by _,
+// for formats that allow fewer characters in names than placeholders do.
+func identifier(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// tabStops numbers the variables of content from 1, in the order they first appear.
+func tabStops(content string) map[string]int {
+	stops := map[string]int{}
+	for i, variable := range model.Variables(content) {
+		stops[variable.Name] = i + 1
+	}
+	return stops
+}
+
+// variables returns the variables of content by name, with the default and choices of all their placeholders.
+func variables(content string) map[string]model.Placeholder {
+	result := map[string]model.Placeholder{}
+	for _, variable := range model.Variables(content) {
+		result[variable.Name] = variable
+	}
+	return result
+}
+
+// description returns the description of the snippet, or its title if it has none.
+func description(snippet model.Snippet) string {
+	if snippet.Description != "" {
+		return snippet.Description
+	}
+	return snippet.Title
+}
@@ -0,0 +1,30 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	exporter, err := Get("espanso")
+	assert.Nil(t, err)
+	assert.Equal(t, "espanso", exporter.Name())
+
+	_, err = Get("sublime")
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+	assert.ErrorContains(t, err, "espanso, jetbrains, ultisnips, vscode")
+}
+
+func TestTriggers(t *testing.T) {
+	snippets := []model.Snippet{
+		{ID: "AB3CD", Title: "Get pods (k8s)"},
+		{ID: "EF4GH", Title: "get-pods, k8s!"},
+		{ID: "JK5LM", Title: "Über Größe"},
+		{ID: "NP6QR", Title: "!!!"},
+		{ID: "ST7UV", Title: "get pods k8s"},
+	}
+	assert.Equal(t, []string{"get-pods-k8s", "get-pods-k8s-2", "über-größe", "np6qr", "get-pods-k8s-3"}, triggers(snippets))
+}
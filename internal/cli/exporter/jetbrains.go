@@ -0,0 +1,143 @@
+package exporter
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/lang"
+)
+
+// JetBrains exports a live template set, which the IDEs load from the templates folder of their settings.
+// Placeholders become variables, with their choices as enum expression.
+type JetBrains struct{}
+
+type jetbrainsTemplateSet struct {
+	XMLName   xml.Name            `xml:"templateSet"`
+	Group     string              `xml:"group,attr"`
+	Templates []jetbrainsTemplate `xml:"template"`
+}
+
+type jetbrainsTemplate struct {
+	Name             string              `xml:"name,attr"`
+	Value            string              `xml:"value,attr"`
+	Description      string              `xml:"description,attr"`
+	ToReformat       bool                `xml:"toReformat,attr"`
+	ToShortenFQNames bool                `xml:"toShortenFQNames,attr"`
+	Variables        []jetbrainsVariable `xml:"variable"`
+	Options          []jetbrainsOption   `xml:"context>option"`
+}
+
+type jetbrainsVariable struct {
+	Name         string `xml:"name,attr"`
+	Expression   string `xml:"expression,attr"`
+	DefaultValue string `xml:"defaultValue,attr"`
+	AlwaysStopAt bool   `xml:"alwaysStopAt,attr"`
+}
+
+type jetbrainsOption struct {
+	Name  string `xml:"name,attr"`
+	Value bool   `xml:"value,attr"`
+}
+
+func (JetBrains) Name() string {
+	return "jetbrains"
+}
+
+func (JetBrains) Export(snippets []model.Snippet) ([]File, error) {
+	names := triggers(snippets)
+	set := jetbrainsTemplateSet{Group: "snac"}
+	for i, snippet := range snippets {
+		value, variables := toJetBrains(snippet.Content)
+		set.Templates = append(set.Templates, jetbrainsTemplate{
+			Name:             names[i],
+			Value:            value,
+			Description:      description(snippet),
+			ToShortenFQNames: true,
+			Variables:        variables,
+			Options:          []jetbrainsOption{{Name: jetbrainsContext(snippet.Language), Value: true}},
+		})
+	}
+
+	data, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []File{{Name: "snac.xml", Content: append(data, '\n')}}, nil
+}
+
+// toJetBrains converts the placeholders of content to $NAME$ variables, $ is escaped as $$.
+// Names get only the characters JetBrains allows, and a number if that makes them clash.
+func toJetBrains(content string) (string, []jetbrainsVariable) {
+	vars := variables(content)
+	names := map[string]string{}
+	taken := map[string]bool{"END": true, "SELECTION": true}
+	var variables []jetbrainsVariable
+
+	var result strings.Builder
+	for _, part := range model.Parts(content) {
+		if part.Placeholder == nil {
+			result.WriteString(strings.ReplaceAll(part.Text, "$", "$$"))
+			continue
+		}
+		name, ok := names[part.Placeholder.Name]
+		if !ok {
+			name = identifier(part.Placeholder.Name)
+			for n := 2; taken[name]; n++ {
+				name = identifier(part.Placeholder.Name) + strconv.Itoa(n)
+			}
+			names[part.Placeholder.Name], taken[name] = name, true
+
+			variable := vars[part.Placeholder.Name]
+			exported := jetbrainsVariable{Name: name, AlwaysStopAt: true}
+			if len(variable.Choices) > 0 {
+				exported.Expression = "enum(" + jetbrainsStrings(variable.Choices) + ")"
+			}
+			if variable.HasDefault {
+				exported.DefaultValue = jetbrainsStrings([]string{variable.Default})
+			}
+			variables = append(variables, exported)
+		}
+		result.WriteString("$" + name + "$")
+	}
+	return result.String(), variables
+}
+
+// jetbrainsStrings returns values as string literals of template expressions, separated by commas.
+func jetbrainsStrings(values []string) string {
+	literals := make([]string, len(values))
+	for i, value := range values {
+		literals[i] = `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+	}
+	return strings.Join(literals, ", ")
+}
+
+// jetbrainsContexts maps languages to the template context of the IDEs that know them.
+var jetbrainsContexts = map[string]string{
+	"bash":       "SHELL_SCRIPT",
+	"css":        "CSS",
+	"go":         "GO",
+	"html":       "HTML",
+	"java":       "JAVA_CODE",
+	"javascript": "JAVA_SCRIPT",
+	"json":       "JSON",
+	"kotlin":     "KOTLIN",
+	"markdown":   "MARKDOWN",
+	"php":        "PHP",
+	"python":     "Python",
+	"ruby":       "RUBY",
+	"rust":       "RUST_FILE",
+	"sql":        "SQL",
+	"typescript": "TypeScript",
+	"xml":        "XML",
+	"yaml":       "YAML",
+}
+
+// jetbrainsContext returns the template context of a language, OTHER if the IDEs have none for it.
+func jetbrainsContext(language string) string {
+	if context, ok := jetbrainsContexts[lang.Normalize(language)]; ok {
+		return context
+	}
+	return "OTHER"
+}
@@ -0,0 +1,54 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/importer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJetBrains(t *testing.T) {
+	snippets := []model.Snippet{
+		{ID: "AB3CD", Title: "Get resources", Language: "bash", Content: "kubectl -n ${ns:default} get ${kind|pods,nodes} # costs $5\n"},
+		{ID: "EF4GH", Title: "Note", Description: "A \"note\"", Language: "elixir", Content: "<none> & ${END:}"},
+	}
+
+	files, err := JetBrains{}.Export(snippets)
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "snac.xml", files[0].Name)
+	assert.Equal(t, `<templateSet group="snac">
+  <template name="get-resources" value="kubectl -n $ns$ get $kind$ # costs $$5&#xA;" description="Get resources" toReformat="false" toShortenFQNames="true">
+    <variable name="ns" expression="" defaultValue="&#34;default&#34;" alwaysStopAt="true"></variable>
+    <variable name="kind" expression="enum(&#34;pods&#34;, &#34;nodes&#34;)" defaultValue="&#34;pods&#34;" alwaysStopAt="true"></variable>
+    <context>
+      <option name="SHELL_SCRIPT" value="true"></option>
+    </context>
+  </template>
+  <template name="note" value="&lt;none&gt; &amp; $END2$" description="A &#34;note&#34;" toReformat="false" toShortenFQNames="true">
+    <variable name="END2" expression="" defaultValue="" alwaysStopAt="true"></variable>
+    <context>
+      <option name="OTHER" value="true"></option>
+    </context>
+  </template>
+</templateSet>
+`, string(files[0].Content))
+
+	// the importer reads what was exported
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, files[0].Name), files[0].Content, 0644))
+	result, err := importer.JetBrains{}.Import(dir)
+	assert.Nil(t, err)
+	assert.Len(t, result.Items, 2)
+	assert.Equal(t, "kubectl -n ${ns:default} get ${kind:pods|pods,nodes} # costs $5\n", result.Items[0].Snippet.Content)
+	assert.Equal(t, "bash", result.Items[0].Snippet.Language)
+}
+
+func TestToJetBrains(t *testing.T) {
+	value, variables := toJetBrains("${user.name:} ${user-name:} ${user.name:}")
+	assert.Equal(t, "$user_name$ $user_name2$ $user_name$", value)
+	assert.Equal(t, []jetbrainsVariable{{Name: "user_name", AlwaysStopAt: true}, {Name: "user_name2", AlwaysStopAt: true}}, variables)
+}
@@ -0,0 +1,98 @@
+package exporter
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/lang"
+)
+
+// UltiSnips exports a .snippets file for every file type of Vim, snippets without a language go to all.snippets.
+// The files can be put in an UltiSnips folder of the runtime path.
+type UltiSnips struct{}
+
+func (UltiSnips) Name() string {
+	return "ultisnips"
+}
+
+func (UltiSnips) Export(snippets []model.Snippet) ([]File, error) {
+	names := triggers(snippets)
+	files := map[string]*strings.Builder{}
+	for i, snippet := range snippets {
+		name := vimFiletype(snippet.Language) + ".snippets"
+		file, ok := files[name]
+		if !ok {
+			file = &strings.Builder{}
+			file.WriteString("# Exported from snac\n")
+			files[name] = file
+		}
+		file.WriteString("\nsnippet " + names[i] + ` "` + strings.ReplaceAll(description(snippet), `"`, `'`) + "\"\n")
+		file.WriteString(toUltiSnips(strings.TrimSuffix(snippet.Content, "\n")))
+		file.WriteString("\nendsnippet\n")
+	}
+
+	result := make([]File, 0, len(files))
+	for name, file := range files {
+		result = append(result, File{Name: name, Content: []byte(file.String())})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+var ultisnipsEscaper = strings.NewReplacer(`\`, `\\`, "$", `\$`, "`", "\\`")
+
+// toUltiSnips converts the placeholders of content to tab stops, numbered in the order their names first appear.
+// The first placeholder of a name gets the default, the others mirror it. Choices cannot be offered, the first one is the default.
+func toUltiSnips(content string) string {
+	stops := tabStops(content)
+	vars := variables(content)
+	seen := map[string]bool{}
+
+	var result strings.Builder
+	for _, part := range model.Parts(content) {
+		if part.Placeholder == nil {
+			result.WriteString(ultisnipsEscaper.Replace(part.Text))
+			continue
+		}
+		name := part.Placeholder.Name
+		stop := strconv.Itoa(stops[name])
+		variable := vars[name]
+		switch {
+		case seen[name]:
+			result.WriteString("$" + stop)
+		case variable.HasDefault:
+			result.WriteString("${" + stop + ":" + ultisnipsEscaper.Replace(variable.Default) + "}")
+		default:
+			result.WriteString("${" + stop + "}")
+		}
+		seen[name] = true
+	}
+	return result.String()
+}
+
+// vimFiletypes maps languages to the file types of Vim where they differ.
+var vimFiletypes = map[string]string{
+	"bash":       "sh",
+	"csharp":     "cs",
+	"makefile":   "make",
+	"powershell": "ps1",
+}
+
+// vimFiletype returns the file type of Vim for a language, all for none.
+func vimFiletype(language string) string {
+	normalized := lang.Normalize(language)
+	if filetype, ok := vimFiletypes[normalized]; ok {
+		return filetype
+	}
+	if normalized != "" {
+		return normalized
+	}
+	if filetype := identifier(strings.ToLower(strings.TrimSpace(language))); filetype != "" {
+		return filetype
+	}
+	return "all"
+}
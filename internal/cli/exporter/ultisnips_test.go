@@ -0,0 +1,26 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUltiSnips(t *testing.T) {
+	snippets := []model.Snippet{
+		{ID: "AB3CD", Title: "Restart", Language: "shell", Content: "kubectl rollout restart ${kind|deployment,statefulset}/${name:}\necho \"$(date)\" `${name:}`\n"},
+		{ID: "EF4GH", Title: "Say \"hi\"", Content: "hi ${who:}"},
+		{ID: "JK5LM", Title: "Main", Language: "go", Content: "func main() {\n\t${body:panic(\"$todo\")}\n}\n"},
+		{ID: "NP6QR", Title: "Elixir", Language: "Elixir", Content: "IO.puts ${text:}"},
+	}
+
+	files, err := UltiSnips{}.Export(snippets)
+	assert.Nil(t, err)
+	assert.Equal(t, []File{
+		{Name: "all.snippets", Content: []byte("# Exported from snac\n\nsnippet say-hi \"Say 'hi'\"\nhi ${1}\nendsnippet\n")},
+		{Name: "elixir.snippets", Content: []byte("# Exported from snac\n\nsnippet elixir \"Elixir\"\nIO.puts ${1}\nendsnippet\n")},
+		{Name: "go.snippets", Content: []byte("# Exported from snac\n\nsnippet main \"Main\"\nfunc main() {\n\t${1:panic(\"\\$todo\")}\n}\nendsnippet\n")},
+		{Name: "sh.snippets", Content: []byte("# Exported from snac\n\nsnippet restart \"Restart\"\nkubectl rollout restart ${1:deployment}/${2}\necho \"\\$(date)\" \\`$2\\`\nendsnippet\n")},
+	}, files)
+}
@@ -0,0 +1,98 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/lang"
+)
+
+// VSCode exports a .code-snippets file with every snippet, scoped to its language.
+// It can be put in the .vscode folder of a workspace or in the snippets folder of the user settings.
+type VSCode struct{}
+
+type vscodeSnippet struct {
+	Prefix      string   `json:"prefix"`
+	Body        []string `json:"body"`
+	Description string   `json:"description,omitempty"`
+	Scope       string   `json:"scope,omitempty"`
+}
+
+func (VSCode) Name() string {
+	return "vscode"
+}
+
+func (VSCode) Export(snippets []model.Snippet) ([]File, error) {
+	prefixes := triggers(snippets)
+	result := map[string]vscodeSnippet{}
+	for i, snippet := range snippets {
+		// the title is the key, so it has to be unique
+		key := snippet.Title
+		if _, ok := result[key]; ok || key == "" {
+			key = strings.TrimSpace(snippet.Title + " (" + string(snippet.ID) + ")")
+		}
+		result[key] = vscodeSnippet{
+			Prefix:      prefixes[i],
+			Body:        strings.Split(strings.TrimSuffix(toVSCode(snippet.Content), "\n"), "\n"),
+			Description: snippet.Description,
+			Scope:       vscodeScope(snippet.Language),
+		}
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "\t")
+	if err := encoder.Encode(result); err != nil {
+		return nil, err
+	}
+	return []File{{Name: "snac.code-snippets", Content: buffer.Bytes()}}, nil
+}
+
+var vscodeEscaper = strings.NewReplacer(`\`, `\\`, `$`, `\$`)
+
+// toVSCode converts the placeholders of content to tab stops, numbered in the order their names first appear.
+// The first placeholder of a name gets the default or choices, the others mirror it.
+func toVSCode(content string) string {
+	stops := tabStops(content)
+	vars := variables(content)
+	seen := map[string]bool{}
+
+	var result strings.Builder
+	for _, part := range model.Parts(content) {
+		if part.Placeholder == nil {
+			result.WriteString(vscodeEscaper.Replace(part.Text))
+			continue
+		}
+		name := part.Placeholder.Name
+		stop := strconv.Itoa(stops[name])
+		variable := vars[name]
+		switch {
+		case seen[name]:
+			result.WriteString("${" + stop + "}")
+		case len(variable.Choices) > 0:
+			result.WriteString("${" + stop + "|" + strings.Join(variable.Choices, ",") + "|}")
+		case variable.HasDefault:
+			result.WriteString("${" + stop + ":" + vscodeEscaper.Replace(variable.Default) + "}")
+		default:
+			result.WriteString("${" + stop + "}")
+		}
+		seen[name] = true
+	}
+	return result.String()
+}
+
+// vscodeScope returns the language identifier of VS Code for a language.
+func vscodeScope(language string) string {
+	switch normalized := lang.Normalize(language); normalized {
+	case "":
+		return strings.ToLower(strings.TrimSpace(language))
+	case "bash":
+		return "shellscript"
+	default:
+		return normalized
+	}
+}
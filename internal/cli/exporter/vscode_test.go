@@ -0,0 +1,56 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/importer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVSCode(t *testing.T) {
+	snippets := []model.Snippet{
+		{ID: "AB3CD", Title: "Run container", Description: "Runs a container", Language: "sh", Content: "docker run ${image:alpine} ${shell|sh,bash}\n# ${image:}\n"},
+		{ID: "EF4GH", Title: "Run container", Content: "echo $HOME"},
+	}
+
+	files, err := VSCode{}.Export(snippets)
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "snac.code-snippets", files[0].Name)
+	assert.JSONEq(t, `{
+		"Run container": {
+			"prefix": "run-container",
+			"body": ["docker run ${1:alpine} ${2|sh,bash|}", "# ${1}"],
+			"description": "Runs a container",
+			"scope": "shellscript"
+		},
+		"Run container (EF4GH)": {
+			"prefix": "run-container-2",
+			"body": ["echo \\$HOME"]
+		}
+	}`, string(files[0].Content))
+
+	// the importer reads what was exported
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, files[0].Name), files[0].Content, 0644))
+	result, err := importer.VSCode{}.Import(dir)
+	assert.Nil(t, err)
+	assert.Len(t, result.Items, 2)
+	assert.Equal(t, "docker run ${p1:alpine} ${p2|sh,bash}\n# ${p1:}", result.Items[0].Snippet.Content)
+	assert.Equal(t, "bash", result.Items[0].Snippet.Language)
+	assert.Equal(t, "echo $HOME", result.Items[1].Snippet.Content)
+}
+
+func TestToVSCode(t *testing.T) {
+	for content, body := range map[string]string{
+		"cost: $5 $${literal} C:\\dir":      `cost: \$5 \${literal} C:\\dir`,
+		"${a:}${b:x}${a:y}":                 "${1:y}${2:x}${1}",
+		"${price:$5 or more} and ${empty:}": `${1:\$5 or more} and ${2}`,
+		"${x|a,b} ${x:}":                    "${1|a,b|} ${1}",
+	} {
+		assert.Equal(t, body, toVSCode(content), content)
+	}
+}
@@ -0,0 +1,162 @@
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	Register("table", FormatterFunc(formatTable))
+	Register("csv", FormatterFunc(formatCSV))
+	Register("markdown", FormatterFunc(formatMarkdown))
+	Register("ndjson", FormatterFunc(formatNDJSON))
+	Register("json", FormatterFunc(formatJSON))
+	Register("yaml", FormatterFunc(formatYAML))
+}
+
+// formatTable writes aligned columns with a header.
+func formatTable(w io.Writer, data Data) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(data.Columns, "\t")))
+	for _, record := range data.Records {
+		values := make([]string, len(data.Columns))
+		for i, column := range data.Columns {
+			values[i] = singleLine(text(record[column]))
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
+
+func formatCSV(w io.Writer, data Data) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(data.Columns); err != nil {
+		return err
+	}
+	for _, record := range data.Records {
+		values := make([]string, len(data.Columns))
+		for i, column := range data.Columns {
+			values[i] = text(record[column])
+		}
+		if err := cw.Write(values); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatMarkdown(w io.Writer, data Data) error {
+	escape := strings.NewReplacer("|", `\|`)
+	fmt.Fprintf(w, "| %s |\n", strings.Join(data.Columns, " | "))
+	fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(data.Columns)))
+	for _, record := range data.Records {
+		values := make([]string, len(data.Columns))
+		for i, column := range data.Columns {
+			values[i] = escape.Replace(singleLine(text(record[column])))
+		}
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(values, " | ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatNDJSON writes one JSON object per line and record.
+func formatNDJSON(w io.Writer, data Data) error {
+	for _, record := range data.Records {
+		object, err := jsonObject(data.Columns, record)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s\n", object); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatJSON(w io.Writer, data Data) error {
+	objects := make([]json.RawMessage, len(data.Records))
+	for i, record := range data.Records {
+		object, err := jsonObject(data.Columns, record)
+		if err != nil {
+			return err
+		}
+		objects[i] = object
+	}
+
+	var value any = objects
+	if data.Single && len(objects) == 1 {
+		value = objects[0]
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// jsonObject writes the fields of record in the order of columns, which a map would not keep.
+func jsonObject(columns []string, record Record) (json.RawMessage, error) {
+	var b bytes.Buffer
+	b.WriteString("{")
+	for i, column := range columns {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		key, _ := json.Marshal(column)
+		value, err := json.Marshal(jsonValue(record[column]))
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteString(":")
+		b.Write(value)
+	}
+	b.WriteString("}")
+	return b.Bytes(), nil
+}
+
+// jsonValue keeps lists as lists but writes times like the text formats do.
+func jsonValue(value any) any {
+	if t, ok := value.(time.Time); ok {
+		return text(t)
+	}
+	if tags, ok := value.([]string); ok && tags == nil {
+		return []string{}
+	}
+	return value
+}
+
+func formatYAML(w io.Writer, data Data) error {
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, record := range data.Records {
+		object := &yaml.Node{Kind: yaml.MappingNode}
+		for _, column := range data.Columns {
+			var value yaml.Node
+			if err := value.Encode(jsonValue(record[column])); err != nil {
+				return err
+			}
+			object.Content = append(object.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: column}, &value)
+		}
+		list.Content = append(list.Content, object)
+	}
+
+	document := list
+	if data.Single && len(list.Content) == 1 {
+		document = list.Content[0]
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,112 @@
+// Package format writes the output of list, show and team show in the formats scripts and people want.
+// The field names of the records are a contract for scripts, they are only ever added to.
+package format
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+)
+
+// Record is one snippet or team, by field name.
+type Record map[string]any
+
+// Data is the output of a command: its records and their fields in order.
+type Data struct {
+	Columns []string
+	Records []Record
+	// Single is set if the command outputs one record, json and yaml then write an object instead of a list.
+	Single bool
+}
+
+// Formatter writes data to w.
+type Formatter interface {
+	Format(w io.Writer, data Data) error
+}
+
+// FormatterFunc is a function that is a Formatter.
+type FormatterFunc func(w io.Writer, data Data) error
+
+func (f FormatterFunc) Format(w io.Writer, data Data) error {
+	return f(w, data)
+}
+
+var formatters = map[string]Formatter{}
+
+// Register makes a formatter available by name, it replaces a formatter of the same name.
+func Register(name string, formatter Formatter) {
+	formatters[strings.ToLower(name)] = formatter
+}
+
+// Get returns the formatter registered as name.
+func Get(name string) (Formatter, error) {
+	formatter, ok := formatters[strings.ToLower(name)]
+	if !ok {
+		return nil, errs.Errorf(errs.ErrInvalid, "Unknown format '%s' (known formats: %s)", name, strings.Join(Names(), ", "))
+	}
+	return formatter, nil
+}
+
+// Names returns the names of all registered formatters, sorted.
+func Names() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Select returns data with only the given columns, in their order. Unknown columns are an error naming the known ones.
+func (d Data) Select(columns []string) (Data, error) {
+	if len(columns) == 0 {
+		return d, nil
+	}
+	selected := make([]string, 0, len(columns))
+	for _, column := range columns {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !contains(d.Columns, column) {
+			return d, errs.Errorf(errs.ErrInvalid, "Unknown column '%s' (known columns: %s)", column, strings.Join(d.Columns, ", "))
+		}
+		selected = append(selected, column)
+	}
+	d.Columns = selected
+	return d, nil
+}
+
+// text returns a value as a single line of text, lists are joined with commas.
+func text(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// singleLine replaces line breaks, so multi-line values do not break rows apart.
+func singleLine(value string) string {
+	return strings.NewReplacer("\r\n", "↵", "\n", "↵").Replace(value)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package format
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testData = Partials([]model.PartialSnippet{
+	{ID: "AAAAA", TeamID: "team1", Title: "Restart | deployment", Language: "bash", Tags: []string{"k8s", "ops"}},
+	{ID: "BBBBB", TeamID: "team1", Title: "Vacuum", Tags: nil},
+})
+
+func formatted(t *testing.T, name string, data Data) string {
+	t.Helper()
+	formatter, err := Get(name)
+	assert.Nil(t, err)
+	var b bytes.Buffer
+	assert.Nil(t, formatter.Format(&b, data))
+	return b.String()
+}
+
+func TestFormats(t *testing.T) {
+	data, err := testData.Select([]string{"id", "title", "tags"})
+	assert.Nil(t, err)
+
+	assert.Equal(t, "ID     TITLE                 TAGS\nAAAAA  Restart | deployment  k8s,ops\nBBBBB  Vacuum                \n", formatted(t, "table", data))
+	assert.Equal(t, "id,title,tags\nAAAAA,Restart | deployment,\"k8s,ops\"\nBBBBB,Vacuum,\n", formatted(t, "csv", data))
+	assert.Equal(t, "| id | title | tags |\n| --- | --- | --- |\n| AAAAA | Restart \\| deployment | k8s,ops |\n| BBBBB | Vacuum |  |\n", formatted(t, "MARKDOWN", data))
+	assert.Equal(t, `{"id":"AAAAA","title":"Restart | deployment","tags":["k8s","ops"]}`+"\n"+`{"id":"BBBBB","title":"Vacuum","tags":[]}`+"\n", formatted(t, "ndjson", data))
+	assert.Equal(t, "- id: AAAAA\n  title: Restart | deployment\n  tags:\n    - k8s\n    - ops\n- id: BBBBB\n  title: Vacuum\n  tags: []\n", formatted(t, "yaml", data))
+}
+
+func TestFormats_Single(t *testing.T) {
+	created := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	data := Team(model.Team{Name: "team1", DisplayName: "Team 1", Created: created, PasswordHash: "secret"}, 3)
+
+	json := formatted(t, "json", data)
+	assert.Equal(t, "{\n  \"name\": \"team1\",\n  \"display_name\": \"Team 1\",\n  \"created\": \"2024-04-01T12:00:00Z\",\n  \"last_modified\": \"\",\n  \"snippets\": 3\n}\n", json)
+	assert.NotContains(t, json, "secret")
+}
+
+func TestSelect_UnknownColumn(t *testing.T) {
+	_, err := testData.Select([]string{"id", "content"})
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+	assert.EqualError(t, err, "Unknown column 'content' (known columns: id, team, title, language, tags)")
+}
+
+func TestGet_Unknown(t *testing.T) {
+	_, err := Get("xml")
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+}
+
+func TestTemplate(t *testing.T) {
+	formatter, err := Template(`{{.id}}: {{.title}} [{{join .tags ", "}}]`)
+	assert.Nil(t, err)
+	var b bytes.Buffer
+	assert.Nil(t, formatter.Format(&b, testData))
+	assert.Equal(t, "AAAAA: Restart | deployment [k8s, ops]\nBBBBB: Vacuum []\n", b.String())
+
+	// only selected columns are available
+	data, _ := testData.Select([]string{"id"})
+	b.Reset()
+	assert.Nil(t, formatter.Format(&b, data))
+	assert.Contains(t, b.String(), "AAAAA: <no value>")
+
+	_, err = Template("{{.id")
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+}
+
+func TestTemplateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list.tmpl")
+	assert.Nil(t, os.WriteFile(path, []byte("{{.id}}\n"), 0600))
+
+	formatter, err := TemplateFile(path)
+	assert.Nil(t, err)
+	var b bytes.Buffer
+	assert.Nil(t, formatter.Format(&b, testData))
+	assert.Equal(t, "AAAAA\nBBBBB\n", b.String())
+}
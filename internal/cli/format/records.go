@@ -0,0 +1,65 @@
+package format
+
+import "github.com/snippetaccumulator/snac/internal/backend/model"
+
+// The fields of snippets and teams. These names are what scripts rely on, so they are never renamed or removed.
+var (
+	SnippetColumns = []string{"id", "team", "title", "description", "language", "tags", "content", "last_modified"}
+	PartialColumns = []string{"id", "team", "title", "language", "tags"}
+	TeamColumns    = []string{"name", "display_name", "created", "last_modified", "snippets"}
+)
+
+func snippetRecord(s model.Snippet) Record {
+	return Record{
+		"id":            s.ID.String(),
+		"team":          s.TeamID,
+		"title":         s.Title,
+		"description":   s.Description,
+		"language":      s.Language,
+		"tags":          s.Tags,
+		"content":       s.Content,
+		"last_modified": s.LastModified,
+	}
+}
+
+// Snippet returns the data of a single snippet.
+func Snippet(s model.Snippet) Data {
+	return Data{Columns: SnippetColumns, Records: []Record{snippetRecord(s)}, Single: true}
+}
+
+func Snippets(snippets []model.Snippet) Data {
+	records := make([]Record, len(snippets))
+	for i, s := range snippets {
+		records[i] = snippetRecord(s)
+	}
+	return Data{Columns: SnippetColumns, Records: records}
+}
+
+func Partials(partials []model.PartialSnippet) Data {
+	records := make([]Record, len(partials))
+	for i, p := range partials {
+		records[i] = Record{
+			"id":       p.ID.String(),
+			"team":     p.TeamID,
+			"title":    p.Title,
+			"language": p.Language,
+			"tags":     p.Tags,
+		}
+	}
+	return Data{Columns: PartialColumns, Records: records}
+}
+
+// Team returns the data of a team with the number of its snippets, never its password hashes.
+func Team(team model.Team, snippets int) Data {
+	return Data{
+		Columns: TeamColumns,
+		Records: []Record{{
+			"name":          team.Name,
+			"display_name":  team.DisplayName,
+			"created":       team.Created,
+			"last_modified": team.LastModified,
+			"snippets":      snippets,
+		}},
+		Single: true,
+	}
+}
@@ -0,0 +1,54 @@
+package format
+
+import (
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+)
+
+// templateFuncs are available in templates besides the built-in functions of text/template.
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"text":  text,
+}
+
+// Template returns a formatter that executes a text/template for every record, e.g. '{{.id}} {{.title}}'.
+// The fields of the record are accessed by name, and a line break is added after each record unless the template ends with one.
+func Template(text string) (Formatter, error) {
+	tmpl, err := template.New("format").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, errs.Errorf(errs.ErrInvalid, "Invalid template: %w", err)
+	}
+	return FormatterFunc(func(w io.Writer, data Data) error {
+		for _, record := range data.Records {
+			// only the selected columns are available
+			fields := make(Record, len(data.Columns))
+			for _, column := range data.Columns {
+				fields[column] = record[column]
+			}
+			if err := tmpl.Execute(w, fields); err != nil {
+				return errs.Errorf(errs.ErrInvalid, "Template failed: %w", err)
+			}
+			if !strings.HasSuffix(text, "\n") {
+				if _, err := io.WriteString(w, "\n"); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}), nil
+}
+
+// TemplateFile returns a Template formatter for the template in the file at path.
+func TemplateFile(path string) (Formatter, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errs.Errorf(errs.ErrInvalid, "Could not read template file: %w", err)
+	}
+	return Template(string(text))
+}
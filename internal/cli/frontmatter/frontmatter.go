@@ -0,0 +1,131 @@
+// Package frontmatter writes snippets as text with their metadata in a YAML block above the content,
+// so they can be edited as one file, and reads them back.
+package frontmatter
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"gopkg.in/yaml.v3"
+)
+
+const delimiter = "---"
+
+// Meta is the metadata of a snippet in the frontmatter.
+type Meta struct {
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description"`
+	Language    string   `yaml:"language"`
+	Tags        []string `yaml:"tags,flow"`
+}
+
+// Write returns the metadata of the snippet between two --- lines, followed by its content.
+func Write(snippet model.Snippet) (string, error) {
+	tags := snippet.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	header, err := yaml.Marshal(Meta{
+		Title:       snippet.Title,
+		Description: snippet.Description,
+		Language:    snippet.Language,
+		Tags:        tags,
+	})
+	if err != nil {
+		return "", err
+	}
+	return delimiter + "\n" + string(header) + delimiter + "\n" + snippet.Content, nil
+}
+
+// Has reports if text starts with a frontmatter.
+func Has(text string) bool {
+	line, _, _ := strings.Cut(text, "\n")
+	return strings.TrimSuffix(line, "\r") == delimiter
+}
+
+// HasMeta reports if text starts with a frontmatter with any of the keys of Meta.
+// Other blocks between --- lines, e.g. of static site generators, are not metadata of snac.
+func HasMeta(text string) bool {
+	header, _, ok := split(text)
+	if !ok {
+		return false
+	}
+	var keys map[string]any
+	if err := yaml.Unmarshal([]byte(header), &keys); err != nil {
+		return false
+	}
+	for _, key := range []string{"title", "description", "language", "tags"} {
+		if _, ok := keys[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse splits text into the metadata of its frontmatter and the content below it.
+// Unknown keys are ErrInvalid errors, so typos do not get lost silently.
+func Parse(text string) (Meta, string, error) {
+	var meta Meta
+	if !Has(text) {
+		return meta, "", errs.Errorf(errs.ErrInvalid, "Missing the %s line that starts the metadata", delimiter)
+	}
+	header, content, ok := split(text)
+	if !ok {
+		return meta, "", errs.Errorf(errs.ErrInvalid, "Missing the %s line that ends the metadata", delimiter)
+	}
+	decoder := yaml.NewDecoder(strings.NewReader(header))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&meta); err != nil && !errors.Is(err, io.EOF) {
+		return meta, "", errs.Errorf(errs.ErrInvalid, "Invalid metadata: %w", err)
+	}
+	return meta, content, nil
+}
+
+// split returns the lines between the --- lines at the top of text and the content below them.
+func split(text string) (string, string, bool) {
+	if !Has(text) {
+		return "", "", false
+	}
+	_, rest, _ := strings.Cut(text, "\n")
+
+	var header strings.Builder
+	for rest != "" {
+		line, remaining, _ := strings.Cut(rest, "\n")
+		rest = remaining
+		if strings.TrimSuffix(line, "\r") == delimiter {
+			return header.String(), rest, true
+		}
+		header.WriteString(line + "\n")
+	}
+	return "", "", false
+}
+
+// Check returns an ErrInvalid error if the metadata lacks what every snippet needs: a title and at least one tag.
+func (m Meta) Check() error {
+	if strings.TrimSpace(m.Title) == "" {
+		return errs.New(errs.ErrInvalid, "The title must not be empty")
+	}
+	for _, tag := range m.Tags {
+		if strings.TrimSpace(tag) != "" {
+			return nil
+		}
+	}
+	return errs.New(errs.ErrInvalid, "A snippet needs at least one tag")
+}
+
+// Apply returns the snippet with the metadata, empty tags are dropped.
+func (m Meta) Apply(snippet model.Snippet) model.Snippet {
+	snippet.Title = strings.TrimSpace(m.Title)
+	snippet.Description = strings.TrimSpace(m.Description)
+	snippet.Language = strings.TrimSpace(m.Language)
+	snippet.Tags = nil
+	for _, tag := range m.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			snippet.Tags = append(snippet.Tags, tag)
+		}
+	}
+	return snippet
+}
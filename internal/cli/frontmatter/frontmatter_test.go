@@ -0,0 +1,75 @@
+package frontmatter
+
+import (
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteParse(t *testing.T) {
+	snippet := model.Snippet{
+		ID:          "abcde",
+		Title:       "List pods",
+		Description: "Lists the pods: all of them",
+		Language:    "bash",
+		Tags:        []string{"k8s", "ops"},
+		Content:     "---\nkubectl get pods\n",
+	}
+
+	text, err := Write(snippet)
+	assert.Nil(t, err)
+	assert.Equal(t, "---\ntitle: List pods\ndescription: 'Lists the pods: all of them'\nlanguage: bash\ntags: [k8s, ops]\n---\n---\nkubectl get pods\n", text)
+	assert.True(t, Has(text))
+
+	// content that starts with --- is kept as is
+	meta, content, err := Parse(text)
+	assert.Nil(t, err)
+	assert.Equal(t, snippet.Content, content)
+	assert.Equal(t, snippet, meta.Apply(model.Snippet{ID: "abcde", Content: content}))
+}
+
+func TestParse(t *testing.T) {
+	meta, content, err := Parse("---\r\ntitle: ' Title '\r\ntags: [a, ' ', b]\r\n---\r\ncontent")
+	assert.Nil(t, err)
+	assert.Equal(t, "content", content)
+	snippet := meta.Apply(model.Snippet{Language: "go"})
+	assert.Equal(t, "Title", snippet.Title)
+	assert.Equal(t, "", snippet.Language)
+	assert.Equal(t, []string{"a", "b"}, snippet.Tags)
+
+	meta, content, err = Parse("---\n---\n")
+	assert.Nil(t, err)
+	assert.Equal(t, Meta{}, meta)
+	assert.Equal(t, "", content)
+
+	_, _, err = Parse("title: Title\n---\ncontent")
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+	assert.False(t, Has("title: Title\n---\ncontent"))
+
+	_, _, err = Parse("---\ntitle: Title\ncontent")
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+	assert.ErrorContains(t, err, "ends the metadata")
+
+	_, _, err = Parse("---\ntitel: Title\n---\ncontent")
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+	assert.ErrorContains(t, err, "titel")
+}
+
+func TestHasMeta(t *testing.T) {
+	assert.True(t, HasMeta("---\ntitle: Title\n---\ncontent"))
+	assert.True(t, HasMeta("---\r\ntags: [go]\r\nlayout: post\r\n---\r\ncontent"))
+	assert.False(t, HasMeta("---\nlayout: post\n---\ncontent"))
+	assert.False(t, HasMeta("---\n---\n"))
+	assert.False(t, HasMeta("---\n- a list\n---\ncontent"))
+	assert.False(t, HasMeta("---\ntitle: Title\ncontent"))
+	assert.False(t, HasMeta("title: Title\n---\ncontent"))
+}
+
+func TestCheck(t *testing.T) {
+	assert.Nil(t, Meta{Title: "Title", Tags: []string{"go"}}.Check())
+	assert.ErrorIs(t, Meta{Title: " ", Tags: []string{"go"}}.Check(), errs.ErrInvalid)
+	assert.ErrorContains(t, Meta{Title: "Title", Tags: []string{" "}}.Check(), "at least one tag")
+	assert.ErrorContains(t, Meta{Title: "Title"}.Check(), "at least one tag")
+}
@@ -0,0 +1,79 @@
+package importer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/snippetaccumulator/snac/internal/cli/frontmatter"
+	"github.com/snippetaccumulator/snac/internal/cli/lang"
+)
+
+// MaxFileSize is the size above which files of a directory are skipped, they are hardly snippets.
+const MaxFileSize = 1 << 20
+
+// Directory imports every file of a folder as a snippet, named after the file, with the names
+// of the folders it is in as tags. A frontmatter at the top of a file, as snac edit writes it, sets the metadata.
+// Blocks between --- lines without any key of snac, e.g. of static site generators, are part of the content.
+type Directory struct{}
+
+func (Directory) Name() string {
+	return "directory"
+}
+
+// Detect reads anything, so Directory is the last importer asked.
+func (Directory) Detect(path string) bool {
+	return true
+}
+
+func (Directory) Import(root string) (Result, error) {
+	var result Result
+	err := walk(root, func(path string) bool { return true }, func(path string) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.Size() > MaxFileSize {
+			result.Skipped = append(result.Skipped, Skip{Source: path, Reason: "larger than 1 MiB"})
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+			result.Skipped = append(result.Skipped, Skip{Source: path, Reason: "not a text file"})
+			return nil
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			result.Skipped = append(result.Skipped, Skip{Source: path, Reason: "empty"})
+			return nil
+		}
+
+		name := filepath.Base(path)
+		snippet := newSnippet(strings.TrimSuffix(name, filepath.Ext(name)), "", lang.FromFilename(name), string(data), folderTags(root, path))
+		if frontmatter.HasMeta(string(data)) {
+			meta, content, err := frontmatter.Parse(string(data))
+			if err != nil {
+				result.Skipped = append(result.Skipped, Skip{Source: path, Reason: err.Error()})
+				return nil
+			}
+			snippet = meta.Apply(snippet)
+			snippet.Content = content
+			if snippet.Title == "" {
+				snippet.Title = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if len(snippet.Tags) == 0 {
+				snippet.Tags = folderTags(root, path)
+			}
+			if snippet.Language == "" {
+				snippet.Language = lang.FromFilename(name)
+			}
+		}
+		result.Items = append(result.Items, Item{Source: path, Snippet: snippet})
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,59 @@
+package importer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectory(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"k8s/prod/restart.sh": "kubectl rollout restart deployment/${name}\n",
+		"query.sql":           "---\ntitle: Active users\ntags: [reports]\n---\nSELECT * FROM users WHERE active\n",
+		"empty.txt":           " \n",
+		"image.png":           "\x89PNG\x00\x00",
+		".git/config":         "[core]",
+	})
+
+	result, err := Directory{}.Import(root)
+	assert.Nil(t, err)
+	assert.Len(t, result.Items, 2)
+
+	snippet := result.Items[0].Snippet
+	assert.Equal(t, "restart", snippet.Title)
+	assert.Equal(t, "bash", snippet.Language)
+	assert.Equal(t, []string{"k8s", "prod"}, snippet.Tags)
+	assert.Equal(t, "kubectl rollout restart deployment/${name}\n", snippet.Content)
+
+	snippet = result.Items[1].Snippet
+	assert.Equal(t, "Active users", snippet.Title)
+	assert.Equal(t, "sql", snippet.Language)
+	assert.Equal(t, []string{"reports"}, snippet.Tags)
+	assert.Equal(t, "SELECT * FROM users WHERE active\n", snippet.Content)
+
+	assert.Equal(t, []Skip{
+		{Source: filepath.Join(root, "empty.txt"), Reason: "empty"},
+		{Source: filepath.Join(root, "image.png"), Reason: "not a text file"},
+	}, result.Skipped)
+
+	// a single file can be imported too
+	result, err = Directory{}.Import(filepath.Join(root, "k8s", "prod", "restart.sh"))
+	assert.Nil(t, err)
+	assert.Len(t, result.Items, 1)
+	assert.Empty(t, result.Items[0].Snippet.Tags)
+}
+
+func TestDirectory_ForeignFrontmatter(t *testing.T) {
+	post := "---\nlayout: post\ndate: 2024-01-01\n---\n# Hello\n"
+	root := writeFiles(t, map[string]string{
+		"post.md": post,
+	})
+
+	result, err := Directory{}.Import(root)
+	assert.Nil(t, err)
+	assert.Empty(t, result.Skipped)
+	assert.Len(t, result.Items, 1)
+	assert.Equal(t, "post", result.Items[0].Snippet.Title)
+	assert.Equal(t, post, result.Items[0].Snippet.Content)
+}
@@ -0,0 +1,193 @@
+// Package importer reads snippets from the files of editors and other tools.
+// Importers are registered by name, so the import command can pick one or detect it from the files.
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+)
+
+// Importer reads the snippets of a file or a directory of files in its format.
+type Importer interface {
+	Name() string
+	// Detect reports if path is in the format of the importer.
+	Detect(path string) bool
+	Import(path string) (Result, error)
+}
+
+// Result is what an importer found.
+type Result struct {
+	Items   []Item
+	Skipped []Skip
+}
+
+// Item is an imported snippet and where it comes from. The snippet has no team yet.
+type Item struct {
+	Source  string
+	Snippet model.Snippet
+}
+
+// Skip is something that was not imported, and why.
+type Skip struct {
+	Source string
+	Reason string
+}
+
+// Add appends the items and skips of other.
+func (r *Result) Add(other Result) {
+	r.Items = append(r.Items, other.Items...)
+	r.Skipped = append(r.Skipped, other.Skipped...)
+}
+
+var importers []Importer
+
+// Register adds an importer, replacing one with the same name. Detect asks them in the order they are registered.
+func Register(importer Importer) {
+	for i, existing := range importers {
+		if existing.Name() == importer.Name() {
+			importers[i] = importer
+			return
+		}
+	}
+	importers = append(importers, importer)
+}
+
+// Get returns the importer with the name, or an ErrInvalid error naming the known ones.
+func Get(name string) (Importer, error) {
+	for _, importer := range importers {
+		if importer.Name() == name {
+			return importer, nil
+		}
+	}
+	return nil, errs.Errorf(errs.ErrInvalid, "Unknown importer '%s', use one of: %s", name, strings.Join(Names(), ", "))
+}
+
+// Names returns the names of the registered importers, sorted.
+func Names() []string {
+	names := make([]string, 0, len(importers))
+	for _, importer := range importers {
+		names = append(names, importer.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Detect returns the first importer that reads path.
+func Detect(path string) (Importer, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	for _, importer := range importers {
+		if importer.Detect(path) {
+			return importer, nil
+		}
+	}
+	return nil, errs.Errorf(errs.ErrInvalid, "No importer reads %s, choose one of: %s", path, strings.Join(Names(), ", "))
+}
+
+func init() {
+	Register(VSCode{})
+	Register(JetBrains{})
+	Register(Directory{})
+}
+
+// Hash returns a hash of content that ignores line endings and trailing white space,
+// which editors tend to change, so copies of a snippet are recognized.
+func Hash(content string) string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	sum := sha256.Sum256([]byte(strings.TrimRight(strings.Join(lines, "\n"), "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Dedupe drops the items whose content is already in existing, which holds titles by the Hash of their content,
+// or in an earlier item, and returns them as skipped.
+func Dedupe(items []Item, existing map[string]string) ([]Item, []Skip) {
+	seen := map[string]string{}
+	for hash, title := range existing {
+		seen[hash] = title
+	}
+
+	var unique []Item
+	var duplicates []Skip
+	for _, item := range items {
+		hash := Hash(item.Snippet.Content)
+		if title, ok := seen[hash]; ok {
+			duplicates = append(duplicates, Skip{Source: item.Source, Reason: "same content as '" + title + "'"})
+			continue
+		}
+		seen[hash] = item.Snippet.Title
+		unique = append(unique, item)
+	}
+	return unique, duplicates
+}
+
+// walk calls fn for the files below root that match, skipping hidden files and directories.
+// A root that is a file is passed to fn if it matches.
+func walk(root string, match func(path string) bool, fn func(path string) error) error {
+	return filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !match(path) {
+			return nil
+		}
+		return fn(path)
+	})
+}
+
+// folderTags returns the names of the folders between root and the file at path, as tags.
+func folderTags(root, path string) []string {
+	relative, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil || relative == "." {
+		return nil
+	}
+	var tags []string
+	for _, folder := range strings.Split(filepath.ToSlash(relative), "/") {
+		if folder != "" && folder != ".." {
+			tags = append(tags, folder)
+		}
+	}
+	return tags
+}
+
+// newSnippet builds a snippet without a team, which is set when it is inserted.
+func newSnippet(title, description, language, content string, tags []string) model.Snippet {
+	return model.NewSnippetBuilder(strings.TrimSpace(title), "").
+		WithDescription(strings.TrimSpace(description)).
+		WithLanguage(language).
+		WithTags(tags).
+		WithContent(content).
+		Build()
+}
+
+// isDir reports if path is a directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// containsMatch reports if there is a file below root that matches.
+func containsMatch(root string, match func(path string) bool) bool {
+	found := false
+	_ = walk(root, match, func(path string) error {
+		found = true
+		return filepath.SkipAll
+	})
+	return found
+}
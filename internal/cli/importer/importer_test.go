@@ -0,0 +1,77 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFiles creates the files below a temporary directory and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return root
+}
+
+func TestGet(t *testing.T) {
+	importer, err := Get("vscode")
+	assert.Nil(t, err)
+	assert.Equal(t, "vscode", importer.Name())
+
+	_, err = Get("sublime")
+	assert.ErrorIs(t, err, errs.ErrInvalid)
+	assert.ErrorContains(t, err, "directory, jetbrains, vscode")
+}
+
+func TestDetect(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"vscode/docker.code-snippets": "{}",
+		"jetbrains/Go.xml":            `<templateSet group="Go"></templateSet>`,
+		"scripts/cleanup.sh":          "rm -rf /tmp/cache",
+		"scripts/config.xml":          "<config></config>",
+	})
+
+	for path, name := range map[string]string{
+		"vscode":                      "vscode",
+		"vscode/docker.code-snippets": "vscode",
+		"jetbrains":                   "jetbrains",
+		"jetbrains/Go.xml":            "jetbrains",
+		"scripts":                     "directory",
+		"scripts/config.xml":          "directory",
+	} {
+		importer, err := Detect(filepath.Join(root, path))
+		assert.Nil(t, err)
+		assert.Equal(t, name, importer.Name(), path)
+	}
+
+	_, err := Detect(filepath.Join(root, "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestDedupe(t *testing.T) {
+	items := []Item{
+		{Source: "a", Snippet: model.Snippet{Title: "A", Content: "echo a\n"}},
+		{Source: "b", Snippet: model.Snippet{Title: "B", Content: "echo a  \r\n\r\n"}},
+		{Source: "c", Snippet: model.Snippet{Title: "C", Content: "echo c"}},
+		{Source: "d", Snippet: model.Snippet{Title: "D", Content: "echo d"}},
+	}
+	existing := map[string]string{Hash("echo d\n"): "Existing"}
+
+	unique, duplicates := Dedupe(items, existing)
+	assert.Equal(t, []Item{items[0], items[2]}, unique)
+	assert.Equal(t, []Skip{
+		{Source: "b", Reason: "same content as 'A'"},
+		{Source: "d", Reason: "same content as 'Existing'"},
+	}, duplicates)
+
+	assert.NotEqual(t, Hash("echo a"), Hash("echo b"))
+	assert.NotEqual(t, Hash("  indented"), Hash("indented"))
+}
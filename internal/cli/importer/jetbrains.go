@@ -0,0 +1,231 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/lang"
+)
+
+// JetBrains imports the live templates of JetBrains IDEs, the XML files in the templates folder of the IDE settings.
+// Variables become placeholders, with defaults from their default values and choices from enum expressions.
+type JetBrains struct{}
+
+type templateSet struct {
+	XMLName   xml.Name            `xml:"templateSet"`
+	Group     string              `xml:"group,attr"`
+	Templates []jetbrainsTemplate `xml:"template"`
+}
+
+type jetbrainsTemplate struct {
+	Name        string `xml:"name,attr"`
+	Value       string `xml:"value,attr"`
+	Description string `xml:"description,attr"`
+	Variables   []struct {
+		Name         string `xml:"name,attr"`
+		Expression   string `xml:"expression,attr"`
+		DefaultValue string `xml:"defaultValue,attr"`
+	} `xml:"variable"`
+	Options []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	} `xml:"context>option"`
+}
+
+func (JetBrains) Name() string {
+	return "jetbrains"
+}
+
+func (JetBrains) Detect(path string) bool {
+	if isDir(path) {
+		return containsMatch(path, isTemplateSet)
+	}
+	return isTemplateSet(path)
+}
+
+// isTemplateSet reports if the file is XML with a templateSet at its top.
+func isTemplateSet(path string) bool {
+	if !strings.EqualFold(filepath.Ext(path), ".xml") {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local == "templateSet"
+		}
+	}
+}
+
+func (j JetBrains) Import(root string) (Result, error) {
+	var result Result
+	err := walk(root, func(path string) bool {
+		return strings.EqualFold(filepath.Ext(path), ".xml")
+	}, func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		result.Add(j.importFile(path, folderTags(root, path), data))
+		return nil
+	})
+	return result, err
+}
+
+func (JetBrains) importFile(path string, tags []string, data []byte) Result {
+	var result Result
+	var set templateSet
+	if err := xml.Unmarshal(data, &set); err != nil {
+		result.Skipped = append(result.Skipped, Skip{Source: path, Reason: fmt.Sprintf("not a template set: %v", err)})
+		return result
+	}
+	if set.Group != "" {
+		tags = append(tags, set.Group)
+	}
+
+	for _, template := range set.Templates {
+		source := path + "#" + template.Name
+		if strings.TrimSpace(template.Value) == "" {
+			result.Skipped = append(result.Skipped, Skip{Source: source, Reason: "no text"})
+			continue
+		}
+		variables := map[string]model.Placeholder{}
+		for _, variable := range template.Variables {
+			placeholder := model.Placeholder{Name: variable.Name}
+			if choices := enumChoices(variable.Expression); len(choices) > 0 {
+				placeholder.Choices = choices
+			}
+			if value, ok := quoted(variable.DefaultValue); ok {
+				placeholder.Default, placeholder.HasDefault = value, true
+			} else if value, ok := quoted(variable.Expression); ok {
+				placeholder.Default, placeholder.HasDefault = value, true
+			}
+			variables[variable.Name] = placeholder
+		}
+
+		language := ""
+		for _, option := range template.Options {
+			if option.Value == "true" {
+				if language = jetbrainsLanguage(option.Name); language != "" {
+					break
+				}
+			}
+		}
+		result.Items = append(result.Items, Item{
+			Source:  source,
+			Snippet: newSnippet(template.Name, template.Description, language, fromJetBrains(template.Value, variables), append([]string(nil), tags...)),
+		})
+	}
+	return result
+}
+
+// fromJetBrains converts the $NAME$ variables of a live template to snac placeholders.
+// $END$ and $SELECTION$ are dropped, $$ is a literal $.
+func fromJetBrains(value string, variables map[string]model.Placeholder) string {
+	var result strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' {
+			result.WriteByte(value[i])
+			continue
+		}
+		if i+1 < len(value) && value[i+1] == '$' {
+			i++
+			result.WriteString(escapeDollar(value, i))
+			continue
+		}
+		end := strings.IndexByte(value[i+1:], '$')
+		name := ""
+		if end >= 0 {
+			name = value[i+1 : i+1+end]
+		}
+		if !placeholderName.MatchString(name) {
+			result.WriteString(escapeDollar(value, i))
+			continue
+		}
+		i += end + 1
+		if name == "END" || name == "SELECTION" {
+			continue
+		}
+		result.WriteString(snacPlaceholder(name, variables[name]))
+	}
+	return result.String()
+}
+
+var placeholderName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// escapeDollar returns the $ at i of value, escaped if it would start a snac placeholder.
+func escapeDollar(value string, i int) string {
+	if i+1 < len(value) && value[i+1] == '{' {
+		return "$$"
+	}
+	return "$"
+}
+
+func snacPlaceholder(name string, variable model.Placeholder) string {
+	placeholder := "${" + name
+	if variable.HasDefault && (len(variable.Choices) == 0 || variable.Check(variable.Default) == nil) {
+		placeholder += ":" + strings.NewReplacer("|", "", "}", "").Replace(variable.Default)
+	} else if len(variable.Choices) == 0 {
+		// an empty default keeps it a placeholder, ${name} alone is left to shell scripts
+		placeholder += ":"
+	}
+	if len(variable.Choices) > 0 {
+		placeholder += "|" + strings.Join(variable.Choices, ",")
+	}
+	return placeholder + "}"
+}
+
+var enumPattern = regexp.MustCompile(`^\s*enum\((.*)\)\s*$`)
+var quotedPattern = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+
+// enumChoices returns the quoted values of an enum("a", "b") expression.
+func enumChoices(expression string) []string {
+	match := enumPattern.FindStringSubmatch(expression)
+	if match == nil {
+		return nil
+	}
+	var choices []string
+	for _, value := range quotedPattern.FindAllStringSubmatch(match[1], -1) {
+		if choice := strings.NewReplacer(",", "", "|", "", "}", "").Replace(value[1]); choice != "" {
+			choices = append(choices, choice)
+		}
+	}
+	return choices
+}
+
+// quoted returns the string of an expression that is only a string literal.
+func quoted(expression string) (string, bool) {
+	expression = strings.TrimSpace(expression)
+	if len(expression) < 2 || expression[0] != '"' || expression[len(expression)-1] != '"' {
+		return "", false
+	}
+	return strings.ReplaceAll(expression[1:len(expression)-1], `\"`, `"`), true
+}
+
+// jetbrainsLanguage returns the language of a context option like GO, JAVA_CODE or SHELL_SCRIPT.
+func jetbrainsLanguage(context string) string {
+	context = strings.ToLower(context)
+	switch {
+	case strings.HasPrefix(context, "java_script"), strings.HasPrefix(context, "js_"):
+		return "javascript"
+	case strings.HasPrefix(context, "shell_script"):
+		return "bash"
+	case strings.HasPrefix(context, "typescript"), strings.HasPrefix(context, "ts_"):
+		return "typescript"
+	}
+	name, _, _ := strings.Cut(context, "_")
+	return lang.Normalize(name)
+}
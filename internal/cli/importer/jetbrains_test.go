@@ -0,0 +1,48 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJetBrains(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"templates/Kubernetes.xml": `<templateSet group="Kubernetes">
+  <template name="kget" value="kubectl -n $NS$ get $KIND$ $END$" description="Get resources" toReformat="false" toShortenFQNames="true">
+    <variable name="NS" expression="" defaultValue="&quot;default&quot;" alwaysStopAt="true" />
+    <variable name="KIND" expression="enum(&quot;pods&quot;, &quot;nodes&quot;)" defaultValue="" alwaysStopAt="true" />
+    <context>
+      <option name="SHELL_SCRIPT" value="true" />
+    </context>
+  </template>
+  <template name="empty" value="" description="" />
+</templateSet>`,
+	})
+
+	result, err := JetBrains{}.Import(root)
+	assert.Nil(t, err)
+	assert.Len(t, result.Items, 1)
+
+	snippet := result.Items[0].Snippet
+	assert.Equal(t, "kget", snippet.Title)
+	assert.Equal(t, "Get resources", snippet.Description)
+	assert.Equal(t, "bash", snippet.Language)
+	assert.Equal(t, []string{"templates", "Kubernetes"}, snippet.Tags)
+	assert.Equal(t, "kubectl -n ${NS:default} get ${KIND|pods,nodes} ", snippet.Content)
+
+	assert.Equal(t, []Skip{{Source: result.Items[0].Source[:len(result.Items[0].Source)-len("kget")] + "empty", Reason: "no text"}}, result.Skipped)
+}
+
+func TestFromJetBrains(t *testing.T) {
+	variables := map[string]model.Placeholder{"VAR": {Name: "VAR", Default: "x", HasDefault: true}}
+	assert.Equal(t, "echo ${VAR:x} $5 $${HOME} $SELECTION", fromJetBrains("echo $VAR$ $$5 $${HOME} $$SELECTION", variables))
+	assert.Equal(t, "cost: $ 5 $ and", fromJetBrains("cost: $ 5 $ and", nil))
+	assert.Equal(t, "for ${i:} := 0", fromJetBrains("for $i$ := 0$END$", nil))
+
+	assert.Equal(t, "go", jetbrainsLanguage("GO"))
+	assert.Equal(t, "java", jetbrainsLanguage("JAVA_CODE"))
+	assert.Equal(t, "javascript", jetbrainsLanguage("JAVA_SCRIPT"))
+	assert.Equal(t, "", jetbrainsLanguage("OTHER"))
+}
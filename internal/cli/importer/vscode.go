@@ -0,0 +1,274 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/lang"
+)
+
+// VSCode imports the snippets of VS Code, from .code-snippets files and the <language>.json files of user snippets.
+// Tab stops become placeholders named p1, p2 and so on, variables like TM_FILENAME keep their names.
+type VSCode struct{}
+
+type vscodeSnippet struct {
+	Prefix      stringOrList `json:"prefix"`
+	Body        stringOrList `json:"body"`
+	Description string       `json:"description"`
+	Scope       string       `json:"scope"`
+}
+
+// stringOrList is a JSON value that is a string or a list of strings.
+type stringOrList []string
+
+func (s *stringOrList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = []string{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+func (VSCode) Name() string {
+	return "vscode"
+}
+
+func (VSCode) Detect(path string) bool {
+	if isDir(path) {
+		return containsMatch(path, isCodeSnippets)
+	}
+	return isCodeSnippets(path)
+}
+
+func isCodeSnippets(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".code-snippets")
+}
+
+func isVSCodeFile(path string) bool {
+	return isCodeSnippets(path) || strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+func (v VSCode) Import(root string) (Result, error) {
+	var result Result
+	err := walk(root, isVSCodeFile, func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		result.Add(v.importFile(path, folderTags(root, path), data))
+		return nil
+	})
+	return result, err
+}
+
+func (VSCode) importFile(path string, tags []string, data []byte) Result {
+	var result Result
+	var snippets map[string]vscodeSnippet
+	if err := json.Unmarshal(stripJSONC(data), &snippets); err != nil {
+		result.Skipped = append(result.Skipped, Skip{Source: path, Reason: fmt.Sprintf("not a snippets file: %v", err)})
+		return result
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	// user snippets are named after their language, other files after their topic
+	fileLanguage := ""
+	if !isCodeSnippets(path) {
+		fileLanguage = lang.Normalize(name)
+	} else {
+		tags = append(tags, name)
+	}
+
+	titles := make([]string, 0, len(snippets))
+	for title := range snippets {
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+	for _, title := range titles {
+		snippet := snippets[title]
+		source := path + "#" + title
+		if len(snippet.Body) == 0 {
+			result.Skipped = append(result.Skipped, Skip{Source: source, Reason: "no body"})
+			continue
+		}
+		language := fileLanguage
+		if scope, _, _ := strings.Cut(snippet.Scope, ","); language == "" && scope != "" {
+			language = lang.Normalize(scope)
+		}
+		content := fromVSCode(strings.Join(snippet.Body, "\n"))
+		result.Items = append(result.Items, Item{
+			Source:  source,
+			Snippet: newSnippet(title, snippet.Description, language, content, append([]string(nil), tags...)),
+		})
+	}
+	return result
+}
+
+// fromVSCode converts the tab stops, placeholders, choices and variables of a VS Code snippet body to snac placeholders.
+// $0, the final cursor position, is dropped.
+func fromVSCode(body string) string {
+	var result strings.Builder
+	runes := []rune(body)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes) && strings.ContainsRune(`$}\`, runes[i+1]):
+			i++
+			result.WriteString(literal(runes, i))
+		case r == '$' && i+1 < len(runes) && runes[i+1] == '{':
+			end := closingBrace(runes, i+2)
+			if end < 0 {
+				result.WriteString(literal(runes, i))
+				continue
+			}
+			result.WriteString(vscodePlaceholder(string(runes[i+2 : end])))
+			i = end
+		case r == '$' && i+1 < len(runes) && isNameRune(runes[i+1]):
+			// tab stops are numbers, variables are names
+			valid := isNameRune
+			if isDigit(runes[i+1]) {
+				valid = isDigit
+			}
+			end := i + 1
+			for end < len(runes) && valid(runes[end]) {
+				end++
+			}
+			result.WriteString(vscodePlaceholder(string(runes[i+1 : end])))
+			i = end - 1
+		default:
+			result.WriteString(literal(runes, i))
+		}
+	}
+	return result.String()
+}
+
+// literal returns the rune at i, a $ before a { is escaped, so snac does not take it for a placeholder.
+func literal(runes []rune, i int) string {
+	if runes[i] == '$' && i+1 < len(runes) && runes[i+1] == '{' {
+		return "$$"
+	}
+	return string(runes[i])
+}
+
+// vscodePlaceholder converts the inside of ${...}, or the name of $name.
+func vscodePlaceholder(inner string) string {
+	name, rest := inner, ""
+	if i := strings.IndexAny(inner, ":|"); i >= 0 {
+		name, rest = inner[:i], inner[i:]
+	}
+	if name == "0" {
+		return plainDefault(strings.TrimPrefix(rest, ":"))
+	}
+	if name != "" && isDigit(rune(name[0])) {
+		name = "p" + name
+	}
+	switch {
+	case strings.HasPrefix(rest, "|"):
+		choices := strings.TrimSuffix(strings.TrimPrefix(rest, "|"), "|")
+		return "${" + name + "|" + choices + "}"
+	case strings.HasPrefix(rest, ":"):
+		return "${" + name + ":" + plainDefault(rest[1:]) + "}"
+	}
+	// an empty default keeps it a placeholder, ${name} alone is left to shell scripts
+	return "${" + name + ":}"
+}
+
+// plainDefault returns a default with its nested placeholders replaced by their own defaults,
+// as snac placeholders cannot be nested.
+func plainDefault(value string) string {
+	converted := fromVSCode(value)
+	empty := map[string]string{}
+	for _, variable := range model.Variables(converted) {
+		if !variable.HasDefault {
+			empty[variable.Name] = ""
+		}
+	}
+	filled, err := model.Fill(converted, empty)
+	if err != nil {
+		filled = value
+	}
+	return strings.NewReplacer("|", "", "}", "").Replace(filled)
+}
+
+// closingBrace returns the index of the } that closes a { before start, -1 if there is none.
+func closingBrace(runes []rune, start int) int {
+	depth := 1
+	for i := start; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isNameRune(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || isDigit(r)
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// stripJSONC removes the comments and trailing commas VS Code allows in its JSON files.
+func stripJSONC(data []byte) []byte {
+	var result []byte
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			result = append(result, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				result = append(result, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			result = append(result, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == '}' || c == ']':
+			// drop a comma before the closing bracket, with only white space in between
+			end := len(result) - 1
+			for end >= 0 && strings.ContainsRune(" \t\r\n", rune(result[end])) {
+				end--
+			}
+			if end >= 0 && result[end] == ',' {
+				result = append(result[:end], result[end+1:]...)
+			}
+			result = append(result, c)
+		default:
+			result = append(result, c)
+		}
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package importer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVSCode(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"ops/docker.code-snippets": `{
+	// comments and trailing commas are allowed
+	"Run container": {
+		"prefix": ["drun", "dr"],
+		"body": ["docker run --rm -it ${1:alpine} ${2|sh,bash|}", "$0"],
+		"description": "Runs a container /* not a comment */",
+		"scope": "shellscript,bash",
+	},
+	"Empty": {"prefix": "e", "body": []},
+}`,
+		"go.json":     `{"main": {"prefix": "main", "body": "func main() {\n\t$1\n}"}}`,
+		"broken.json": `{"broken":`,
+	})
+
+	result, err := VSCode{}.Import(root)
+	assert.Nil(t, err)
+	assert.Len(t, result.Items, 2)
+
+	snippet := result.Items[0].Snippet
+	assert.Equal(t, "main", snippet.Title)
+	assert.Equal(t, "go", snippet.Language)
+	assert.Empty(t, snippet.Tags)
+	assert.Equal(t, "func main() {\n\t${p1:}\n}", snippet.Content)
+
+	snippet = result.Items[1].Snippet
+	assert.Equal(t, filepath.Join(root, "ops", "docker.code-snippets")+"#Run container", result.Items[1].Source)
+	assert.Equal(t, "Run container", snippet.Title)
+	assert.Equal(t, "Runs a container /* not a comment */", snippet.Description)
+	assert.Equal(t, "bash", snippet.Language)
+	assert.Equal(t, []string{"ops", "docker"}, snippet.Tags)
+	assert.Equal(t, "docker run --rm -it ${p1:alpine} ${p2|sh,bash}\n", snippet.Content)
+	assert.NotEmpty(t, snippet.ID)
+
+	assert.Len(t, result.Skipped, 2)
+	assert.Equal(t, filepath.Join(root, "broken.json"), result.Skipped[0].Source)
+	assert.Equal(t, "no body", result.Skipped[1].Reason)
+}
+
+func TestFromVSCode(t *testing.T) {
+	for body, content := range map[string]string{
+		"ssh $1@${2:host}$0":           "ssh ${p1:}@${p2:host}",
+		"echo $TM_FILENAME ${USER:me}": "echo ${TM_FILENAME:} ${USER:me}",
+		"${1:outer ${2:inner}} $2":     "${p1:outer inner} ${p2:}",
+		`cost: \$5 \${literal\}`:       "cost: $5 $${literal}",
+		"$1abc":                        "${p1:}abc",
+		"echo $ 100% ${unclosed":       "echo $ 100% $${unclosed",
+	} {
+		assert.Equal(t, content, fromVSCode(body), body)
+	}
+}
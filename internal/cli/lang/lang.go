@@ -0,0 +1,106 @@
+// Package lang knows the file extensions of common languages and their other names, for editors and highlighting.
+package lang
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// languageExtensions lists the file extensions of common languages, the first one is used for new files.
+var languageExtensions = map[string][]string{
+	"bash":       {".sh", ".bash"},
+	"c":          {".c", ".h"},
+	"cpp":        {".cpp", ".cc", ".cxx", ".hpp"},
+	"csharp":     {".cs"},
+	"css":        {".css"},
+	"dockerfile": {".dockerfile"},
+	"go":         {".go"},
+	"html":       {".html", ".htm"},
+	"java":       {".java"},
+	"javascript": {".js", ".mjs", ".cjs"},
+	"json":       {".json"},
+	"kotlin":     {".kt", ".kts"},
+	"lua":        {".lua"},
+	"makefile":   {".mk"},
+	"markdown":   {".md", ".markdown"},
+	"php":        {".php"},
+	"powershell": {".ps1"},
+	"python":     {".py"},
+	"ruby":       {".rb"},
+	"rust":       {".rs"},
+	"sql":        {".sql"},
+	"swift":      {".swift"},
+	"toml":       {".toml"},
+	"typescript": {".ts", ".tsx"},
+	"xml":        {".xml"},
+	"yaml":       {".yaml", ".yml"},
+}
+
+// languageAliases maps other common names of languages to the ones of languageExtensions.
+var languageAliases = map[string]string{
+	"c++":         "cpp",
+	"c#":          "csharp",
+	"docker":      "dockerfile",
+	"golang":      "go",
+	"js":          "javascript",
+	"md":          "markdown",
+	"make":        "makefile",
+	"ps":          "powershell",
+	"py":          "python",
+	"sh":          "bash",
+	"shell":       "bash",
+	"shellscript": "bash",
+	"ts":          "typescript",
+	"yml":         "yaml",
+	"zsh":         "bash",
+	"postgresql":  "sql",
+}
+
+// Extension returns the file extension for content of language, .txt if the language is unknown.
+func Extension(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if alias, ok := languageAliases[language]; ok {
+		language = alias
+	}
+	if extensions, ok := languageExtensions[language]; ok {
+		return extensions[0]
+	}
+	return ".txt"
+}
+
+// Normalize returns the name snac uses for a language given by another name, e.g. golang for go.
+// It returns "" if the language is unknown.
+func Normalize(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if alias, ok := languageAliases[language]; ok {
+		return alias
+	}
+	if _, ok := languageExtensions[language]; ok {
+		return language
+	}
+	return ""
+}
+
+// FromFilename returns the language of a file by its extension or name, "" if it is unknown.
+func FromFilename(filename string) string {
+	base := strings.ToLower(filepath.Base(filename))
+	switch base {
+	case "dockerfile":
+		return "dockerfile"
+	case "makefile":
+		return "makefile"
+	}
+
+	extension := filepath.Ext(base)
+	if extension == "" {
+		return ""
+	}
+	for language, extensions := range languageExtensions {
+		for _, e := range extensions {
+			if e == extension {
+				return language
+			}
+		}
+	}
+	return ""
+}
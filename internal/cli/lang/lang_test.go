@@ -0,0 +1,31 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtension(t *testing.T) {
+	assert.Equal(t, ".go", Extension("go"))
+	assert.Equal(t, ".go", Extension(" Golang "))
+	assert.Equal(t, ".sh", Extension("shell"))
+	assert.Equal(t, ".txt", Extension(""))
+	assert.Equal(t, ".txt", Extension("brainfuck"))
+}
+
+func TestFromFilename(t *testing.T) {
+	assert.Equal(t, "python", FromFilename("scripts/cleanup.py"))
+	assert.Equal(t, "yaml", FromFilename("deploy.YML"))
+	assert.Equal(t, "dockerfile", FromFilename("build/Dockerfile"))
+	assert.Equal(t, "", FromFilename("notes"))
+	assert.Equal(t, "", FromFilename("archive.xyz"))
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "go", Normalize("Go"))
+	assert.Equal(t, "go", Normalize("golang"))
+	assert.Equal(t, "bash", Normalize("shellscript"))
+	assert.Equal(t, "", Normalize("brainfuck"))
+	assert.Equal(t, "", Normalize(""))
+}
@@ -0,0 +1,74 @@
+// Package picker finds the snippet a user means by a query, or lets them pick it.
+package picker
+
+import (
+	"strings"
+
+	"github.com/sahilm/fuzzy"
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+)
+
+// source lets fuzzy search the title, tags and language of snippets at once.
+type source []model.PartialSnippet
+
+func (s source) String(i int) string {
+	return s[i].Title + " " + strings.Join(s[i].Tags, " ") + " " + s[i].Language
+}
+
+func (s source) Len() int {
+	return len(s)
+}
+
+// Match returns the snippets matching query fuzzily on title, tags and language, the best match first.
+// An empty query matches all snippets in their order.
+func Match(partials []model.PartialSnippet, query string) []model.PartialSnippet {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return partials
+	}
+
+	matches := fuzzy.FindFrom(query, source(partials))
+	result := make([]model.PartialSnippet, len(matches))
+	for i, match := range matches {
+		result[i] = partials[match.Index]
+	}
+	return result
+}
+
+// Resolve returns the snippet meant by query, which is its ID, its title or a part of it.
+// If several snippets match equally well, it returns them as candidates to pick from instead.
+func Resolve(partials []model.PartialSnippet, query string) (model.PartialSnippet, []model.PartialSnippet, error) {
+	query = strings.TrimSpace(query)
+	for _, partial := range partials {
+		if strings.EqualFold(partial.ID.String(), query) {
+			return partial, nil, nil
+		}
+	}
+
+	var titles []model.PartialSnippet
+	for _, partial := range partials {
+		if strings.EqualFold(partial.Title, query) {
+			titles = append(titles, partial)
+		}
+	}
+	candidates := titles
+	if len(titles) == 0 {
+		candidates = Match(partials, query)
+	}
+
+	switch len(candidates) {
+	case 0:
+		return model.PartialSnippet{}, nil, errs.Errorf(errs.ErrNotFound, "No snippet matches '%s'", query)
+	case 1:
+		return candidates[0], nil, nil
+	default:
+		return model.PartialSnippet{}, candidates, nil
+	}
+}
+
+// Exact reports if query is the ID or the title of the snippet, apart from case, and not just a fuzzy match.
+func Exact(partial model.PartialSnippet, query string) bool {
+	query = strings.TrimSpace(query)
+	return strings.EqualFold(partial.ID.String(), query) || strings.EqualFold(partial.Title, query)
+}
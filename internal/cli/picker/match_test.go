@@ -0,0 +1,59 @@
+package picker
+
+import (
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var partials = []model.PartialSnippet{
+	{ID: "AAAAA", Title: "Restart deployment", Tags: []string{"k8s"}, Language: "bash"},
+	{ID: "BBBBB", Title: "Vacuum database", Tags: []string{"postgres"}, Language: "sql"},
+	{ID: "CCCCC", Title: "Port forward", Tags: []string{"k8s", "debug"}, Language: "bash"},
+	{ID: "DDDDD", Title: "port forward", Tags: []string{"ssh"}},
+}
+
+func TestMatch(t *testing.T) {
+	assert.Equal(t, partials, Match(partials, " "))
+
+	matches := Match(partials, "vacuum")
+	assert.Len(t, matches, 1)
+	assert.Equal(t, model.ID("BBBBB"), matches[0].ID)
+
+	// tags and languages are searched as well
+	matches = Match(partials, "postgres")
+	assert.Len(t, matches, 1)
+	matches = Match(partials, "k8s")
+	assert.Len(t, matches, 2)
+}
+
+func TestResolve(t *testing.T) {
+	partial, candidates, err := Resolve(partials, "bbbbb")
+	assert.Nil(t, err)
+	assert.Nil(t, candidates)
+	assert.Equal(t, model.ID("BBBBB"), partial.ID)
+
+	partial, _, err = Resolve(partials, "restart deployment")
+	assert.Nil(t, err)
+	assert.Equal(t, model.ID("AAAAA"), partial.ID)
+
+	partial, _, err = Resolve(partials, "vacuum")
+	assert.Nil(t, err)
+	assert.Equal(t, model.ID("BBBBB"), partial.ID)
+
+	// titles that are the same apart from case are ambiguous
+	_, candidates, err = Resolve(partials, "Port Forward")
+	assert.Nil(t, err)
+	assert.Len(t, candidates, 2)
+
+	_, _, err = Resolve(partials, "nothing like this")
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+}
+
+func TestExact(t *testing.T) {
+	assert.True(t, Exact(partials[0], "aaaaa"))
+	assert.True(t, Exact(partials[0], " restart deployment "))
+	assert.False(t, Exact(partials[0], "restart"))
+}
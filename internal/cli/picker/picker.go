@@ -0,0 +1,146 @@
+package picker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+)
+
+// ErrCancelled is returned by Pick when the user quits without picking a snippet.
+var ErrCancelled = errors.New("No snippet was picked")
+
+// visible is the number of matches shown at once.
+const visible = 10
+
+var (
+	cursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true)
+	dimStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+)
+
+// Pick shows an inline fuzzy finder over the snippets, starting with query, and returns the picked one.
+// It is drawn on stderr, so the output of the command can still be piped.
+func Pick(partials []model.PartialSnippet, query string) (model.PartialSnippet, error) {
+	result, err := tea.NewProgram(New(partials, query), tea.WithOutput(os.Stderr)).Run()
+	if err != nil {
+		return model.PartialSnippet{}, err
+	}
+	m := result.(Model)
+	if m.picked == nil {
+		return model.PartialSnippet{}, ErrCancelled
+	}
+	return *m.picked, nil
+}
+
+type Model struct {
+	partials []model.PartialSnippet
+	matches  []model.PartialSnippet
+	input    textinput.Model
+	cursor   int
+	picked   *model.PartialSnippet
+	done     bool
+}
+
+func New(partials []model.PartialSnippet, query string) Model {
+	input := textinput.New()
+	input.Prompt = "snippet> "
+	input.Placeholder = "title, tag or language"
+	input.SetValue(query)
+	input.CursorEnd()
+	input.Focus()
+
+	return Model{
+		partials: partials,
+		matches:  Match(partials, query),
+		input:    input,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+
+	switch key.String() {
+	case "esc", "ctrl+c":
+		m.done = true
+		return m, tea.Quit
+	case "enter":
+		if len(m.matches) == 0 {
+			return m, nil
+		}
+		m.picked = &m.matches[m.cursor]
+		m.done = true
+		return m, tea.Quit
+	case "up", "ctrl+p", "ctrl+k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+		return m, nil
+	case "down", "ctrl+n", "ctrl+j":
+		if m.cursor < len(m.matches)-1 {
+			m.cursor++
+		}
+		return m, nil
+	}
+
+	query := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != query {
+		m.matches = Match(m.partials, m.input.Value())
+		m.cursor = 0
+	}
+	return m, cmd
+}
+
+func (m Model) View() string {
+	// nothing is left on the terminal once a snippet is picked
+	if m.done {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(m.input.View() + "\n")
+
+	// the window of matches follows the cursor
+	start := 0
+	if m.cursor >= visible {
+		start = m.cursor - visible + 1
+	}
+	end := min(start+visible, len(m.matches))
+	for i := start; i < end; i++ {
+		partial := m.matches[i]
+		details := dimStyle.Render(describe(partial))
+		if i == m.cursor {
+			b.WriteString(cursorStyle.Render("> "+partial.Title) + "  " + details + "\n")
+		} else {
+			b.WriteString("  " + partial.Title + "  " + details + "\n")
+		}
+	}
+	b.WriteString(dimStyle.Render(fmt.Sprintf("%d/%d · ↑/↓ select · enter pick · esc cancel", len(m.matches), len(m.partials))))
+	return b.String()
+}
+
+func describe(partial model.PartialSnippet) string {
+	details := []string{partial.ID.String()}
+	if partial.Language != "" {
+		details = append(details, partial.Language)
+	}
+	if len(partial.Tags) > 0 {
+		details = append(details, strings.Join(partial.Tags, ", "))
+	}
+	return strings.Join(details, " · ")
+}
@@ -0,0 +1,49 @@
+package picker
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func send(m Model, msgs ...tea.Msg) Model {
+	for _, msg := range msgs {
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+	}
+	return m
+}
+
+func TestPicker(t *testing.T) {
+	m := New(partials, "")
+	assert.Len(t, m.matches, 4)
+	assert.Contains(t, m.View(), "4/4")
+
+	m = send(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k8s")})
+	assert.Len(t, m.matches, 2)
+	assert.Equal(t, 0, m.cursor)
+
+	m = send(m, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.True(t, m.done)
+	assert.Equal(t, m.matches[1], *m.picked)
+	assert.Equal(t, "", m.View())
+}
+
+func TestPicker_Cancel(t *testing.T) {
+	m := New(partials, "nothing like this")
+	assert.Empty(t, m.matches)
+
+	m = send(m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.False(t, m.done)
+
+	m = send(m, tea.KeyMsg{Type: tea.KeyEsc})
+	assert.True(t, m.done)
+	assert.Nil(t, m.picked)
+}
+
+func TestPicker_Query(t *testing.T) {
+	m := New(partials, "vacuum")
+	assert.Equal(t, []model.PartialSnippet{partials[1]}, m.matches)
+}
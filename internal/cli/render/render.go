@@ -0,0 +1,217 @@
+// Package render turns snippets into text for the terminal.
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/rivo/uniseg"
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/snippetaccumulator/snac/internal/cli/lang"
+)
+
+// DefaultStyle is the chroma style used when none is configured.
+const DefaultStyle = "monokai"
+
+// Lexer returns the lexer for a language, by its name or else by the file extension of the language.
+// It returns nil if the language is unknown.
+func Lexer(language string) chroma.Lexer {
+	language = strings.TrimSpace(language)
+	if language == "" {
+		return nil
+	}
+	lexer := lexers.Get(language)
+	if lexer == nil {
+		lexer = lexers.Match("snippet" + lang.Extension(language))
+	}
+	// unknown languages end up as plain text, which has nothing to highlight
+	if lexer == nil || lexer.Config().Name == "plaintext" {
+		return nil
+	}
+	return lexer
+}
+
+// Highlight returns content with terminal colors for its language in the chroma style.
+// Content of unknown languages is returned as is, unknown styles fall back to DefaultStyle.
+func Highlight(content, language, style string) (string, error) {
+	lexer := Lexer(language)
+	if lexer == nil {
+		return content, nil
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, content)
+	if err != nil {
+		return content, err
+	}
+	var result strings.Builder
+	err = formatters.TTY256.Format(&result, styleOrDefault(style), iterator)
+	if err != nil {
+		return content, err
+	}
+	return result.String(), nil
+}
+
+// Styles returns the names of all chroma styles.
+func Styles() []string {
+	return styles.Names()
+}
+
+// IsStyle reports if style is the name of a chroma style.
+func IsStyle(style string) bool {
+	_, ok := styles.Registry[style]
+	return ok
+}
+
+func styleOrDefault(style string) *chroma.Style {
+	chromaStyle := styles.Get(style)
+	if style == "" || chromaStyle == styles.Fallback {
+		chromaStyle = styles.Get(DefaultStyle)
+	}
+	return chromaStyle
+}
+
+// Options control how Content renders the content of a snippet.
+type Options struct {
+	// Style is the chroma style, see Highlight.
+	Style string
+	// Color enables syntax highlighting, leave it off for output that is not a terminal.
+	Color bool
+	// LineNumbers prefixes every line with its number.
+	LineNumbers bool
+}
+
+// Content renders the content of a snippet in the language for the terminal.
+// Every line is highlighted on its own, so line numbers do not end up inside a colored token.
+func Content(content, language string, options Options) (string, error) {
+	// placeholders are found by their offsets in the content, which lexers would shift by removing \r
+	content = strings.TrimRight(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	lines := strings.Split(content, "\n")
+	if options.Color {
+		highlighted, err := highlightLines(content, language, options.Style)
+		if err != nil {
+			return content, err
+		}
+		if highlighted != nil {
+			lines = highlighted
+		}
+	}
+	if !options.LineNumbers {
+		return strings.Join(lines, "\n"), nil
+	}
+
+	width := len(strconv.Itoa(len(lines)))
+	var result strings.Builder
+	for i, line := range lines {
+		number := fmt.Sprintf("%*d │ ", width, i+1)
+		if options.Color {
+			number = lineNumberColor + number + reset
+		}
+		result.WriteString(number)
+		result.WriteString(line)
+		if i < len(lines)-1 {
+			result.WriteString("\n")
+		}
+	}
+	return result.String(), nil
+}
+
+// placeholderToken marks the placeholders of a snippet between the tokens of its language.
+const placeholderToken chroma.TokenType = 9100
+
+const (
+	lineNumberColor = "\x1b[38;5;245m"
+	reset           = "\x1b[0m"
+)
+
+// highlightLines returns the highlighted lines of content, or nil if neither the language
+// is known nor the content has placeholders.
+func highlightLines(content, language, style string) ([]string, error) {
+	placeholders := model.Placeholders(content)
+	lexer := Lexer(language)
+	if lexer == nil && len(placeholders) == 0 {
+		return nil, nil
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	tokens, err := chroma.Tokenise(chroma.Coalesce(lexer), nil, content)
+	if err != nil {
+		return nil, err
+	}
+	tokens = markPlaceholders(tokens, placeholders)
+
+	chromaStyle, err := styleOrDefault(style).Builder().Add(placeholderToken, "bold underline").Build()
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range chroma.SplitTokensIntoLines(tokens) {
+		last := &line[len(line)-1]
+		last.Value = strings.TrimSuffix(last.Value, "\n")
+		var result strings.Builder
+		err = formatters.TTY256.Format(&result, chromaStyle, chroma.Literator(line...))
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, result.String())
+	}
+	return lines, nil
+}
+
+// markPlaceholders splits the tokens at the borders of the placeholders and gives the parts within them placeholderToken.
+func markPlaceholders(tokens []chroma.Token, placeholders []model.Placeholder) []chroma.Token {
+	if len(placeholders) == 0 {
+		return tokens
+	}
+	var marked []chroma.Token
+	offset, next := 0, 0
+	for _, token := range tokens {
+		value := token.Value
+		for value != "" {
+			for next < len(placeholders) && placeholders[next].End <= offset {
+				next++
+			}
+			length, tokenType := len(value), token.Type
+			if next < len(placeholders) {
+				if placeholder := placeholders[next]; offset < placeholder.Start {
+					length = min(length, placeholder.Start-offset)
+				} else {
+					length, tokenType = min(length, placeholder.End-offset), placeholderToken
+				}
+			}
+			if last := len(marked) - 1; tokenType == placeholderToken && last >= 0 && marked[last].Type == placeholderToken {
+				marked[last].Value += value[:length]
+			} else {
+				marked = append(marked, chroma.Token{Type: tokenType, Value: value[:length]})
+			}
+			value = value[length:]
+			offset += length
+		}
+	}
+	return marked
+}
+
+// Cut shortens content to at most limit characters and reports if it did.
+// Characters are counted as the user perceives them, so neither multi-byte runes
+// nor combined characters like emoji sequences are split apart. A negative limit keeps all of content.
+func Cut(content string, limit int) (string, bool) {
+	if limit < 0 {
+		return content, false
+	}
+	graphemes := uniseg.NewGraphemes(content)
+	end := 0
+	for count := 0; graphemes.Next(); count++ {
+		if count == limit {
+			return content[:end], true
+		}
+		_, end = graphemes.Positions()
+	}
+	return content, false
+}
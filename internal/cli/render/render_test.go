@@ -0,0 +1,112 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlight(t *testing.T) {
+	content := "package main\n\nfunc main() {}\n"
+
+	highlighted, err := Highlight(content, "go", "")
+	assert.Nil(t, err)
+	assert.Contains(t, highlighted, "\x1b[")
+	assert.Contains(t, stripANSI(highlighted), "func main() {}")
+
+	// aliases of snac are known too
+	highlighted, err = Highlight(content, "golang", "no-such-style")
+	assert.Nil(t, err)
+	assert.Contains(t, highlighted, "\x1b[")
+
+	plain, err := Highlight(content, "unknown-language", "")
+	assert.Nil(t, err)
+	assert.Equal(t, content, plain)
+}
+
+func stripANSI(s string) string {
+	var result strings.Builder
+	escape := false
+	for _, r := range s {
+		switch {
+		case r == '\x1b':
+			escape = true
+		case escape && r == 'm':
+			escape = false
+		case !escape:
+			result.WriteRune(r)
+		}
+	}
+	return result.String()
+}
+
+func TestContent(t *testing.T) {
+	content := "/* a comment\nover two lines */\nfunc main() {}\n"
+
+	plain, err := Content(content, "go", Options{})
+	assert.Nil(t, err)
+	assert.Equal(t, strings.TrimSuffix(content, "\n"), plain)
+
+	numbered, err := Content(content, "go", Options{LineNumbers: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "1 │ /* a comment\n2 │ over two lines */\n3 │ func main() {}", numbered)
+
+	highlighted, err := Content(content, "go", Options{Color: true, LineNumbers: true})
+	assert.Nil(t, err)
+	lines := strings.Split(highlighted, "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[1], "\x1b[")
+	assert.Equal(t, "2 │ over two lines */", stripANSI(lines[1]))
+
+	// unknown languages get line numbers without colors
+	numbered, err = Content(content, "unknown-language", Options{Color: true, LineNumbers: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "1 │ /* a comment\n2 │ over two lines */\n3 │ func main() {}", stripANSI(numbered))
+	assert.True(t, strings.HasSuffix(numbered, reset+"func main() {}"))
+}
+
+func TestCut(t *testing.T) {
+	cut, ok := Cut("hello world", 5)
+	assert.True(t, ok)
+	assert.Equal(t, "hello", cut)
+
+	cut, ok = Cut("hello", 5)
+	assert.False(t, ok)
+	assert.Equal(t, "hello", cut)
+
+	cut, ok = Cut("hello", -1)
+	assert.False(t, ok)
+	assert.Equal(t, "hello", cut)
+
+	// multi-byte runes and combined characters stay whole
+	cut, ok = Cut("héllo wörld", 7)
+	assert.True(t, ok)
+	assert.Equal(t, "héllo w", cut)
+
+	cut, ok = Cut("a👩‍💻b", 2)
+	assert.True(t, ok)
+	assert.Equal(t, "a👩‍💻", cut)
+
+	cut, ok = Cut("éx", 1)
+	assert.True(t, ok)
+	assert.Equal(t, "é", cut)
+}
+
+func TestContent_Placeholders(t *testing.T) {
+	content := "ssh ${user:root}@${host:}\n"
+
+	highlighted, err := Content(content, "bash", Options{Color: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "ssh ${user:root}@${host:}", stripANSI(highlighted))
+	assert.Regexp(t, `\x1b\[1m\x1b\[4m(\x1b\[[0-9;]*m)*\$\{host:\}\x1b`, highlighted)
+
+	// also in languages without a lexer
+	highlighted, err = Content(content, "unknown-language", Options{Color: true})
+	assert.Nil(t, err)
+	assert.Regexp(t, `\x1b\[1m\x1b\[4m(\x1b\[[0-9;]*m)*\$\{user:root\}\x1b`, highlighted)
+
+	plain, err := Content(content, "bash", Options{})
+	assert.Nil(t, err)
+	assert.Equal(t, "ssh ${user:root}@${host:}", plain)
+}
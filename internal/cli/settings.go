@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/cli/clipboard"
+	"github.com/snippetaccumulator/snac/internal/cli/render"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the names of the environment variables that override settings, e.g. SNAC_DATABASE_URL for database.url.
+const EnvPrefix = "SNAC_"
+
+// Field is a setting of the config, addressed by its key in the config file, e.g. database.url.
+// Settings of embedded structs have no prefix, team_name is the key of the team name.
+type Field struct {
+	Key string
+	// Env is the environment variable that overrides the setting.
+	Env   string
+	value reflect.Value
+}
+
+// secretKeys are the settings that are hidden when the config is shown.
+var secretKeys = map[string]bool{
+	"password":             true,
+	"session":              true,
+	"api_key":              true,
+	"instance_token":       true,
+	"database.auth_token":  true,
+	"database.session_key": true,
+}
+
+// Secret reports if the setting is a credential.
+func (f Field) Secret() bool {
+	return secretKeys[f.Key]
+}
+
+// Value returns the value of the setting as it is written in the config file.
+func (f Field) Value() string {
+	switch f.value.Kind() {
+	case reflect.Int:
+		return strconv.FormatInt(f.value.Int(), 10)
+	case reflect.Bool:
+		return strconv.FormatBool(f.value.Bool())
+	default:
+		return f.value.String()
+	}
+}
+
+// Set parses value for the setting. It returns an ErrInvalid error naming the key if value does not fit.
+func (f Field) Set(value string) error {
+	if err := f.set(value); err != nil {
+		return errs.Errorf(errs.ErrInvalid, "%s: %v", f.Key, err)
+	}
+	return nil
+}
+
+func (f Field) set(value string) error {
+	switch f.value.Kind() {
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("'%s' is not a number", value)
+		}
+		f.value.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("'%s' is neither true nor false", value)
+		}
+		f.value.SetBool(b)
+	default:
+		f.value.SetString(value)
+	}
+	return nil
+}
+
+// Fields returns the settings of the config in the order of the config file, without the profiles.
+func (c *Config) Fields() []Field {
+	return fields(reflect.ValueOf(c).Elem(), "")
+}
+
+// Fields returns the settings of the profile.
+func (p *Profile) Fields() []Field {
+	return fields(reflect.ValueOf(p).Elem(), "")
+}
+
+// Field returns the setting with the key, or an ErrInvalid error for an unknown key.
+func (c *Config) Field(key string) (Field, error) {
+	return field(c.Fields(), key)
+}
+
+// Field returns the setting of the profile with the key, or an ErrInvalid error for an unknown key.
+func (p *Profile) Field(key string) (Field, error) {
+	return field(p.Fields(), key)
+}
+
+func field(fields []Field, key string) (Field, error) {
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		if f.Key == key {
+			return f, nil
+		}
+		keys[i] = f.Key
+	}
+	return Field{}, errs.Errorf(errs.ErrInvalid, "%s: unknown setting, use one of: %s", key, strings.Join(keys, ", "))
+}
+
+// fields walks the yaml keys of the struct v, embedded structs are flattened and maps are left out.
+func fields(v reflect.Value, prefix string) []Field {
+	var result []Field
+	for i := 0; i < v.NumField(); i++ {
+		structField := v.Type().Field(i)
+		name, options, _ := strings.Cut(structField.Tag.Get("yaml"), ",")
+		value := v.Field(i)
+		if structField.Anonymous || options == "inline" {
+			result = append(result, fields(value, prefix)...)
+			continue
+		}
+		if name == "" || name == "-" || !structField.IsExported() {
+			continue
+		}
+		key := prefix + name
+		switch value.Kind() {
+		case reflect.Struct:
+			result = append(result, fields(value, key+".")...)
+		case reflect.String, reflect.Int, reflect.Bool:
+			result = append(result, Field{Key: key, Env: EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_")), value: value})
+		}
+	}
+	return result
+}
+
+// Defaults returns the config used for the settings that neither the config file, the environment nor flags set.
+func Defaults() Config {
+	return Config{
+		LogLevel:  "INFO",
+		Theme:     render.DefaultStyle,
+		Clipboard: clipboard.Auto,
+	}
+}
+
+// Load returns the config in effect, from lowest to highest precedence:
+// the defaults, the config file at path if there is one, the profile and the SNAC_* environment variables.
+// The profile is the one with the name, else the one of SNAC_PROFILE, else the current one of the file.
+// lookup is usually os.LookupEnv. Errors name the key or the environment variable that is invalid.
+func Load(path, profile string, lookup func(string) (string, bool)) (Config, error) {
+	config := Defaults()
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return config, err
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return config, fileError(data, err)
+		}
+	}
+
+	if err := config.UseProfile(ProfileName(profile, lookup)); err != nil {
+		return config, err
+	}
+	return config, config.ApplyEnv(lookup)
+}
+
+// ProfileName returns the profile picked by the flag, SNAC_PROFILE if the flag is empty.
+// "" leaves the choice to the profile key of the config file.
+func ProfileName(flag string, lookup func(string) (string, bool)) string {
+	if flag != "" {
+		return flag
+	}
+	profile, _ := lookup(EnvPrefix + "PROFILE")
+	return profile
+}
+
+// ApplyEnv overrides the settings with the environment variables that are set, e.g. SNAC_TEAM_NAME.
+// SNAC_PROFILE is left to Load, which picks the profile before anything else.
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	for _, f := range c.Fields() {
+		value, ok := lookup(f.Env)
+		if !ok || f.Key == "profile" {
+			continue
+		}
+		if err := f.set(value); err != nil {
+			return errs.Errorf(errs.ErrInvalid, "%s (%s): %v", f.Env, f.Key, err)
+		}
+	}
+	return nil
+}
+
+var yamlLinePattern = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// fileError names the keys of the config file that an error of yaml.Unmarshal is about.
+func fileError(data []byte, err error) error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return errs.Errorf(errs.ErrInvalid, "Config file is not valid YAML: %v", err)
+	}
+	var root yaml.Node
+	_ = yaml.Unmarshal(data, &root)
+
+	var failures []error
+	for _, message := range typeErr.Errors {
+		match := yamlLinePattern.FindStringSubmatch(message)
+		if match == nil {
+			failures = append(failures, errs.New(errs.ErrInvalid, message))
+			continue
+		}
+		line, _ := strconv.Atoi(match[1])
+		key := keyAt(&root, line, "")
+		if key == "" {
+			failures = append(failures, errs.New(errs.ErrInvalid, message))
+			continue
+		}
+		failures = append(failures, errs.Errorf(errs.ErrInvalid, "%s: %s (line %d)", key, match[2], line))
+	}
+	return errors.Join(failures...)
+}
+
+// keyAt returns the key of the value at the line of the YAML document node, in the flattened form of Fields.
+func keyAt(node *yaml.Node, line int, prefix string) string {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if key := keyAt(child, line, prefix); key != "" {
+				return key
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name, value := node.Content[i].Value, node.Content[i+1]
+			key := prefix + name + "."
+			// the embedded common config is written as commonconfig, its keys are flattened
+			if prefix == "" && name == "commonconfig" {
+				key = ""
+			}
+			if value.Kind == yaml.MappingNode {
+				if found := keyAt(value, line, key); found != "" {
+					return found
+				}
+				continue
+			}
+			if node.Content[i].Line == line || value.Line == line {
+				return prefix + name
+			}
+		}
+	}
+	return ""
+}
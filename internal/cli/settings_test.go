@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/cli/clipboard"
+	"github.com/snippetaccumulator/snac/internal/cli/render"
+	"github.com/stretchr/testify/assert"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestFields(t *testing.T) {
+	var config Config
+	keys := map[string]string{}
+	for _, field := range config.Fields() {
+		keys[field.Key] = field.Env
+	}
+	assert.Equal(t, "SNAC_TEAM_NAME", keys["team_name"])
+	assert.Equal(t, "SNAC_DATABASE_URL", keys["database.url"])
+	assert.Equal(t, "SNAC_DATABASE_PASSWORD_POLICY_BCRYPT_COST", keys["database.password_policy.bcrypt_cost"])
+	assert.Equal(t, "SNAC_LOG_LEVEL", keys["log_level"])
+	assert.Equal(t, "SNAC_PROFILE", keys["profile"])
+	assert.NotContains(t, keys, "profiles")
+
+	var profile Profile
+	field, err := profile.Field("server")
+	assert.Nil(t, err)
+	assert.Nil(t, field.Set("https://snac.example.com"))
+	assert.Equal(t, "https://snac.example.com", profile.Server)
+	_, err = profile.Field("log_level")
+	assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+}
+
+func TestFieldSet(t *testing.T) {
+	var config Config
+	field, err := config.Field("database.password_policy.min_length")
+	assert.Nil(t, err)
+	assert.Nil(t, field.Set("12"))
+	assert.Equal(t, 12, config.Database.PasswordPolicy.MinLength)
+	assert.Equal(t, "12", field.Value())
+
+	field, err = config.Field("database.password_policy.allow_same_admin")
+	assert.Nil(t, err)
+	assert.Nil(t, field.Set("true"))
+	assert.True(t, config.Database.PasswordPolicy.AllowSameAdmin)
+
+	err = field.Set("maybe")
+	assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+	assert.Contains(t, err.Error(), "database.password_policy.allow_same_admin")
+
+	_, err = config.Field("team")
+	assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+	assert.Contains(t, err.Error(), "team_name")
+}
+
+func TestFieldSecret(t *testing.T) {
+	var config Config
+	for key, secret := range map[string]bool{"password": true, "database.auth_token": true, "instance_token": true, "team_name": false, "server": false} {
+		field, err := config.Field(key)
+		assert.Nil(t, err)
+		assert.Equal(t, secret, field.Secret(), key)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	config, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), "", env(nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "INFO", config.LogLevel)
+	assert.Equal(t, render.DefaultStyle, config.Theme)
+	assert.Equal(t, clipboard.Auto, config.Clipboard)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfig(t, `commonconfig:
+  team_name: file
+  database:
+    url: libsql://file
+log_level: WARN
+profiles:
+  work:
+    team_name: ops
+    server: https://work.example.com
+`)
+
+	config, err := Load(path, "", env(nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "file", config.TeamName)
+	assert.Equal(t, "WARN", config.LogLevel)
+	assert.Equal(t, render.DefaultStyle, config.Theme)
+
+	config, err = Load(path, "", env(map[string]string{"SNAC_TEAM_NAME": "ci", "SNAC_DATABASE_URL": "libsql://ci"}))
+	assert.Nil(t, err)
+	assert.Equal(t, "ci", config.TeamName)
+	assert.Equal(t, "libsql://ci", config.Database.Url)
+
+	config, err = Load(path, "", env(map[string]string{"SNAC_PROFILE": "work"}))
+	assert.Nil(t, err)
+	assert.Equal(t, "ops", config.TeamName)
+	assert.Equal(t, "https://work.example.com", config.Server)
+
+	config, err = Load(path, "work", env(map[string]string{"SNAC_PROFILE": "home", "SNAC_TEAM_NAME": "ci"}))
+	assert.Nil(t, err)
+	assert.Equal(t, "work", config.Profile)
+	assert.Equal(t, "ci", config.TeamName)
+
+	_, err = Load(path, "home", env(nil))
+	assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+}
+
+func TestLoadErrors(t *testing.T) {
+	_, err := Load(writeConfig(t, ""), "", env(map[string]string{"SNAC_DATABASE_PASSWORD_POLICY_BCRYPT_COST": "high"}))
+	assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+	assert.Contains(t, err.Error(), "SNAC_DATABASE_PASSWORD_POLICY_BCRYPT_COST (database.password_policy.bcrypt_cost)")
+
+	_, err = Load(writeConfig(t, "commonconfig:\n  database:\n    password_policy:\n      min_length: many\n"), "", env(nil))
+	assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+	assert.Contains(t, err.Error(), "database.password_policy.min_length:")
+	assert.Contains(t, err.Error(), "(line 4)")
+
+	_, err = Load(writeConfig(t, "profiles:\n  work:\n    team_name: [a, b]\n"), "", env(nil))
+	assert.Contains(t, err.Error(), "profiles.work.team_name:")
+
+	_, err = Load(writeConfig(t, "theme: [\n"), "", env(nil))
+	assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+	assert.Contains(t, err.Error(), "not valid YAML")
+}
+
+func TestProfileName(t *testing.T) {
+	lookup := env(map[string]string{"SNAC_PROFILE": "work"})
+
+	assert.Equal(t, "home", ProfileName("home", lookup))
+	assert.Equal(t, "work", ProfileName("", lookup))
+	assert.Equal(t, "", ProfileName("", env(nil)))
+}
@@ -0,0 +1,69 @@
+// Package suggest proposes the languages and tags a team uses already, e.g. when a snippet is created.
+package suggest
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+)
+
+// Languages returns the languages used by the snippets, the most used first.
+// Languages that only differ in case are counted as one, in the spelling used most.
+func Languages(partials []model.PartialSnippet) []string {
+	var values []string
+	for _, partial := range partials {
+		values = append(values, partial.Language)
+	}
+	return byUsage(values)
+}
+
+// Tags returns the tags used by the snippets, the most used first.
+func Tags(partials []model.PartialSnippet) []string {
+	var values []string
+	for _, partial := range partials {
+		values = append(values, partial.Tags...)
+	}
+	return byUsage(values)
+}
+
+// byUsage returns the distinct non-empty values, the most frequent first and alphabetically on ties.
+func byUsage(values []string) []string {
+	counts := map[string]int{}
+	spellings := map[string]map[string]int{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		key := strings.ToLower(value)
+		counts[key]++
+		if spellings[key] == nil {
+			spellings[key] = map[string]int{}
+		}
+		spellings[key][value]++
+	}
+
+	result := make([]string, 0, len(counts))
+	for key := range counts {
+		result = append(result, mostUsed(spellings[key]))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := counts[strings.ToLower(result[i])], counts[strings.ToLower(result[j])]
+		if a != b {
+			return a > b
+		}
+		return result[i] < result[j]
+	})
+	return result
+}
+
+func mostUsed(spellings map[string]int) string {
+	best := ""
+	for spelling, count := range spellings {
+		if best == "" || count > spellings[best] || (count == spellings[best] && spelling < best) {
+			best = spelling
+		}
+	}
+	return best
+}
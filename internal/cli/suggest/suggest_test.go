@@ -0,0 +1,32 @@
+package suggest
+
+import (
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanguages(t *testing.T) {
+	partials := []model.PartialSnippet{
+		{Language: "go"},
+		{Language: "SQL"},
+		{Language: "sql"},
+		{Language: "sql"},
+		{Language: ""},
+		{Language: "bash"},
+	}
+
+	assert.Equal(t, []string{"sql", "bash", "go"}, Languages(partials))
+	assert.Empty(t, Languages(nil))
+}
+
+func TestTags(t *testing.T) {
+	partials := []model.PartialSnippet{
+		{Tags: []string{"k8s", "ops"}},
+		{Tags: []string{"ops", " ci "}},
+		{Tags: []string{}},
+	}
+
+	assert.Equal(t, []string{"ops", "ci", "k8s"}, Tags(partials))
+}
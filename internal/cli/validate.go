@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/snippetaccumulator/snac/internal/cli/clipboard"
+	"github.com/snippetaccumulator/snac/internal/cli/render"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+var logLevels = []string{"DEBUG", "INFO", "WARN", "SUCCESS", "ERROR"}
+
+// Validate checks the settings that are set, including those of the profiles.
+// It returns an ErrInvalid error naming the key of every invalid setting, settings that are not set are skipped.
+func (c *Config) Validate() error {
+	var failures []error
+	invalid := func(key, format string, a ...any) {
+		failures = append(failures, errs.Errorf(errs.ErrInvalid, "%s: "+format, append([]any{key}, a...)...))
+	}
+
+	if c.LogLevel != "" && !slices.Contains(logLevels, strings.ToUpper(c.LogLevel)) {
+		invalid("log_level", "'%s' is not a log level, use one of: %s", c.LogLevel, strings.Join(logLevels, ", "))
+	}
+	if c.Theme != "" && !render.IsStyle(c.Theme) {
+		invalid("theme", "'%s' is not a chroma style", c.Theme)
+	}
+	if names := clipboard.Names(clipboard.Backends()); c.Clipboard != "" && !slices.Contains(names, c.Clipboard) {
+		invalid("clipboard", "'%s' is not a clipboard backend, use one of: %s", c.Clipboard, strings.Join(names, ", "))
+	}
+	if c.Profile != "" {
+		if _, ok := c.Profiles[c.Profile]; !ok {
+			invalid("profile", "there is no profile '%s'", c.Profile)
+		}
+	}
+	validateProfile(c.CurrentProfile(), "", invalid)
+	for _, name := range c.ProfileNames() {
+		if profile := c.Profiles[name]; profile != nil {
+			validateProfile(*profile, "profiles."+name+".", invalid)
+		}
+	}
+	return errors.Join(failures...)
+}
+
+// validateProfile checks team, credentials and backend, prefix leads the keys of the profile.
+func validateProfile(profile Profile, prefix string, invalid func(key, format string, a ...any)) {
+	if profile.Server != "" && !isURL(profile.Server, "http", "https") {
+		invalid(prefix+"server", "'%s' is not an http or https URL", profile.Server)
+	}
+	if profile.Database.Url != "" && !isURL(profile.Database.Url, "libsql", "http", "https", "ws", "wss", "file") {
+		invalid(prefix+"database.url", "'%s' is not a libsql, http, https, ws, wss or file URL", profile.Database.Url)
+	}
+	policy := profile.Database.PasswordPolicy
+	if policy.MinLength < 0 {
+		invalid(prefix+"database.password_policy.min_length", "must not be negative")
+	}
+	if policy.Cost != 0 && (policy.Cost < bcrypt.MinCost || policy.Cost > bcrypt.MaxCost) {
+		invalid(prefix+"database.password_policy.bcrypt_cost", "must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+}
+
+func isURL(value string, schemes ...string) bool {
+	parsed, err := url.Parse(value)
+	if err != nil || !slices.Contains(schemes, parsed.Scheme) {
+		return false
+	}
+	return parsed.Scheme == "file" || parsed.Host != ""
+}
+
+// CheckKeys returns an ErrInvalid error naming every key of the config file data that snac does not know,
+// e.g. because of a typo.
+func CheckKeys(data []byte) error {
+	var config Config
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder.KnownFields(true)
+	err := decoder.Decode(&config)
+	if err == nil || err.Error() == "EOF" {
+		return nil
+	}
+	return fileError(data, err)
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	config := testConfig()
+	assert.Nil(t, config.Validate())
+
+	config = Defaults()
+	assert.Nil(t, config.Validate())
+
+	config = testConfig()
+	config.LogLevel = "LOUD"
+	config.Theme = "no-such-style"
+	config.Clipboard = "carrier-pigeon"
+	config.Server = "snac.example.com"
+	config.Database.PasswordPolicy.Cost = 2
+	config.Profiles["home"].Database.Url = "home"
+	err := config.Validate()
+	assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+	for _, key := range []string{"log_level:", "theme:", "clipboard:", "server:", "database.password_policy.bcrypt_cost:", "profiles.home.database.url:"} {
+		assert.Contains(t, err.Error(), key)
+	}
+
+	config = testConfig()
+	config.Profile = "gone"
+	err = config.Validate()
+	assert.Contains(t, err.Error(), "profile: there is no profile 'gone'")
+}
+
+func TestCheckKeys(t *testing.T) {
+	assert.Nil(t, CheckKeys([]byte("")))
+	assert.Nil(t, CheckKeys([]byte("commonconfig:\n  team_name: ops\nlog_level: INFO\n")))
+
+	err := CheckKeys([]byte("commonconfig:\n  team_nam: ops\nlog_level: INFO\n"))
+	assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+	assert.Contains(t, err.Error(), "team_nam")
+}
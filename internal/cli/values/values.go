@@ -0,0 +1,70 @@
+// Package values remembers the values last used for the placeholders of snippets, so they can be proposed next time.
+package values
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/snippetaccumulator/snac/internal/backend/model"
+)
+
+// Store holds the values per snippet in a JSON file.
+type Store struct {
+	path   string
+	values map[string]map[string]string
+}
+
+// Open reads the store at path, a missing file is an empty store.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, values: map[string]map[string]string{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, json.Unmarshal(data, &s.values)
+}
+
+// Last returns the values last used for the placeholders of the snippet, by their names.
+func (s *Store) Last(team string, id model.ID) map[string]string {
+	last := map[string]string{}
+	for name, value := range s.values[key(team, id)] {
+		last[name] = value
+	}
+	return last
+}
+
+// Remember saves the values for the snippet, values of other placeholders are kept.
+// The file is only readable by the user, as values can be hostnames or user names.
+func (s *Store) Remember(team string, id model.ID, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	snippet := s.values[key(team, id)]
+	if snippet == nil {
+		snippet = map[string]string{}
+		s.values[key(team, id)] = snippet
+	}
+	for name, value := range values {
+		snippet[name] = value
+	}
+
+	data, err := json.MarshalIndent(s.values, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(s.path), 0700)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
+
+func key(team string, id model.ID) string {
+	return team + "/" + string(id)
+}
@@ -0,0 +1,38 @@
+package values
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snac", "values.json")
+
+	store, err := Open(path)
+	assert.Nil(t, err)
+	assert.Empty(t, store.Last("team", "abcde"))
+
+	err = store.Remember("team", "abcde", map[string]string{"host": "example.com", "port": "22"})
+	assert.Nil(t, err)
+	err = store.Remember("team", "abcde", map[string]string{"port": "2222"})
+	assert.Nil(t, err)
+	err = store.Remember("other", "abcde", map[string]string{"host": "other.com"})
+	assert.Nil(t, err)
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	store, err = Open(path)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"host": "example.com", "port": "2222"}, store.Last("team", "abcde"))
+	assert.Equal(t, map[string]string{"host": "other.com"}, store.Last("other", "abcde"))
+
+	// changing the values returned does not change the store
+	last := store.Last("team", "abcde")
+	last["host"] = "changed"
+	assert.Equal(t, "example.com", store.Last("team", "abcde")["host"])
+}
@@ -0,0 +1,296 @@
+// Package vault keeps credentials in a file encrypted with a passphrase or a key file.
+//
+// The file is JSON with the parameters of the key derivation and the credentials sealed with AES-256-GCM.
+// The key is derived from the passphrase or the content of the key file with Argon2id and a random salt.
+// The file and the key file must only be readable by the user, looser permissions are refused.
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// Format names credential stores of snac in their file.
+	Format = "snac-vault"
+	// Version is the version of the file layout that is written, stores of newer versions cannot be opened.
+	Version = 1
+	// KeyFileSize is the number of random bytes of a new key file, and the least a key file must have.
+	KeyFileSize = 32
+
+	keySize  = 32
+	saltSize = 16
+	// maxMemory caps the memory in KiB a store may ask Argon2id for, 1 GiB, so a damaged file cannot exhaust it.
+	maxMemory = 1024 * 1024
+	// maxTime caps the passes over the memory a store may ask for.
+	maxTime = 16
+)
+
+// KDF are the Argon2id parameters the key of a store is derived with.
+type KDF struct {
+	Salt    []byte `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+}
+
+// file is a store as it is written, the sealed credentials are authenticated together with format and version.
+type file struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	KDF     KDF    `json:"kdf"`
+	Nonce   []byte `json:"nonce"`
+	Sealed  []byte `json:"sealed"`
+}
+
+// Store holds credentials by scope and key, e.g. the password of the profile work.
+// Changes are only written by Save.
+type Store struct {
+	path    string
+	kdf     KDF
+	key     []byte
+	secrets map[string]map[string]string
+}
+
+// Create creates an empty store at path, locked with secret, the passphrase or the content of a key file.
+// It returns an ErrConflict error if there is a store already.
+func Create(path string, secret []byte) (*Store, error) {
+	if Exists(path) {
+		return nil, errs.Errorf(errs.ErrConflict, "There is a credential store at %s already", path)
+	}
+	if len(secret) == 0 {
+		return nil, errs.New(errs.ErrInvalid, "The passphrase of the credential store must not be empty")
+	}
+	kdf := KDF{Salt: make([]byte, saltSize), Time: 1, Memory: 64 * 1024, Threads: 4}
+	if _, err := rand.Read(kdf.Salt); err != nil {
+		return nil, err
+	}
+	store := &Store{path: path, kdf: kdf, key: kdf.derive(secret), secrets: map[string]map[string]string{}}
+	return store, store.Save()
+}
+
+// Open unlocks the store at path with secret. It returns an ErrUnauthorized error for a wrong secret,
+// and an ErrInvalid error if the file is readable by others or is no store of a known version.
+func Open(path string, secret []byte) (*Store, error) {
+	if err := CheckPermissions(path); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var stored file
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Format != Format {
+		return nil, errs.Errorf(errs.ErrInvalid, "%s is not a credential store of snac", path)
+	}
+	if stored.Version > Version {
+		return nil, errs.Errorf(errs.ErrInvalid, "The credential store at %s has version %d, this snac reads up to version %d", path, stored.Version, Version)
+	}
+
+	if err := stored.KDF.check(); err != nil {
+		return nil, errs.Errorf(errs.ErrInvalid, "The credential store at %s is damaged: %v", path, err)
+	}
+
+	store := &Store{path: path, kdf: stored.KDF, key: stored.KDF.derive(secret)}
+	aead, err := store.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(stored.Nonce) != aead.NonceSize() {
+		return nil, errs.Errorf(errs.ErrInvalid, "The credential store at %s is damaged: the nonce has %d bytes instead of %d", path, len(stored.Nonce), aead.NonceSize())
+	}
+	plain, err := aead.Open(nil, stored.Nonce, stored.Sealed, header(stored.Format, stored.Version))
+	if err != nil {
+		return nil, errs.Errorf(errs.ErrUnauthorized, "Could not unlock the credential store at %s, the passphrase or key file is wrong", path)
+	}
+	if err := json.Unmarshal(plain, &store.secrets); err != nil {
+		return nil, errs.Errorf(errs.ErrInvalid, "The credential store at %s is damaged: %v", path, err)
+	}
+	if store.secrets == nil {
+		store.secrets = map[string]map[string]string{}
+	}
+	return store, nil
+}
+
+// Save encrypts the credentials with a new nonce and replaces the file, which only the user can read.
+func (s *Store) Save() error {
+	plain, err := json.Marshal(s.secrets)
+	if err != nil {
+		return err
+	}
+	aead, err := s.aead()
+	if err != nil {
+		return err
+	}
+	stored := file{Format: Format, Version: Version, KDF: s.kdf, Nonce: make([]byte, aead.NonceSize())}
+	if _, err := rand.Read(stored.Nonce); err != nil {
+		return err
+	}
+	stored.Sealed = aead.Seal(nil, stored.Nonce, plain, header(stored.Format, stored.Version))
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writePrivate(s.path, data)
+}
+
+// Path returns the path of the store file.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Get returns the credential with the key in the scope, "" if there is none.
+func (s *Store) Get(scope, key string) string {
+	return s.secrets[scope][key]
+}
+
+// Scope returns the credentials of the scope by key.
+func (s *Store) Scope(scope string) map[string]string {
+	return s.secrets[scope]
+}
+
+// SetScope replaces the credentials of the scope, empty values are left out and an empty scope is removed.
+func (s *Store) SetScope(scope string, secrets map[string]string) {
+	kept := map[string]string{}
+	for key, value := range secrets {
+		if value != "" {
+			kept[key] = value
+		}
+	}
+	if len(kept) == 0 {
+		delete(s.secrets, scope)
+		return
+	}
+	s.secrets[scope] = kept
+}
+
+// Scopes returns the scopes with credentials, sorted.
+func (s *Store) Scopes() []string {
+	scopes := make([]string, 0, len(s.secrets))
+	for scope := range s.secrets {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+func (s *Store) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// check returns an error if the parameters would make Argon2id panic or use more than the caps.
+func (k KDF) check() error {
+	switch {
+	case len(k.Salt) != saltSize:
+		return fmt.Errorf("the salt has %d bytes instead of %d", len(k.Salt), saltSize)
+	case k.Time < 1 || k.Time > maxTime:
+		return fmt.Errorf("the time %d is not between 1 and %d", k.Time, maxTime)
+	case k.Threads < 1:
+		return fmt.Errorf("the threads must be at least 1")
+	case k.Memory < 8*uint32(k.Threads) || k.Memory > maxMemory:
+		return fmt.Errorf("the memory %d KiB is not between %d and %d", k.Memory, 8*uint32(k.Threads), maxMemory)
+	}
+	return nil
+}
+
+func (k KDF) derive(secret []byte) []byte {
+	return argon2.IDKey(secret, k.Salt, k.Time, k.Memory, k.Threads, keySize)
+}
+
+func header(format string, version int) []byte {
+	return []byte(fmt.Sprintf("%s/%d", format, version))
+}
+
+// Exists reports if there is a file at path.
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// CheckPermissions returns an ErrInvalid error if the file at path can be read or written by anyone but the user.
+func CheckPermissions(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return errs.Errorf(errs.ErrInvalid, "%s has the permissions %s, only the user may read it (chmod 600 %s)", path, info.Mode().Perm(), path)
+	}
+	return nil
+}
+
+// ReadKeyFile returns the content of the key file at path after checking its permissions and size.
+func ReadKeyFile(path string) ([]byte, error) {
+	if err := CheckPermissions(path); err != nil {
+		return nil, err
+	}
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) < KeyFileSize {
+		return nil, errs.Errorf(errs.ErrInvalid, "The key file %s has %d bytes, it needs at least %d", path, len(key), KeyFileSize)
+	}
+	return key, nil
+}
+
+// NewKeyFile writes a key file of random bytes to path, which only the user can read.
+// It returns an ErrConflict error if there is a file already.
+func NewKeyFile(path string) error {
+	key := make([]byte, KeyFileSize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		return errs.Errorf(errs.ErrConflict, "There is a file at %s already", path)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(key)
+	return errors.Join(err, f.Close())
+}
+
+// writePrivate replaces the file at path with data through a temporary file, so a failed write keeps the old one.
+func writePrivate(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	// CreateTemp creates files only the user can read, Chmod makes sure of it
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,108 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/snippetaccumulator/snac/internal/backend/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.vault")
+	store, err := Create(path, []byte("passphrase"))
+	assert.Nil(t, err)
+	store.SetScope("", map[string]string{"password": "secret", "session": ""})
+	store.SetScope("work", map[string]string{"api_key": "key"})
+	assert.Nil(t, store.Save())
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "secret")
+
+	opened, err := Open(path, []byte("passphrase"))
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", opened.Get("", "password"))
+	assert.Equal(t, map[string]string{"password": "secret"}, opened.Scope(""))
+	assert.Equal(t, []string{"", "work"}, opened.Scopes())
+
+	_, err = Open(path, []byte("wrong"))
+	assert.Equal(t, errs.ErrUnauthorized, errs.KindOf(err))
+
+	_, err = Create(path, []byte("passphrase"))
+	assert.Equal(t, errs.ErrConflict, errs.KindOf(err))
+}
+
+func TestSetScope(t *testing.T) {
+	store, err := Create(filepath.Join(t.TempDir(), "credentials.vault"), []byte("passphrase"))
+	assert.Nil(t, err)
+	store.SetScope("work", map[string]string{"password": "secret"})
+	store.SetScope("work", map[string]string{"password": ""})
+	assert.Empty(t, store.Scopes())
+	assert.Equal(t, "", store.Get("work", "password"))
+}
+
+func TestOpenRefusesChangedFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.vault")
+	_, err := Create(path, []byte("passphrase"))
+	assert.Nil(t, err)
+
+	assert.Nil(t, os.Chmod(path, 0644))
+	_, err = Open(path, []byte("passphrase"))
+	assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+	assert.Nil(t, os.Chmod(path, 0600))
+
+	assert.Nil(t, os.WriteFile(path, []byte(`{"format":"snac-vault","version":2}`), 0600))
+	_, err = Open(path, []byte("passphrase"))
+	assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+
+	assert.Nil(t, os.WriteFile(path, []byte("password: secret"), 0600))
+	_, err = Open(path, []byte("passphrase"))
+	assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+
+	// parameters that would make the key derivation or decryption panic are refused as damage
+	salt := `"AAAAAAAAAAAAAAAAAAAAAA=="`
+	for _, kdf := range []string{
+		`{"salt":` + salt + `,"time":0,"memory":65536,"threads":4}`,
+		`{"salt":` + salt + `,"time":1,"memory":65536,"threads":0}`,
+		`{"salt":` + salt + `,"time":1,"memory":4294967295,"threads":4}`,
+		`{"salt":"AAAA","time":1,"memory":65536,"threads":4}`,
+	} {
+		data := `{"format":"snac-vault","version":1,"kdf":` + kdf + `,"nonce":"AAAAAAAAAAAAAAAA","sealed":"AAAA"}`
+		assert.Nil(t, os.WriteFile(path, []byte(data), 0600))
+		_, err = Open(path, []byte("passphrase"))
+		assert.ErrorContains(t, err, "damaged", kdf)
+		assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+	}
+	data := `{"format":"snac-vault","version":1,"kdf":{"salt":` + salt + `,"time":1,"memory":64,"threads":1},"nonce":"AAAA","sealed":"AAAA"}`
+	assert.Nil(t, os.WriteFile(path, []byte(data), 0600))
+	_, err = Open(path, []byte("passphrase"))
+	assert.ErrorContains(t, err, "damaged")
+}
+
+func TestKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "snac.key")
+	assert.Nil(t, NewKeyFile(path))
+	key, err := ReadKeyFile(path)
+	assert.Nil(t, err)
+	assert.Len(t, key, KeyFileSize)
+	assert.Equal(t, errs.ErrConflict, errs.KindOf(NewKeyFile(path)))
+
+	store, err := Create(filepath.Join(t.TempDir(), "credentials.vault"), key)
+	assert.Nil(t, err)
+	_, err = Open(store.Path(), key)
+	assert.Nil(t, err)
+
+	assert.Nil(t, os.Chmod(path, 0640))
+	_, err = ReadKeyFile(path)
+	assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+
+	short := filepath.Join(t.TempDir(), "short.key")
+	assert.Nil(t, os.WriteFile(short, []byte("too short"), 0600))
+	_, err = ReadKeyFile(short)
+	assert.Equal(t, errs.ErrInvalid, errs.KindOf(err))
+}
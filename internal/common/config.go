@@ -1,13 +1,23 @@
 package common
 
+import "github.com/snippetaccumulator/snac/internal/backend/model"
+
 type Database struct {
 	Name      string `yaml:"name" json:"name"`
 	Url       string `yaml:"url" json:"url"`
 	AuthToken string `yaml:"auth_token" json:"auth_token"`
+	// SessionKey signs session tokens. If empty, a key derived from AuthToken is used.
+	SessionKey string `yaml:"session_key" json:"session_key"`
+	// PasswordPolicy applies to the passwords of new teams and to rotated passwords.
+	PasswordPolicy model.PasswordPolicy `yaml:"password_policy" json:"password_policy"`
 }
 
 type CommonConfig struct {
-	TeamName string   `yaml:"team_name" json:"team_name"`
-	Password string   `yaml:"password" json:"password"`
+	TeamName string `yaml:"team_name" json:"team_name"`
+	Password string `yaml:"password" json:"password"`
+	// Session is a session token used instead of Password, see snac login.
+	Session string `yaml:"session" json:"session"`
+	// APIKey is used instead of Password by automation, see snac team keys.
+	APIKey   string   `yaml:"api_key" json:"api_key"`
 	Database Database `yaml:"database" json:"database"`
 }
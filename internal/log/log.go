@@ -1,6 +1,7 @@
 package log
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -153,11 +154,25 @@ func Success(format string, a ...any) {
 	log(SUCCESS, format, a...)
 }
 
+// exitCoder is implemented by errors that decide the exit code, like the kinds of the errs package.
+type exitCoder interface {
+	ExitCode() int
+}
+
+// Err logs err and exits with the exit code of its kind if exit is set, 1 if it has none.
 func Err(exit bool, err error) {
 	if err == nil {
 		return
 	}
-	Error(exit, err.Error())
+	log(ERROR, "%s", err.Error())
+	if exit {
+		code := 1
+		var coder exitCoder
+		if errors.As(err, &coder) {
+			code = coder.ExitCode()
+		}
+		os.Exit(code)
+	}
 }
 
 func Error(exit bool, format string, a ...any) {
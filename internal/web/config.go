@@ -0,0 +1,13 @@
+package web
+
+import "github.com/snippetaccumulator/snac/internal/common"
+
+type Config struct {
+	Address  string          `yaml:"address" json:"address"`
+	Database common.Database `yaml:"database" json:"database"`
+	LogLevel string          `yaml:"log_level" json:"log_level"`
+	// InstanceToken grants the instance-admin role, which is needed to create teams. Empty disables it.
+	InstanceToken string `yaml:"instance_token" json:"instance_token"`
+	// RequestsPerMinute limits the requests per team, 0 disables the limit.
+	RequestsPerMinute int `yaml:"requests_per_minute" json:"requests_per_minute"`
+}